@@ -1,21 +1,34 @@
 package analyzer
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log"
 	"net/http"
+	"strings"
+	"sync"
 	"time"
+	"unicode/utf8"
 
+	"github.com/Catker/chaoleme/collector"
 	"github.com/Catker/chaoleme/config"
 )
 
+// ErrAICircuitOpen AI 调用处于熔断期，直接降级为规则评分
+var ErrAICircuitOpen = errors.New("AI 接口连续失败，熔断期间跳过调用")
+
 // AIAnalyzer AI 分析器
 type AIAnalyzer struct {
-	client *http.Client
-	config *config.AIConfig
+	client   *http.Client
+	config   *config.AIConfig
+	provider aiProvider
+
+	// 熔断器：连续失败达到阈值后在冷却期内跳过调用，保证规则报告不被拖慢
+	mu        sync.Mutex
+	failures  int       // 连续失败次数
+	openUntil time.Time // 熔断截止时间
 }
 
 // NewAIAnalyzer 创建 AI 分析器
@@ -24,7 +37,8 @@ func NewAIAnalyzer(cfg *config.AIConfig) *AIAnalyzer {
 		client: &http.Client{
 			Timeout: 30 * time.Second,
 		},
-		config: cfg,
+		config:   cfg,
+		provider: newAIProvider(cfg.Provider),
 	}
 }
 
@@ -36,7 +50,7 @@ func (a *AIAnalyzer) Analyze(stats *PeriodStats, reportType string) (string, err
 
 	// 检查是否启用该类型的 AI 评价
 	switch reportType {
-	case "daily":
+	case "daily", "today":
 		if !a.config.Daily {
 			return "", nil
 		}
@@ -50,12 +64,71 @@ func (a *AIAnalyzer) Analyze(stats *PeriodStats, reportType string) (string, err
 		}
 	}
 
+	if !a.allowCall() {
+		return "", ErrAICircuitOpen
+	}
+
 	prompt := a.buildPrompt(stats, reportType)
+	content, err := a.callAPIWithRetry(a.config.Model, prompt)
+	if err != nil && a.config.FallbackModel != "" {
+		// 主模型重试耗尽（常见于限流）时用备用模型尝试一次，不再重试
+		log.Printf("AI 主模型 %s 调用失败，改用备用模型 %s: %v", a.config.Model, a.config.FallbackModel, err)
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		var fallbackErr error
+		content, fallbackErr = a.callAPI(ctx, a.config.FallbackModel, prompt)
+		cancel()
+		if fallbackErr == nil {
+			err = nil
+		} else {
+			err = fmt.Errorf("%w；备用模型 %s 也失败: %v", err, a.config.FallbackModel, fallbackErr)
+		}
+	}
+	a.recordResult(err)
+	return content, err
+}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
+// allowCall 判断熔断器是否允许调用
+// 冷却期结束后放行一次试探调用：成功则复位，失败则立即再次熔断
+func (a *AIAnalyzer) allowCall() bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return time.Now().After(a.openUntil)
+}
+
+// recordResult 记录调用结果并更新熔断状态
+func (a *AIAnalyzer) recordResult(err error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if err == nil {
+		a.failures = 0
+		a.openUntil = time.Time{}
+		return
+	}
+
+	a.failures++
+	if a.config.BreakerThreshold > 0 && a.failures >= a.config.BreakerThreshold {
+		a.openUntil = time.Now().Add(a.config.GetAIBreakerCooldown())
+	}
+}
+
+// callAPIWithRetry 调用 API（带重试，指数退避 1s, 2s, 4s...）
+func (a *AIAnalyzer) callAPIWithRetry(model, prompt string) (string, error) {
+	var lastErr error
+	for i := 0; i <= a.config.MaxRetries; i++ {
+		if i > 0 {
+			time.Sleep(time.Duration(1<<uint(i-1)) * time.Second)
+		}
 
-	return a.callAPI(ctx, prompt)
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		content, err := a.callAPI(ctx, model, prompt)
+		cancel()
+		if err == nil {
+			return content, nil
+		}
+		lastErr = err
+	}
+	return "", fmt.Errorf("AI 调用失败（重试 %d 次）: %w", a.config.MaxRetries, lastErr)
 }
 
 // buildPrompt 构建 AI prompt
@@ -64,6 +137,8 @@ func (a *AIAnalyzer) buildPrompt(stats *PeriodStats, reportType string) string {
 	switch reportType {
 	case "daily":
 		periodDesc = "24 小时"
+	case "today":
+		periodDesc = "今日 0 点至今（不完整的一天）"
 	case "weekly":
 		periodDesc = "7 天"
 	case "monthly":
@@ -74,6 +149,9 @@ func (a *AIAnalyzer) buildPrompt(stats *PeriodStats, reportType string) string {
 	if stats.StorageType != "" {
 		storageType = string(stats.StorageType)
 	}
+	if stats.FilesystemKind != "" && stats.FilesystemKind != collector.FilesystemLocal {
+		storageType += fmt.Sprintf("（测试目录位于 %s，%s 文件系统，延迟天然偏高）", stats.FilesystemType, stats.FilesystemKind)
+	}
 
 	// 格式化峰值时间（只显示时分）
 	stealPeakTime := "N/A"
@@ -96,6 +174,7 @@ func (a *AIAnalyzer) buildPrompt(stats *PeriodStats, reportType string) string {
 - I/O 随机延迟: 写 %.2fms，读 %.2fms，P95 %.2fms
 - 磁盘繁忙度: 平均 %.1f%%，P95 %.1f%%
 - 内存可用率: %.1f%%
+- 内存缺页延迟: 平均 %.2fms，变异系数 %.3f（0 表示未测试）
 - 存储类型: %s
 - 基线偏离: %.1f%% (%s)
 - 规则评分: %.0f/100
@@ -113,65 +192,180 @@ func (a *AIAnalyzer) buildPrompt(stats *PeriodStats, reportType string) string {
 		stats.RandomIOWriteAvg, stats.RandomIOReadAvg, stats.RandomIOP95,
 		stats.DiskBusyPercent, stats.DiskBusyP95,
 		stats.MemoryAvailablePercent,
+		stats.MemFaultAvg, stats.MemFaultCV,
 		storageType,
-		stats.BaselineDeviation, stats.BaselineStatus,
+		stats.BaselineDeviation, describeBaselineForAI(stats),
 		stats.TotalScore,
 	)
 
+	if labels := stats.LabelPairs(); len(labels) > 0 {
+		prompt += fmt.Sprintf("\n\n机器标签（服务商/套餐等）: %s\n如标签包含服务商或套餐，可结合其常见情况给出针对性建议。", strings.Join(labels, ", "))
+	}
+
+	prompt += formatBaselineComparison(stats, a.config.IncludeHourly)
+
+	if len(stats.StaleMetrics) > 0 {
+		names := make([]string, len(stats.StaleMetrics))
+		for i, m := range stats.StaleMetrics {
+			names[i] = string(m.Type)
+		}
+		prompt += fmt.Sprintf("\n\n以下指标已长时间未更新（采集器可能失效），其数值可能已过时，请提醒用户检查: %s", strings.Join(names, ", "))
+	}
+
+	if len(stats.CollectErrors) > 0 {
+		parts := make([]string, len(stats.CollectErrors))
+		for i, e := range stats.CollectErrors {
+			parts[i] = fmt.Sprintf("%s %d 次", e.Metric, e.Count)
+		}
+		prompt += fmt.Sprintf("\n\n本周期内部分采集失败（相关指标样本不完整，频繁失败本身也可能说明机器负载吃紧）: %s", strings.Join(parts, ", "))
+	}
+
+	if stats.SchedWaitAvg > 0 {
+		prompt += fmt.Sprintf("\n\n调度等待（/proc/schedstat）: 运行队列等待占每 vCPU 时间平均 %.2f%%，P95 %.2f%%，每时间片平均等待 %.3fms。",
+			stats.SchedWaitAvg, stats.SchedWaitP95, stats.SchedWaitPerSliceMs)
+		if stats.SchedContention {
+			prompt += "本地负载偏低而调度等待偏高，说明 vCPU 在等待宿主机调度，是比 Steal 更直接的超售信号。"
+		}
+	}
+
+	if stats.SyncSuspect {
+		prompt += fmt.Sprintf("\n\n落盘探测: 4KB 覆写后 fdatasync 中位耗时仅 %.3fms，低于 %s 的物理下限，fsync 疑似被宿主机写缓存直接确认（未真正落盘）。顺序写延迟因此可能偏乐观，且存在断电丢数据风险，请提醒运行数据库的用户注意。",
+			stats.SyncProbeMs, stats.StorageType)
+	}
+
+	if stats.NUMANodes >= 2 {
+		prompt += fmt.Sprintf("\n\nNUMA: %d 个节点，远程节点分配占比 %.1f%%，numa_miss 占比 %.1f%%（比例高说明 vCPU 与内存未放在同一节点，访存延迟偏高）",
+			stats.NUMANodes, stats.NUMARemotePercent, stats.NUMAMissPercent)
+	}
+
+	if stats.Virtualization != "" {
+		prompt += fmt.Sprintf("\n\n虚拟化: %s", stats.Virtualization.DisplayName())
+		switch {
+		case stats.StealUntrusted:
+			prompt += "（容器共享宿主机内核，Steal 为宿主机数值或恒为 0，不能据此判断超售，请以 CPU 基准测试波动、I/O 延迟与调度等待为准）"
+		case stats.Virtualization == collector.VirtXen:
+			prompt += "（Xen 的 Steal 同时包含其他虚拟机占用与 hypervisor 自身开销，少量 Steal 属正常）"
+		case stats.Virtualization == collector.VirtNone:
+			prompt += "（物理机没有 Steal，超售判断无意义，请关注硬件与负载本身）"
+		}
+	}
+
+	if stats.PassiveOnly {
+		prompt += "\n\n本机处于被动模式：未运行 I/O 写入测试与 CPU 基准测试，I/O 延迟与 CPU 稳定性数据缺失（显示为 0），评分仅基于其余指标。请勿据此评价磁盘延迟。"
+	}
+
+	if c := stats.Consistency; c != nil {
+		prompt += fmt.Sprintf("\n\n多指标一致性: %s，劣化小时 %d 个，其中 %d 个多项指标同步劣化（涉及 %s）。",
+			c.Level, c.BadHours, c.CoBadHours, strings.Join(c.SignalNames(), "、"))
+		if c.Level == ConsistencyIsolated {
+			prompt += "劣化只出现在单项指标，请说明可能是偶发噪声，避免给出过于严重的结论。"
+		} else if c.Level == ConsistencyConsistent {
+			prompt += "多项独立指标同步劣化，超售结论可信度较高。"
+		}
+	}
+
 	// 周报/月报增加趋势分析提示
 	if reportType == "weekly" {
 		prompt += "\n\n请额外分析本周的性能趋势。"
 	} else if reportType == "monthly" {
 		prompt += "\n\n请额外分析长期趋势，并评估是否建议更换服务商。"
 	}
+	if stats.Baseline != nil && reportType != "daily" {
+		prompt += "请结合基线对比说明主要指标较历史的具体变化（如翻倍、下降一半）。"
+	}
+
+	// 小时明细放在最后，按剩余预算截断
+	if a.config.IncludeHourly {
+		budget := 0
+		if a.config.MaxPromptChars > 0 {
+			budget = a.config.MaxPromptChars - utf8.RuneCountInString(prompt)
+		}
+		prompt += formatHourlyBreakdown(stats.HourlyBreakdown, budget)
+	}
 
 	return prompt
 }
 
-// OpenAI API 请求/响应结构
-type chatRequest struct {
-	Model    string        `json:"model"`
-	Messages []chatMessage `json:"messages"`
-}
+// formatHourlyBreakdown 列出逐小时统计供 AI 分析时段规律
+// budget > 0 时整段不超过 budget 个字符，放不下的小时行省略并注明；预算连表头都放不下时返回空串
+func formatHourlyBreakdown(hours []HourlyStats, budget int) string {
+	if len(hours) == 0 {
+		return ""
+	}
+
+	header := "\n\n## 小时明细（小时, 样本数, Steal 均值/峰值 %, IOWait 均值/峰值 %, 顺序写延迟均值 ms）\n"
+	footer := "请结合小时明细分析时段规律：Steal 集中在工作时段通常意味着商业邻居，集中在夜间多为邻居的定时任务或备份。"
+	if budget > 0 && utf8.RuneCountInString(header+footer) > budget {
+		return ""
+	}
 
-type chatMessage struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
+	var buf strings.Builder
+	buf.WriteString(header)
+	used := utf8.RuneCountInString(header + footer)
+	for i, h := range hours {
+		line := fmt.Sprintf("%02d, %d, %.2f/%.2f, %.2f/%.2f, %.2f\n",
+			h.Hour, h.SampleCount, h.CPUStealAvg, h.CPUStealMax, h.CPUIoWaitAvg, h.CPUIoWaitMax, h.IOLatencyAvg)
+		// 预留截断说明的长度
+		omitted := fmt.Sprintf("（受 prompt 长度限制，其余 %d 个小时已省略）\n", len(hours)-i)
+		if budget > 0 && used+utf8.RuneCountInString(line) > budget-utf8.RuneCountInString(omitted) {
+			buf.WriteString(omitted)
+			break
+		}
+		buf.WriteString(line)
+		used += utf8.RuneCountInString(line)
+	}
+	buf.WriteString(footer)
+	return buf.String()
 }
 
-type chatResponse struct {
-	Choices []struct {
-		Message struct {
-			Content string `json:"content"`
-		} `json:"message"`
-	} `json:"choices"`
-	Error *struct {
-		Message string `json:"message"`
-	} `json:"error"`
+// describeBaselineForAI 基线状态与可信度，历史数据不足时说明未做对比，避免 AI 将偏离度 0 解读为稳定
+func describeBaselineForAI(stats *PeriodStats) string {
+	switch stats.BaselineConfidence {
+	case BaselineConfidenceNone:
+		return "历史数据不足，未做基线对比"
+	case BaselineConfidenceLow:
+		return stats.BaselineStatus + "，基线历史较短，仅供参考"
+	}
+	return stats.BaselineStatus
 }
 
-// callAPI 调用 OpenAI 兼容 API
-func (a *AIAnalyzer) callAPI(ctx context.Context, prompt string) (string, error) {
-	reqBody := chatRequest{
-		Model: a.config.Model,
-		Messages: []chatMessage{
-			{Role: "user", Content: prompt},
-		},
+// formatBaselineComparison 列出本期与基线期间的均值对比，历史数据不足时返回空串
+// 偏离度只是一个综合百分比，给出具体数值 AI 才能描述"Steal 较上周翻倍"之类的变化；
+// withDelta 为 true 时附加相对基线的变化幅度
+func formatBaselineComparison(stats *PeriodStats, withDelta bool) string {
+	b := stats.Baseline
+	if b == nil {
+		return ""
 	}
 
-	jsonData, err := json.Marshal(reqBody)
-	if err != nil {
-		return "", fmt.Errorf("序列化请求失败: %w", err)
+	var buf strings.Builder
+	buf.WriteString(fmt.Sprintf("\n\n## 与基线对比（%s ~ %s 均值）\n",
+		b.Start.Format("01-02"), b.End.Format("01-02")))
+	delta := func(cur, base float64) string {
+		if !withDelta || base <= 0 {
+			return ""
+		}
+		return fmt.Sprintf("（%+.0f%%）", (cur-base)/base*100)
+	}
+	if b.CPUStealSamples > 0 {
+		buf.WriteString(fmt.Sprintf("- CPU Steal: 本期 %.2f%%，基线 %.2f%%%s\n", stats.CPUStealAvg, b.CPUStealAvg, delta(stats.CPUStealAvg, b.CPUStealAvg)))
+	}
+	if b.IOLatencySamples > 0 {
+		buf.WriteString(fmt.Sprintf("- I/O 顺序写延迟: 本期 %.2fms，基线 %.2fms%s\n", stats.IOLatencyAvg, b.IOLatencyAvg, delta(stats.IOLatencyAvg, b.IOLatencyAvg)))
 	}
+	if b.CPULoadSamples > 0 {
+		buf.WriteString(fmt.Sprintf("- CPU Load (归一化): 本期 %.2f，基线 %.2f%s\n", stats.CPULoadAvg, b.CPULoadAvg, delta(stats.CPULoadAvg, b.CPULoadAvg)))
+	}
+	return strings.TrimSuffix(buf.String(), "\n")
+}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", a.config.APIURL, bytes.NewBuffer(jsonData))
+// callAPI 以指定模型调用配置的 AI 服务商 API
+func (a *AIAnalyzer) callAPI(ctx context.Context, model, prompt string) (string, error) {
+	req, err := a.provider.newRequest(ctx, a.config, model, prompt)
 	if err != nil {
-		return "", fmt.Errorf("创建请求失败: %w", err)
+		return "", err
 	}
 
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+a.config.APIKey)
-
 	resp, err := a.client.Do(req)
 	if err != nil {
 		return "", fmt.Errorf("API 请求失败: %w", err)
@@ -183,18 +377,23 @@ func (a *AIAnalyzer) callAPI(ctx context.Context, prompt string) (string, error)
 		return "", fmt.Errorf("读取响应失败: %w", err)
 	}
 
-	var chatResp chatResponse
-	if err := json.Unmarshal(body, &chatResp); err != nil {
-		return "", fmt.Errorf("解析响应失败: %w", err)
-	}
-
-	if chatResp.Error != nil {
-		return "", fmt.Errorf("API 错误: %s", chatResp.Error.Message)
+	// 非 2xx 一律视为失败，即使响应体能解析出内容，保证重试、备用模型与熔断生效
+	content, err := a.provider.parseResponse(body)
+	if resp.StatusCode/100 != 2 {
+		if err != nil {
+			return "", fmt.Errorf("API 错误 (%d): %w", resp.StatusCode, err)
+		}
+		return "", fmt.Errorf("API 错误 (%d): %s", resp.StatusCode, truncateBody(body))
 	}
+	return content, err
+}
 
-	if len(chatResp.Choices) == 0 {
-		return "", fmt.Errorf("API 返回空响应")
+// truncateBody 截断响应体用于错误信息，避免把整页 HTML 写入日志
+func truncateBody(body []byte) string {
+	const limit = 200
+	r := []rune(strings.TrimSpace(string(body)))
+	if len(r) > limit {
+		return string(r[:limit]) + "..."
 	}
-
-	return chatResp.Choices[0].Message.Content, nil
+	return string(r)
 }
@@ -0,0 +1,197 @@
+package analyzer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/Catker/chaoleme/config"
+)
+
+// aiProvider AI 服务商适配器，负责把 prompt 映射为服务商的请求格式并解析响应
+type aiProvider interface {
+	newRequest(ctx context.Context, cfg *config.AIConfig, model, prompt string) (*http.Request, error)
+	parseResponse(body []byte) (string, error)
+}
+
+// newAIProvider 根据配置选择适配器，未知或空值使用 OpenAI 兼容格式
+func newAIProvider(name string) aiProvider {
+	switch name {
+	case config.AIProviderAnthropic:
+		return anthropicProvider{}
+	case config.AIProviderOllama:
+		return ollamaProvider{}
+	default:
+		return openAIProvider{}
+	}
+}
+
+// newJSONRequest 构建 JSON POST 请求
+func newJSONRequest(ctx context.Context, url string, body interface{}) (*http.Request, error) {
+	jsonData, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("序列化请求失败: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("创建请求失败: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return req, nil
+}
+
+// ========== OpenAI 兼容（OpenAI、DeepSeek、Gemini OpenAI 兼容端点等） ==========
+
+type openAIProvider struct{}
+
+// OpenAI API 请求/响应结构
+type chatRequest struct {
+	Model    string        `json:"model"`
+	Messages []chatMessage `json:"messages"`
+}
+
+type chatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type chatResponse struct {
+	Choices []struct {
+		Message struct {
+			Content string `json:"content"`
+		} `json:"message"`
+	} `json:"choices"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+func (openAIProvider) newRequest(ctx context.Context, cfg *config.AIConfig, model, prompt string) (*http.Request, error) {
+	req, err := newJSONRequest(ctx, cfg.APIURL, chatRequest{
+		Model:    model,
+		Messages: []chatMessage{{Role: "user", Content: prompt}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+cfg.APIKey)
+	return req, nil
+}
+
+func (openAIProvider) parseResponse(body []byte) (string, error) {
+	var chatResp chatResponse
+	if err := json.Unmarshal(body, &chatResp); err != nil {
+		return "", fmt.Errorf("解析响应失败: %w", err)
+	}
+	if chatResp.Error != nil {
+		return "", fmt.Errorf("API 错误: %s", chatResp.Error.Message)
+	}
+	if len(chatResp.Choices) == 0 {
+		return "", fmt.Errorf("API 返回空响应")
+	}
+	return chatResp.Choices[0].Message.Content, nil
+}
+
+// ========== Anthropic Messages API ==========
+
+type anthropicProvider struct{}
+
+type anthropicRequest struct {
+	Model     string        `json:"model"`
+	MaxTokens int           `json:"max_tokens"`
+	Messages  []chatMessage `json:"messages"`
+}
+
+type anthropicResponse struct {
+	Content []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"content"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+func (anthropicProvider) newRequest(ctx context.Context, cfg *config.AIConfig, model, prompt string) (*http.Request, error) {
+	req, err := newJSONRequest(ctx, cfg.APIURL, anthropicRequest{
+		Model:     model,
+		MaxTokens: 1024,
+		Messages:  []chatMessage{{Role: "user", Content: prompt}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("x-api-key", cfg.APIKey)
+	req.Header.Set("anthropic-version", "2023-06-01")
+	return req, nil
+}
+
+func (anthropicProvider) parseResponse(body []byte) (string, error) {
+	var resp anthropicResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return "", fmt.Errorf("解析响应失败: %w", err)
+	}
+	if resp.Error != nil {
+		return "", fmt.Errorf("API 错误: %s", resp.Error.Message)
+	}
+	var text string
+	for _, block := range resp.Content {
+		if block.Type == "text" {
+			text += block.Text
+		}
+	}
+	if text == "" {
+		return "", fmt.Errorf("API 返回空响应")
+	}
+	return text, nil
+}
+
+// ========== Ollama /api/chat ==========
+
+type ollamaProvider struct{}
+
+type ollamaRequest struct {
+	Model    string        `json:"model"`
+	Messages []chatMessage `json:"messages"`
+	Stream   bool          `json:"stream"`
+}
+
+type ollamaResponse struct {
+	Message struct {
+		Content string `json:"content"`
+	} `json:"message"`
+	Error string `json:"error"`
+}
+
+func (ollamaProvider) newRequest(ctx context.Context, cfg *config.AIConfig, model, prompt string) (*http.Request, error) {
+	req, err := newJSONRequest(ctx, cfg.APIURL, ollamaRequest{
+		Model:    model,
+		Messages: []chatMessage{{Role: "user", Content: prompt}},
+		Stream:   false,
+	})
+	if err != nil {
+		return nil, err
+	}
+	// 本地 Ollama 通常无需认证，经反向代理时可配置 api_key
+	if cfg.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+cfg.APIKey)
+	}
+	return req, nil
+}
+
+func (ollamaProvider) parseResponse(body []byte) (string, error) {
+	var resp ollamaResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return "", fmt.Errorf("解析响应失败: %w", err)
+	}
+	if resp.Error != "" {
+		return "", fmt.Errorf("API 错误: %s", resp.Error)
+	}
+	if resp.Message.Content == "" {
+		return "", fmt.Errorf("API 返回空响应")
+	}
+	return resp.Message.Content, nil
+}
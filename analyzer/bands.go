@@ -0,0 +1,144 @@
+package analyzer
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/Catker/chaoleme/collector"
+)
+
+// scoreBand 评分档位：取值落在 limit 之前（越小越好时低于 limit，越大越好时高于 limit）得 score
+type scoreBand struct {
+	limit float64
+	score float64
+}
+
+// scoreBands 一项指标的评分档位表，按顺序匹配，均未命中时得 fallback
+// 评分函数与 -explain 共用同一张表，保证解释的区间与实际评分一致
+type scoreBands struct {
+	bands          []scoreBand
+	fallback       float64
+	higherIsBetter bool // 为 true 时取值高于 limit 命中（如内存可用率）
+}
+
+// match 返回命中的档位序号，未命中任何档位时为 len(bands)
+func (b scoreBands) match(v float64) int {
+	for i, band := range b.bands {
+		if (!b.higherIsBetter && v < band.limit) || (b.higherIsBetter && v > band.limit) {
+			return i
+		}
+	}
+	return len(b.bands)
+}
+
+// score 按档位评分
+func (b scoreBands) score(v float64) float64 {
+	if i := b.match(v); i < len(b.bands) {
+		return b.bands[i].score
+	}
+	return b.fallback
+}
+
+// interpolate 在档位锚点之间线性插值评分
+// 锚点为各档上限及其分数（越小越好时 v ≤ 首档上限得满档分，此后线性下降，到达下一档上限时
+// 恰为该档分数）；末档之后沿末段斜率继续下降，降至 fallback 后不再变化。
+// 相比 score，阈值两侧的微小差异只带来成比例的分差，不再有 30 分的跳变
+func (b scoreBands) interpolate(v float64) float64 {
+	if len(b.bands) < 2 {
+		return b.score(v)
+	}
+	// 统一换算为"越大越差"的距离，便于按同一方向插值
+	dist := func(x float64) float64 {
+		if b.higherIsBetter {
+			return -x
+		}
+		return x
+	}
+	x := dist(v)
+	first := b.bands[0]
+	if x <= dist(first.limit) {
+		return first.score
+	}
+	for i := 1; i < len(b.bands); i++ {
+		lo, hi := b.bands[i-1], b.bands[i]
+		if x <= dist(hi.limit) {
+			return lerp(x, dist(lo.limit), lo.score, dist(hi.limit), hi.score)
+		}
+	}
+
+	lo, hi := b.bands[len(b.bands)-2], b.bands[len(b.bands)-1]
+	score := lerp(x, dist(lo.limit), lo.score, dist(hi.limit), hi.score)
+	return math.Max(score, b.fallback)
+}
+
+// lerp 过 (x0, y0)、(x1, y1) 两点的直线在 x 处的取值
+func lerp(x, x0, y0, x1, y1 float64) float64 {
+	return y0 + (x-x0)*(y1-y0)/(x1-x0)
+}
+
+// describe 描述取值命中的区间，如 "< 3"、"[3, 8)"、"≥ 15"
+func (b scoreBands) describe(v float64) string {
+	i := b.match(v)
+	if b.higherIsBetter {
+		switch {
+		case i == 0:
+			return fmt.Sprintf("> %g", b.bands[0].limit)
+		case i == len(b.bands):
+			return fmt.Sprintf("≤ %g", b.bands[i-1].limit)
+		default:
+			return fmt.Sprintf("(%g, %g]", b.bands[i].limit, b.bands[i-1].limit)
+		}
+	}
+	switch {
+	case i == 0:
+		return fmt.Sprintf("< %g", b.bands[0].limit)
+	case i == len(b.bands):
+		return fmt.Sprintf("≥ %g", b.bands[i-1].limit)
+	default:
+		return fmt.Sprintf("[%g, %g)", b.bands[i-1].limit, b.bands[i].limit)
+	}
+}
+
+// stealBands CPU Steal 档位，按独享/共享区分阈值
+func stealBands(tenancy CPUTenancy) scoreBands {
+	low, medium, high := stealThresholds(tenancy)
+	return scoreBands{bands: []scoreBand{{low, 100}, {medium, 70}, {high, 40}}, fallback: 0}
+}
+
+// ioLatencyBands 顺序写延迟 P95 档位（ms），SSD 和 HDD 使用不同阈值
+func ioLatencyBands(storageType collector.StorageType) scoreBands {
+	if storageType == collector.StorageTypeHDD {
+		return scoreBands{bands: []scoreBand{{50, 100}, {100, 70}, {200, 40}}, fallback: 0}
+	}
+	return scoreBands{bands: []scoreBand{{20, 100}, {50, 70}, {100, 40}}, fallback: 0}
+}
+
+// randomIOBands 随机 IO P95 档位（ms），随机 IO 通常比顺序 IO 慢，阈值放宽
+func randomIOBands(storageType collector.StorageType) scoreBands {
+	if storageType == collector.StorageTypeHDD {
+		return scoreBands{bands: []scoreBand{{100, 100}, {200, 70}, {500, 40}}, fallback: 0}
+	}
+	return scoreBands{bands: []scoreBand{{30, 100}, {80, 70}, {150, 40}}, fallback: 0}
+}
+
+var (
+	ioWaitBands    = scoreBands{bands: []scoreBand{{5, 100}, {15, 70}, {30, 40}}, fallback: 0}
+	stabilityBands = scoreBands{bands: []scoreBand{{0.05, 100}, {0.15, 70}}, fallback: 30}
+	diskBusyBands  = scoreBands{bands: []scoreBand{{30, 100}, {60, 70}, {85, 40}}, fallback: 0}
+	memoryBands    = scoreBands{bands: []scoreBand{{90, 100}, {80, 80}}, fallback: 50, higherIsBetter: true}
+	memFaultBands  = scoreBands{bands: []scoreBand{{0.10, 100}, {memFaultUnstableCV, 70}}, fallback: 30}
+	baselineBands  = scoreBands{bands: []scoreBand{{10, 100}, {25, 70}, {50, 40}}, fallback: 20}
+)
+
+// ScoreTraceItem 单个评分项的计算过程
+type ScoreTraceItem struct {
+	Key          string  // 评分项（键与 ScoreBreakdown 一致）
+	Value        string  // 参与评分的聚合值
+	Bucket       string  // 命中的阈值区间
+	SubScore     float64 // 档位分（平滑评分时为插值分）
+	Boost        float64 // 实际应用的超售可信度加成（1 表示未应用）
+	Score        float64 // 加成、混合后的单项分
+	Weight       float64 // 权重
+	Contribution float64 // 加权贡献 = Score × Weight
+	Note         string  // 补充说明（如阈值类型、混合方式）
+}
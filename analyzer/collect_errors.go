@@ -0,0 +1,65 @@
+package analyzer
+
+import (
+	"log"
+	"sort"
+	"time"
+
+	"github.com/Catker/chaoleme/storage"
+)
+
+// CollectErrorCount 周期内某项指标的采集失败次数
+type CollectErrorCount struct {
+	Metric    storage.MetricType `json:"metric"`
+	Count     int                `json:"count"`
+	Rate      float64            `json:"rate"`       // 失败次数占预期采集次数的百分比（按配置间隔估算，无法估算时为 0）
+	LastError string             `json:"last_error"` // 最近一次失败的错误信息
+}
+
+// calculateCollectErrors 按指标类型汇总周期内的采集失败事件，按次数降序
+// 频繁的采集失败本身就是机器吃力的信号，也意味着评分所依据的样本不完整
+func (a *Analyzer) calculateCollectErrors(start, end time.Time) []CollectErrorCount {
+	events, err := a.store.Query(storage.MetricTypeCollectError, start, end)
+	if err != nil {
+		log.Printf("查询采集失败记录失败: %v", err)
+		return nil
+	}
+	if len(events) == 0 {
+		return nil
+	}
+
+	byMetric := make(map[storage.MetricType]*CollectErrorCount)
+	for _, e := range events {
+		metric, _ := e.Extra["metric"].(string)
+		c, ok := byMetric[storage.MetricType(metric)]
+		if !ok {
+			c = &CollectErrorCount{Metric: storage.MetricType(metric)}
+			byMetric[c.Metric] = c
+		}
+		c.Count++
+		if msg, ok := e.Extra["error"].(string); ok {
+			c.LastError = msg // 按时间升序，最后一条即最近一次
+		}
+	}
+
+	intervals := MetricIntervals(a.config)
+	counts := make([]CollectErrorCount, 0, len(byMetric))
+	for _, c := range byMetric {
+		if interval := intervals[c.Metric]; interval > 0 {
+			if expected := float64(end.Sub(start)) / float64(interval); expected >= 1 {
+				c.Rate = float64(c.Count) / expected * 100
+				if c.Rate > 100 {
+					c.Rate = 100
+				}
+			}
+		}
+		counts = append(counts, *c)
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Count != counts[j].Count {
+			return counts[i].Count > counts[j].Count
+		}
+		return counts[i].Metric < counts[j].Metric
+	})
+	return counts
+}
@@ -0,0 +1,148 @@
+package analyzer
+
+import (
+	"sort"
+	"time"
+
+	"github.com/Catker/chaoleme/collector"
+)
+
+// 多指标一致性判定参数
+const (
+	consistencyBucket      = time.Hour // 按实际小时对齐各指标
+	consistencyMinBadHours = 3         // 劣化小时数少于此值时不下结论
+	consistencyMinBenchHrs = 3         // 基准测试至少覆盖的小时数，否则无法得到可靠的周期中位数
+	benchSlowdownRatio     = 1.2       // 小时中位耗时超过周期中位数的倍数视为变慢
+	consistencyHighRatio   = 0.5       // 同步劣化小时占比达到此值视为一致
+	consistencyLowRatio    = 0.2       // 同步劣化小时占比低于此值视为孤立
+	consistencyBoostFactor = 1.1       // 多项指标一致劣化时对 Steal/IOWait 低分的额外加成
+	consistencyMaxBoost    = 1.3       // 叠加后加成上限
+	consistencySignalSteal = "cpu_steal"
+	consistencySignalIO    = "io_latency"
+	consistencySignalBench = "cpu_bench"
+)
+
+// 一致性等级
+const (
+	ConsistencyConsistent = "consistent" // 多项指标在同一时段同步劣化
+	ConsistencyPartial    = "partial"    // 部分时段同步
+	ConsistencyIsolated   = "isolated"   // 劣化集中在单项指标，可能是噪声
+)
+
+// SignalConsistency 多项独立指标劣化时段的一致性
+// 单项指标偶发偏高可能只是噪声；Steal 升高的同时 I/O 延迟飙升、基准测试变慢，才构成完整的超售证据
+type SignalConsistency struct {
+	BadSignals []string `json:"bad_signals"`  // 周期内出现劣化时段的指标
+	BadHours   int      `json:"bad_hours"`    // 至少一项指标劣化的小时数
+	CoBadHours int      `json:"co_bad_hours"` // 两项及以上指标同时劣化的小时数
+	Ratio      float64  `json:"ratio"`        // CoBadHours / BadHours
+	Level      string   `json:"level"`
+}
+
+// calculateConsistency 按实际小时对齐 Steal、顺序写延迟与 CPU 基准测试，统计劣化时段的重合程度
+// 只统计 Steal 与至少一项其他指标都有样本的小时，避免因采样间隔不同把"未测到"当作"未劣化"。
+// 劣化小时不足时返回 nil。
+func calculateConsistency(steal, ioLatency, bench series, tenancy CPUTenancy, storageType collector.StorageType) *SignalConsistency {
+	stealLow, _, _ := stealThresholds(tenancy)
+	latencyLimit := latencyThreshold(storageType)
+
+	stealHours := bucketByHour(steal)
+	ioHours := bucketByHour(ioLatency)
+	benchHours := bucketByHour(bench)
+
+	benchLimit := 0.0
+	if len(benchHours) >= consistencyMinBenchHrs {
+		benchLimit = percentile(bench.values, 50) * benchSlowdownRatio
+	}
+
+	badBySignal := make(map[string]int)
+	c := &SignalConsistency{}
+	for hour, sv := range stealHours {
+		iv, hasIO := ioHours[hour]
+		bv, hasBench := benchHours[hour]
+		hasBench = hasBench && benchLimit > 0
+		if !hasIO && !hasBench {
+			continue
+		}
+
+		bad := 0
+		if avg(sv) >= stealLow {
+			badBySignal[consistencySignalSteal]++
+			bad++
+		}
+		if hasIO && avg(iv) >= latencyLimit {
+			badBySignal[consistencySignalIO]++
+			bad++
+		}
+		if hasBench && percentile(bv, 50) >= benchLimit {
+			badBySignal[consistencySignalBench]++
+			bad++
+		}
+
+		if bad > 0 {
+			c.BadHours++
+		}
+		if bad >= 2 {
+			c.CoBadHours++
+		}
+	}
+
+	if c.BadHours < consistencyMinBadHours {
+		return nil
+	}
+
+	for signal := range badBySignal {
+		c.BadSignals = append(c.BadSignals, signal)
+	}
+	sort.Strings(c.BadSignals)
+
+	c.Ratio = float64(c.CoBadHours) / float64(c.BadHours)
+	switch {
+	case c.Ratio >= consistencyHighRatio:
+		c.Level = ConsistencyConsistent
+	case c.Ratio < consistencyLowRatio:
+		c.Level = ConsistencyIsolated
+	default:
+		c.Level = ConsistencyPartial
+	}
+	return c
+}
+
+// consistencySignalNames 指标的中文名称
+var consistencySignalNames = map[string]string{
+	consistencySignalSteal: "Steal",
+	consistencySignalIO:    "写延迟",
+	consistencySignalBench: "基准测试",
+}
+
+// SignalNames 返回劣化指标的中文名称
+func (c *SignalConsistency) SignalNames() []string {
+	names := make([]string, len(c.BadSignals))
+	for i, s := range c.BadSignals {
+		names[i] = consistencySignalNames[s]
+	}
+	return names
+}
+
+// bucketByHour 按实际小时（非一天中的时段）分组
+func bucketByHour(sr series) map[int64][]float64 {
+	buckets := make(map[int64][]float64)
+	for i, v := range sr.values {
+		key := sr.times[i].Truncate(consistencyBucket).Unix()
+		buckets[key] = append(buckets[key], v)
+	}
+	return buckets
+}
+
+// consistencyBoost 根据一致性调整超售可信度加成：多项指标同步劣化时进一步加成，
+// 孤立劣化时不做调整（仅在报告中提示）
+func consistencyBoost(boost float64, c *SignalConsistency) float64 {
+	if c == nil || c.Level != ConsistencyConsistent {
+		return boost
+	}
+	boost *= consistencyBoostFactor
+	if boost > consistencyMaxBoost {
+		boost = consistencyMaxBoost
+	}
+	return boost
+}
@@ -0,0 +1,121 @@
+package analyzer
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/Catker/chaoleme/storage"
+)
+
+// recencyWeights 按样本距周期末尾的时长计算指数衰减权重：每早一个半衰期，权重减半
+// 未配置 analysis.recency_half_life 时返回 nil，表示等权平均
+func (a *Analyzer) recencyWeights(times []time.Time, end time.Time) []float64 {
+	halfLife := a.config.GetRecencyHalfLife()
+	if halfLife <= 0 || len(times) == 0 {
+		return nil
+	}
+	weights := make([]float64, len(times))
+	for i, t := range times {
+		age := end.Sub(t)
+		if age < 0 {
+			age = 0
+		}
+		weights[i] = math.Exp2(-float64(age) / float64(halfLife))
+	}
+	return weights
+}
+
+// durationWeights 开启自适应 Steal 间隔时按各样本覆盖的时长计算权重
+// 守护进程每次 Steal/IOWait 采样覆盖自上一次采样以来的区间，即相邻样本的时间差；
+// 间隔缩短的争抢期样本更密，等权平均会放大争抢期的占比，按时长加权后与固定间隔的结果可比。
+// 第一个样本沿用下一个间隔；时间差以自适应上限封顶，停采或业务时段掩码造成的空档不计入权重。
+// 未开启自适应间隔或序列为聚合结果时返回 nil
+func (a *Analyzer) durationWeights(sr series) []float64 {
+	if !a.config.Collect.AdaptiveInterval || sr.resolution > 0 || len(sr.times) < 2 {
+		return nil
+	}
+	switch sr.metricType {
+	case storage.MetricTypeCPUSteal, storage.MetricTypeCPUIoWait:
+	default:
+		return nil
+	}
+	limit := a.config.ExpectedCPUStealInterval()
+	weights := make([]float64, len(sr.times))
+	for i := range sr.times {
+		var gap time.Duration
+		if i == 0 {
+			gap = sr.times[1].Sub(sr.times[0])
+		} else {
+			gap = sr.times[i].Sub(sr.times[i-1])
+		}
+		if limit > 0 && gap > limit {
+			gap = limit
+		}
+		if gap <= 0 {
+			gap = time.Second
+		}
+		weights[i] = gap.Seconds()
+	}
+	return weights
+}
+
+// sampleWeights 合并近期加权与时长加权，均未启用时返回 nil（等权）
+func (a *Analyzer) sampleWeights(sr series) []float64 {
+	recency := a.recencyWeights(sr.times, sr.end)
+	duration := a.durationWeights(sr)
+	switch {
+	case recency == nil:
+		return duration
+	case duration == nil:
+		return recency
+	}
+	for i := range recency {
+		recency[i] *= duration[i]
+	}
+	return recency
+}
+
+// weightedAvg 加权平均值，权重为 nil 时退化为等权平均
+func weightedAvg(values, weights []float64) float64 {
+	if weights == nil {
+		return avg(values)
+	}
+	sum, total := 0.0, 0.0
+	for i, v := range values {
+		sum += v * weights[i]
+		total += weights[i]
+	}
+	if total == 0 {
+		return 0
+	}
+	return sum / total
+}
+
+// weightedPercentile 加权分位数：取累计权重首次达到总权重 p% 的样本值
+// 权重为 nil 时与 percentile 结果一致
+func weightedPercentile(values, weights []float64, p float64) float64 {
+	if weights == nil {
+		return percentile(values, p)
+	}
+	if len(values) == 0 {
+		return 0
+	}
+	idx := make([]int, len(values))
+	total := 0.0
+	for i := range idx {
+		idx[i] = i
+		total += weights[i]
+	}
+	sort.Slice(idx, func(i, j int) bool { return values[idx[i]] < values[idx[j]] })
+
+	target := p / 100 * total
+	cum := 0.0
+	for _, i := range idx {
+		cum += weights[i]
+		if cum >= target {
+			return values[i]
+		}
+	}
+	return values[idx[len(idx)-1]]
+}
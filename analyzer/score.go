@@ -1,15 +1,27 @@
 package analyzer
 
 import (
+	"errors"
 	"fmt"
+	"log"
 	"math"
 	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/Catker/chaoleme/collector"
+	"github.com/Catker/chaoleme/config"
+	"github.com/Catker/chaoleme/locale"
 	"github.com/Catker/chaoleme/storage"
 )
 
+// 过热降频判定阈值
+const (
+	thermalHighCelsius = 85   // 多数 CPU 在 90-100°C 开始降频，85°C 已接近
+	thermalCVThreshold = 0.05 // 与 CPU 稳定性满分阈值一致
+)
+
 // 评分权重
 const (
 	WeightCPUSteal     = 0.35 // CPU Steal 权重 35%
@@ -23,6 +35,49 @@ const (
 	// 注意：CPU Load 不再参与独立评分，改为佐证因子
 )
 
+// scoreWeights 各评分项权重（键与 RiskDetails/ScoreBreakdown 一致）
+var scoreWeights = map[string]float64{
+	"cpu_steal":     WeightCPUSteal,
+	"cpu_iowait":    WeightCPUIoWait,
+	"cpu_stability": WeightCPUStability,
+	"io_latency":    WeightIOLatency,
+	"random_io":     WeightRandomIO,
+	"disk_busy":     WeightDiskBusy,
+	"memory":        WeightMemory,
+	"baseline":      WeightBaseline,
+}
+
+// activeScoreKeys 依赖主动测试（I/O 写入、CPU 基准测试）的评分项，被动模式下不参与评分
+var activeScoreKeys = map[string]bool{
+	"cpu_stability": true,
+	"io_latency":    true,
+	"random_io":     true,
+}
+
+// weightsFor 返回评分权重：被动模式下去掉依赖主动测试的评分项，
+// Steal 不可信（容器环境）时其权重乘以 untrustedStealFactor，其余按比例放大使总和仍为 1
+func weightsFor(stats *PeriodStats) map[string]float64 {
+	if !stats.PassiveOnly && !stats.StealUntrusted {
+		return scoreWeights
+	}
+	weights := make(map[string]float64, len(scoreWeights))
+	var total float64
+	for key, w := range scoreWeights {
+		if stats.PassiveOnly && activeScoreKeys[key] {
+			continue
+		}
+		if key == "cpu_steal" && stats.StealUntrusted {
+			w *= untrustedStealFactor
+		}
+		weights[key] = w
+		total += w
+	}
+	for key, w := range weights {
+		weights[key] = w / total
+	}
+	return weights
+}
+
 // RiskLevel 风险等级
 type RiskLevel string
 
@@ -33,174 +88,450 @@ const (
 	RiskLevelSevere    RiskLevel = "severe"    // 0-49: 严重
 )
 
+// Severity 风险等级的严重程度（数值越大越严重）
+func (l RiskLevel) Severity() int {
+	switch l {
+	case RiskLevelExcellent:
+		return 0
+	case RiskLevelGood:
+		return 1
+	case RiskLevelMedium:
+		return 2
+	case RiskLevelSevere:
+		return 3
+	default:
+		return -1
+	}
+}
+
+// Recommendation 续费建议
+type Recommendation string
+
+const (
+	RecommendationRenew   Recommendation = "renew"   // 建议续费
+	RecommendationMonitor Recommendation = "monitor" // 继续观察
+	RecommendationSwitch  Recommendation = "switch"  // 建议更换
+)
+
+// Confidence 置信度
+type Confidence string
+
+const (
+	ConfidenceHigh   Confidence = "high"
+	ConfidenceMedium Confidence = "medium"
+	ConfidenceLow    Confidence = "low"
+)
+
+// MetricChange 单项指标相对初始状态的变化
+type MetricChange struct {
+	Name          string  `json:"name"`           // 指标名称
+	Unit          string  `json:"unit"`           // 单位
+	Initial       float64 `json:"initial"`        // 初始窗口平均值
+	Current       float64 `json:"current"`        // 当前周期平均值
+	ChangePercent float64 `json:"change_percent"` // 变化百分比（正值表示变差）
+}
+
+// InstallComparison 与安装初期（数据库最早完整窗口）的对比
+type InstallComparison struct {
+	WindowStart time.Time      `json:"window_start"`
+	WindowEnd   time.Time      `json:"window_end"`
+	Changes     []MetricChange `json:"changes"`
+}
+
 // HourlyStats 小时级统计（用于时段分析）
 type HourlyStats struct {
-	Hour         int     // 0-23 小时
-	SampleCount  int     // 样本数量
-	CPUStealAvg  float64 // CPU Steal 平均值
-	CPUStealMax  float64 // CPU Steal 峰值
-	CPUIoWaitAvg float64 // IOWait 平均值
-	CPUIoWaitMax float64 // IOWait 峰值
+	Hour         int     `json:"hour"`           // 0-23 小时
+	SampleCount  int     `json:"sample_count"`   // 样本数量
+	CPUStealAvg  float64 `json:"cpu_steal_avg"`  // CPU Steal 平均值
+	CPUStealMax  float64 `json:"cpu_steal_max"`  // CPU Steal 峰值
+	CPUIoWaitAvg float64 `json:"cpu_iowait_avg"` // IOWait 平均值
+	CPUIoWaitMax float64 `json:"cpu_iowait_max"` // IOWait 峰值
+	IOLatencyAvg float64 `json:"io_latency_avg"` // 顺序写延迟平均值（ms），无样本时为 0
 }
 
+// DayTypeComparison 工作日与周末对比（周报/月报）
+// 工作日明显更差是商业邻居争抢资源的典型特征
+type DayTypeComparison struct {
+	WeekdayStealAvg float64 `json:"weekday_steal_avg"`
+	WeekendStealAvg float64 `json:"weekend_steal_avg"`
+	WeekdayLoadAvg  float64 `json:"weekday_load_avg"`
+	WeekendLoadAvg  float64 `json:"weekend_load_avg"`
+	WeekdaySamples  int     `json:"weekday_samples"`
+	WeekendSamples  int     `json:"weekend_samples"`
+}
+
+// CustomMetricStats 自定义指标统计
+type CustomMetricStats struct {
+	Name    string  `json:"name"`
+	Avg     float64 `json:"avg"`
+	Min     float64 `json:"min"`
+	Max     float64 `json:"max"`
+	Samples int     `json:"samples"`
+}
+
+// CPUTenancy CPU 核心独享类型（推断值）
+type CPUTenancy string
+
+const (
+	CPUTenancyUnknown   CPUTenancy = "unknown"
+	CPUTenancyDedicated CPUTenancy = "dedicated"
+	CPUTenancyShared    CPUTenancy = "shared"
+)
+
 // PeriodStats 周期统计数据
 type PeriodStats struct {
-	Period    string    // "daily", "weekly", "monthly"
-	StartTime time.Time // 统计开始时间
-	EndTime   time.Time // 统计结束时间
+	Period    string    `json:"period"`     // "daily", "weekly", "monthly"
+	StartTime time.Time `json:"start_time"` // 统计开始时间
+	EndTime   time.Time `json:"end_time"`   // 统计结束时间
+
+	// 连续处于 alert.escalate_level 及以上的报告周期数（含本次），达到 escalate_after 时 Escalated 为 true
+	RiskStreak int  `json:"risk_streak"`
+	Escalated  bool `json:"escalated"`
+
+	// 评分已越过等级边界但未超过 alert.level_hysteresis，沿用上次的等级
+	RiskLevelHeld bool `json:"risk_level_held,omitempty"`
+
+	// 各指标段本周期的样本数（键同 report.sections），报告据此跳过无数据的段
+	Samples map[string]int `json:"samples"`
+
+	// 被动模式（collect.passive_only）：未运行 I/O 写入测试与 CPU 基准测试，评分仅基于被动指标
+	PassiveOnly bool `json:"passive_only"`
+
+	// 评分所用的业务时段（如 "09:00-18:00"），为空表示全天
+	BusinessHours string `json:"business_hours,omitempty"`
+
+	// 周期均值与分位数的近期加权半衰期（如 "24h"），为空表示等权
+	RecencyHalfLife string `json:"recency_half_life,omitempty"`
+
+	// 数据覆盖（基于 CPU Steal 采样序列检测缺口，如宕机、服务停止）
+	DataCoverage    float64       `json:"data_coverage"`    // 数据覆盖率 (0-100)
+	DataMissing     time.Duration `json:"data_missing_ns"`  // 缺失时长
+	DataSufficiency bool          `json:"data_sufficiency"` // 数据是否充足，不足时报告需标注评分仅供参考
+
+	// 长时间未更新的指标（采集器可能已失效，其旧数据仍会进入统计）
+	StaleMetrics []StaleMetric `json:"stale_metrics,omitempty"`
+	// 周期内各指标的采集失败次数（按次数降序）
+	CollectErrors []CollectErrorCount `json:"collect_errors,omitempty"`
 
 	// CPU Steal 统计
-	CPUStealAvg     float64
-	CPUStealMax     float64
-	CPUStealP95     float64
-	CPUStealMaxTime time.Time // 峰值发生时间
+	CPUStealAvg     float64   `json:"cpu_steal_avg"`
+	CPUStealMax     float64   `json:"cpu_steal_max"`
+	CPUStealP95     float64   `json:"cpu_steal_p95"`
+	CPUStealMaxTime time.Time `json:"cpu_steal_max_time"` // 峰值发生时间
+
+	// 疑似迁移/挂起事件（时钟跳变期间的 Steal 样本，已从上述统计中排除）
+	SuspendEvents   int     `json:"suspend_events"`
+	SuspendStealMax float64 `json:"suspend_steal_max"`
+
+	// 突发额度耗尽（T 系列等积分型实例）：Steal 随自身高负载同步上升，而非空闲时出现
+	BurstCreditSuspected bool    `json:"burst_credit_suspected"`
+	BurstBusySteal       float64 `json:"burst_busy_steal"`       // 高负载时段 Steal 平均值
+	BurstIdleSteal       float64 `json:"burst_idle_steal"`       // 空闲时段 Steal 平均值
+	StealLoadCorrelation float64 `json:"steal_load_correlation"` // Steal 与负载的相关系数
+
+	// CPU 独享/共享判定：独享核心采用更严格的 Steal 阈值
+	CPUTenancy       CPUTenancy `json:"cpu_tenancy"`
+	CPUTenancyReason string     `json:"cpu_tenancy_reason"` // 判定依据，供用户与所购套餐对照
+
+	// 虚拟化平台（KVM、Xen、OpenVZ 等），容器环境的 Steal 不反映本实例被争抢的程度，降低其评分权重
+	Virtualization collector.Virtualization `json:"virtualization,omitempty"`
+	StealUntrusted bool                     `json:"steal_untrusted,omitempty"`
 
 	// CPU IOWait 统计
-	CPUIoWaitAvg     float64
-	CPUIoWaitMax     float64
-	CPUIoWaitP95     float64
-	CPUIoWaitMaxTime time.Time // 峰值发生时间
+	CPUIoWaitAvg     float64   `json:"cpu_iowait_avg"`
+	CPUIoWaitMax     float64   `json:"cpu_iowait_max"`
+	CPUIoWaitP95     float64   `json:"cpu_iowait_p95"`
+	CPUIoWaitMaxTime time.Time `json:"cpu_iowait_max_time"` // 峰值发生时间
 
 	// 时段分布（用于周报/月报分析）
-	HourlyBreakdown []HourlyStats
+	HourlyBreakdown []HourlyStats `json:"hourly_breakdown,omitempty"`
+	// 综合劣化分最高的小时（Steal + IOWait + 归一化写延迟），无数据时为 nil
+	WorstHour      *HourlyStats `json:"worst_hour,omitempty"`
+	WorstHourScore float64      `json:"worst_hour_score,omitempty"`
+	// 工作日/周末对比（周报/月报，两类日期均有数据时计算）
+	DayTypes *DayTypeComparison `json:"day_types,omitempty"`
 
 	// CPU 基准测试统计
-	CPUBenchAvg float64 // 平均耗时
-	CPUBenchCV  float64 // 变异系数 (Coefficient of Variation)
+	CPUBenchAvg float64 `json:"cpu_bench_avg"` // 平均耗时
+	CPUBenchCV  float64 `json:"cpu_bench_cv"`  // 变异系数 (Coefficient of Variation)
+	// 相对参考性能（配置 reference_bench_ms 时计算）：参考耗时 / 当前耗时中位数 × 100
+	CPUPerfPercent  float64 `json:"cpu_perf_percent,omitempty"`
+	CPUPerfDegraded bool    `json:"cpu_perf_degraded"` // 持续低于参考值阈值
+
+	// CPU 温度统计（仅暴露 thermal zone 的机器，多为物理机/独享实例）
+	CPUTempAvg     float64 `json:"cpu_temp_avg"`
+	CPUTempMax     float64 `json:"cpu_temp_max"`
+	CPUTempSamples int     `json:"cpu_temp_samples"`
+	// 性能波动伴随高温：更可能是过热降频而非邻居争抢
+	ThermalThrottling bool `json:"thermal_throttling"`
+
+	// 在线 vCPU 数量变化序列（如 [4 2 4]），仅在周期内发生 CPU 热插拔时非空
+	CPUOnlineChanges []int `json:"cpu_online_changes,omitempty"`
 
 	// I/O 顺序延迟统计
-	IOLatencyAvg float64
-	IOLatencyP95 float64
-	IOLatencyP99 float64
+	IOLatencyAvg float64 `json:"io_latency_avg"`
+	IOLatencyP95 float64 `json:"io_latency_p95"`
+	IOLatencyP99 float64 `json:"io_latency_p99"`
+	// 疑似命中缓存而被排除的样本数（O_DIRECT/fsync 失效时延迟低得不合理）
+	IOLatencyCacheSamples int `json:"io_latency_cache_samples"`
+	// 每轮多次测量（collect.io_samples_per_run > 1）时单轮内最大与最小延迟之差的平均值，未启用时为 0
+	IOLatencySpreadAvg float64 `json:"io_latency_spread_avg"`
+	IOSamplesPerRun    int     `json:"io_samples_per_run,omitempty"`
+	// 落盘探测：4KB 覆写后 fdatasync 的中位耗时，未运行时为 0；
+	// 低于存储类别的物理下限时 SyncSuspect 为 true（fsync 疑似被写缓存直接确认）
+	SyncProbeMs float64 `json:"sync_probe_ms"`
+	SyncSuspect bool    `json:"sync_suspect"`
+
+	// I/O 卡顿（卡顿探测写入超过阈值的次数），未开启探测时阈值为 0
+	IOStalls          int     `json:"io_stalls"`
+	IOStallMaxMs      float64 `json:"io_stall_max_ms"`
+	IOStallDeadlineMs float64 `json:"io_stall_deadline_ms,omitempty"`
 
 	// I/O 随机延迟统计
-	RandomIOWriteAvg float64
-	RandomIOReadAvg  float64
-	RandomIOP95      float64
+	RandomIOWriteAvg float64 `json:"random_io_write_avg"`
+	RandomIOReadAvg  float64 `json:"random_io_read_avg"`
+	RandomIOP95      float64 `json:"random_io_p95"`
 
 	// 磁盘繁忙度统计
-	DiskBusyPercent float64 // IO 时间占比（平均）
-	DiskBusyP95     float64 // IO 时间占比（P95）
+	DiskBusyPercent  float64 `json:"disk_busy_percent"`  // IO 时间占比（平均）
+	DiskBusyP95      float64 `json:"disk_busy_p95"`      // IO 时间占比（P95）
+	DiskIOPS         float64 `json:"disk_iops"`          // 平均 IOPS（读写合计）
+	DiskQueueDepth   float64 `json:"disk_queue_depth"`   // 平均队列深度（加权 IO 耗时 / IO 耗时）
+	DiskMergePercent float64 `json:"disk_merge_percent"` // 被合并的请求占比
+	// 低 IOPS 下队列持续较深：共享存储后端拥塞的有力证据
+	DiskQueueCongested bool `json:"disk_queue_congested"`
+
+	// 磁盘综合分（0-100）：顺序写、随机 I/O、繁忙度三项评分按其权重归一化
+	DiskHealthScore float64 `json:"disk_health_score"`
+
+	// 网络流量统计（所选网卡，Mbps），无数据时为 0
+	NetworkInterface string  `json:"network_interface,omitempty"` // 最近一次采集的网卡
+	NetworkRxMbps    float64 `json:"network_rx_mbps"`
+	NetworkTxMbps    float64 `json:"network_tx_mbps"`
+	NetworkP95Mbps   float64 `json:"network_p95_mbps"` // 收发合计的 P95
+
+	// NUMA 跨节点访问统计（仅多节点机器，单节点时 NUMANodes 为 0）
+	NUMANodes         int     `json:"numa_nodes,omitempty"`
+	NUMARemotePercent float64 `json:"numa_remote_percent"` // other_node / (local_node + other_node)
+	NUMAMissPercent   float64 `json:"numa_miss_percent"`   // numa_miss / (numa_hit + numa_miss)
 
 	// 内存统计
-	MemoryAvailablePercent float64
+	MemoryAvailablePercent float64 `json:"memory_available_percent"`
+	// 内存缺页延迟（固定大小缓冲区全部缺页的耗时），未启用测试时为 0
+	MemFaultAvg float64 `json:"mem_fault_avg"`
+	MemFaultCV  float64 `json:"mem_fault_cv"`
+	// 可用率充足但缺页延迟波动大：疑似宿主机内存气球/压缩
+	MemBallooningSuspected bool `json:"mem_ballooning_suspected"`
 
 	// CPU Load 统计
-	CPULoadAvg float64 // 归一化后的 load1 平均值
-	CPULoadMax float64 // 归一化后的 load1 最大值
+	CPULoadAvg float64 `json:"cpu_load_avg"` // 归一化后的 load1 平均值
+	CPULoadMax float64 `json:"cpu_load_max"` // 归一化后的 load1 最大值
+	// 每 vCPU 可运行进程数（瞬时运行队列，补充 load1 的平滑值），无数据时为 0
+	RunQueueAvg float64 `json:"run_queue_avg"`
+	RunQueueP95 float64 `json:"run_queue_p95"`
+	// 调度等待（/proc/schedstat）：任务在运行队列中等待 CPU 的时间占每 vCPU 时间的百分比，
+	// 以及每个时间片的平均等待；内核未暴露 schedstat 时为 0
+	SchedWaitAvg        float64 `json:"sched_wait_avg"`
+	SchedWaitP95        float64 `json:"sched_wait_p95"`
+	SchedWaitPerSliceMs float64 `json:"sched_wait_per_slice_ms"`
+	// 本地负载低但调度等待高：vCPU 本身在等宿主机，比 Steal 更直接的超售信号（部分平台不上报 Steal）
+	SchedContention bool `json:"sched_contention"`
 
 	// 基线对比
-	BaselineDeviation float64 // 基线偏离度 (0-100，0 表示无偏离)
-	BaselineStatus    string  // "stable" / "degrading" / "improving"
+	BaselineDeviation float64 `json:"baseline_deviation"` // 基线偏离度 (0-100，0 表示无偏离)
+	BaselineStatus    string  `json:"baseline_status"`    // "stable" / "degrading" / "improving"
+	// 基线可信度：none 为历史数据不足、未做对比（偏离度 0 不代表确认稳定），low 为历史较短，high 为历史充足
+	BaselineConfidence BaselineConfidence `json:"baseline_confidence"`
+	// 基线期间的实际均值（历史数据不足时为 nil），供 AI 描述"较基线变化了多少"
+	Baseline *BaselineAverages `json:"baseline,omitempty"`
 
 	// 存储类型
-	StorageType collector.StorageType
+	StorageType       collector.StorageType `json:"storage_type"`
+	StorageTypeForced bool                  `json:"storage_type_forced"` // 由 -force-storage 指定，而非推断
+
+	// I/O 测试目录所在文件系统（旧数据未记录时为空）；写时复制或网络文件系统按 HDD 阈值评分
+	FilesystemType string                   `json:"filesystem_type,omitempty"`
+	FilesystemKind collector.FilesystemKind `json:"filesystem_kind,omitempty"`
+
+	// 多指标劣化时段一致性（劣化时段不足时为 nil）
+	Consistency *SignalConsistency `json:"consistency,omitempty"`
+
+	// 配置中的自由标签（provider、plan 等），JSON 报告中置于顶层
+	Labels map[string]string `json:"-"`
+
+	// 自定义指标（custom:<name>，仅展示，不参与评分）
+	CustomMetrics []CustomMetricStats `json:"custom_metrics,omitempty"`
 
 	// 综合评分
-	TotalScore  float64
-	RiskLevel   RiskLevel
-	RiskDetails map[string]string
+	TotalScore  float64           `json:"total_score"`
+	RiskLevel   RiskLevel         `json:"risk_level"`
+	RiskDetails map[string]string `json:"risk_details"`
+	// 各项评分对总分的加权贡献（键与 RiskDetails 一致），总和即 TotalScore
+	ScoreBreakdown map[string]float64 `json:"score_breakdown"`
+	// 评分计算过程（-explain 输出），不写入 JSON 报告
+	ScoreTrace       []ScoreTraceItem `json:"-"`
+	ScoreBoost       float64          `json:"-"` // 最终超售可信度加成（1 表示未加成）
+	ScoreBoostReason string           `json:"-"`
+
+	// 续费建议（仅月报计算）：综合当前评分、基线趋势和日评分波动
+	Recommendation           Recommendation `json:"recommendation,omitempty"`
+	RecommendationConfidence Confidence     `json:"recommendation_confidence,omitempty"`
+	DailyScoreVolatility     float64        `json:"daily_score_volatility"` // 日评分标准差
+	DailyScoreDays           int            `json:"daily_score_days"`       // 参与计算的天数
+
+	// 较初始状态（仅月报计算）：锚定数据库中最早的完整 24 小时窗口
+	SinceInstall *InstallComparison `json:"since_install,omitempty"`
 }
 
 // Analyzer 分析器
 type Analyzer struct {
-	store *storage.Storage
+	store  *storage.Storage
+	config *config.Config
+
+	forcedStorage collector.StorageType // 命令行强制指定的存储类型，为空时按延迟推断
+}
+
+// ForceStorageType 跳过延迟推断，固定使用指定的存储类型评分（用于排查阈值选择）
+func (a *Analyzer) ForceStorageType(t collector.StorageType) {
+	a.forcedStorage = t
 }
 
 // NewAnalyzer 创建分析器
 // 存储类型将在 AnalyzePeriod 时根据实测的随机读延迟动态推断
-func NewAnalyzer(store *storage.Storage) *Analyzer {
+func NewAnalyzer(store *storage.Storage, cfg *config.Config) *Analyzer {
 	return &Analyzer{
-		store: store,
+		store:  store,
+		config: cfg,
 	}
 }
 
+// ErrNoData 周期内没有任何核心指标样本（如守护进程整个周期都未运行），重试无意义
+var ErrNoData = errors.New("周期内没有采集数据")
+
 // AnalyzePeriod 分析指定周期的数据
 func (a *Analyzer) AnalyzePeriod(period string, start, end time.Time) (*PeriodStats, error) {
 	stats := &PeriodStats{
-		Period:      period,
-		StartTime:   start,
-		EndTime:     end,
-		StorageType: collector.StorageTypeUnknown, // 初始为未知，后续根据延迟推断
-		RiskDetails: make(map[string]string),
+		Period:         period,
+		StartTime:      start,
+		EndTime:        end,
+		StorageType:    collector.StorageTypeUnknown, // 初始为未知，后续根据延迟推断
+		RiskDetails:    make(map[string]string),
+		ScoreBreakdown: make(map[string]float64),
+		PassiveOnly:    a.config.Collect.PassiveOnly,
 	}
+	stats.Labels = a.config.Labels
 
 	// 查询各类指标
-	cpuStealMetrics, _ := a.store.Query(storage.MetricTypeCPUSteal, start, end)
-	cpuBenchMetrics, _ := a.store.Query(storage.MetricTypeCPUBench, start, end)
-	ioLatencyMetrics, _ := a.store.Query(storage.MetricTypeIOLatency, start, end)
-	memoryMetrics, _ := a.store.Query(storage.MetricTypeMemory, start, end)
+	// 只需要主值的指标走 QueryValuesOnly 快速路径，跳过 extra 反序列化
+	cpuSteal := a.querySeries(storage.MetricTypeCPUSteal, start, end)
+	cpuIoWait := a.querySeries(storage.MetricTypeCPUIoWait, start, end)
+	cpuBench := a.querySeries(storage.MetricTypeCPUBench, start, end)
+	ioLatency := a.querySeries(storage.MetricTypeIOLatency, start, end)
+	memoryMetrics, err := a.store.Query(storage.MetricTypeMemory, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("查询 %s 失败: %w", storage.MetricTypeMemory, err)
+	}
+
+	// 核心指标查询失败（如数据库短暂锁定）时返回错误，由调用方重试；其余指标查询失败按缺失处理
+	for _, sr := range []series{cpuSteal, cpuIoWait, cpuBench, ioLatency} {
+		if sr.err != nil {
+			return nil, fmt.Errorf("查询 %s 失败: %w", sr.metricType, sr.err)
+		}
+	}
+	if cpuSteal.len()+cpuIoWait.len()+cpuBench.len()+ioLatency.len()+len(memoryMetrics) == 0 {
+		return nil, ErrNoData
+	}
+
+	if a.config.GetRecencyHalfLife() > 0 {
+		stats.RecencyHalfLife = a.config.Analysis.RecencyHalfLife
+	}
+
+	// 业务时段掩码：仅用时段内的样本评分；数据覆盖率与时段分布仍基于全天数据
+	rawSteal, rawIoWait, rawIOLatency := cpuSteal, cpuIoWait, ioLatency
+	if a.businessHours() != nil {
+		stats.BusinessHours = a.config.Report.BusinessHours
+		cpuSteal = a.maskSeries(cpuSteal)
+		cpuIoWait = a.maskSeries(cpuIoWait)
+		cpuBench = a.maskSeries(cpuBench)
+		ioLatency = a.maskSeries(ioLatency)
+		memoryMetrics = a.maskMetrics(memoryMetrics)
+	}
 
 	// 计算 CPU Steal 统计
-	if len(cpuStealMetrics) > 0 {
-		values := extractValues(cpuStealMetrics)
-		stats.CPUStealAvg = avg(values)
-		stats.CPUStealMax = percentile(values, 99) // 使用 P99 作为实用峰值，避免极端异常干扰
-		stats.CPUStealP95 = percentile(values, 95)
+	if cpuSteal.len() > 0 {
+		// 使用 P99 作为实用峰值，避免极端异常干扰
+		stats.CPUStealAvg, stats.CPUStealP95, stats.CPUStealMax = a.distribution(cpuSteal)
 		// 记录峰值发生时间
-		_, stats.CPUStealMaxTime = findMaxWithTime(cpuStealMetrics)
+		_, stats.CPUStealMaxTime = findMaxWithTime(cpuSteal)
+	}
+
+	if suspendSteal, _, err := a.store.QueryValuesOnly(storage.MetricTypeCPUStealSuspend, start, end); err == nil && len(suspendSteal) > 0 {
+		stats.SuspendEvents = len(suspendSteal)
+		stats.SuspendStealMax = max(suspendSteal)
+	}
+
+	stats.Virtualization = a.virtualization()
+	stats.StealUntrusted = stats.Virtualization.Container()
+	stats.CPUTenancy, stats.CPUTenancyReason = a.classifyCPUTenancy(end, stats.Virtualization)
+
+	if interval, deadline := a.config.GetCanary(); interval > 0 {
+		stats.IOStallDeadlineMs = float64(deadline.Microseconds()) / 1000.0
+		if stalls, _, err := a.store.QueryValuesOnly(storage.MetricTypeIOStall, start, end); err == nil && len(stalls) > 0 {
+			stats.IOStalls = len(stalls)
+			stats.IOStallMaxMs = max(stalls)
+		}
 	}
 
 	// 计算 CPU IOWait 统计
-	cpuIoWaitMetrics, _ := a.store.Query(storage.MetricTypeCPUIoWait, start, end)
-	if len(cpuIoWaitMetrics) > 0 {
-		values := extractValues(cpuIoWaitMetrics)
-		stats.CPUIoWaitAvg = avg(values)
-		stats.CPUIoWaitMax = percentile(values, 99) // 使用 P99 作为实用峰值
-		stats.CPUIoWaitP95 = percentile(values, 95)
+	if cpuIoWait.len() > 0 {
+		// 使用 P99 作为实用峰值
+		stats.CPUIoWaitAvg, stats.CPUIoWaitP95, stats.CPUIoWaitMax = a.distribution(cpuIoWait)
 		// 记录峰值发生时间
-		_, stats.CPUIoWaitMaxTime = findMaxWithTime(cpuIoWaitMetrics)
+		_, stats.CPUIoWaitMaxTime = findMaxWithTime(cpuIoWait)
 	}
 
+	// 计算数据覆盖率
+	stats.DataCoverage, stats.DataMissing = a.calculateCoverage(rawSteal, start, end)
+	stats.DataSufficiency = stats.DataCoverage >= a.config.Analysis.MinCoverage
+	stats.StaleMetrics = a.detectStaleMetrics(end)
+	stats.CollectErrors = a.calculateCollectErrors(start, end)
+
 	// 计算时段分布（用于周报/月报分析）
-	if len(cpuStealMetrics) > 0 || len(cpuIoWaitMetrics) > 0 {
-		stats.HourlyBreakdown = calculateHourlyBreakdown(cpuStealMetrics, cpuIoWaitMetrics)
+	if rawSteal.len() > 0 || rawIoWait.len() > 0 {
+		stats.HourlyBreakdown = calculateHourlyBreakdown(rawSteal, rawIoWait, rawIOLatency)
 	}
 
 	// 计算 CPU 基准测试统计
-	if len(cpuBenchMetrics) > 0 {
-		values := extractValues(cpuBenchMetrics)
-		stats.CPUBenchAvg = avg(values)
+	if cpuBench.len() > 0 {
+		values := cpuBench.values
+		stats.CPUBenchAvg = weightedAvg(values, a.recencyWeights(cpuBench.times, end))
 		stats.CPUBenchCV = coefficientOfVariation(values)
-	}
-
-	// 计算 I/O 延迟统计
-	if len(ioLatencyMetrics) > 0 {
-		values := extractValues(ioLatencyMetrics)
-		stats.IOLatencyAvg = avg(values)
-		stats.IOLatencyP95 = percentile(values, 95)
-		stats.IOLatencyP99 = percentile(values, 99)
-	}
 
-	// 计算内存统计（使用平均可用率，而非单点值）
-	if len(memoryMetrics) > 0 {
-		var availPercents []float64
-		for _, m := range memoryMetrics {
-			if m.Extra != nil {
-				if availPct, ok := m.Extra["available_percent"].(float64); ok {
-					availPercents = append(availPercents, availPct)
-				}
+		// 与参考性能对比：使用中位数衡量持续水平，不受偶发抖动影响
+		if ref := a.config.Analysis.ReferenceBenchMs; ref > 0 {
+			if median := percentile(values, 50); median > 0 {
+				stats.CPUPerfPercent = ref / median * 100
+				stats.CPUPerfDegraded = stats.CPUPerfPercent < a.config.Analysis.ReferenceMinPercent
 			}
 		}
-		if len(availPercents) > 0 {
-			stats.MemoryAvailablePercent = avg(availPercents)
-		} else {
-			// 降级：从 Value（使用率）计算可用率
-			values := extractValues(memoryMetrics)
-			stats.MemoryAvailablePercent = 100 - avg(values)
-		}
 	}
 
-	// 计算 CPU Load 统计
-	cpuLoadMetrics, _ := a.store.Query(storage.MetricTypeCPULoad, start, end)
-	if len(cpuLoadMetrics) > 0 {
-		values := extractValues(cpuLoadMetrics)
-		stats.CPULoadAvg = avg(values)
-		stats.CPULoadMax = percentile(values, 99) // 使用 P99 作为实用峰值
+	// 计算 CPU 温度统计
+	if temps, _, err := a.store.QueryValuesOnly(storage.MetricTypeCPUTemp, start, end); err == nil && len(temps) > 0 {
+		stats.CPUTempAvg = avg(temps)
+		stats.CPUTempMax = max(temps)
+		stats.CPUTempSamples = len(temps)
+		stats.ThermalThrottling = stats.CPUBenchCV >= thermalCVThreshold && stats.CPUTempMax >= thermalHighCelsius
+	}
+
+	// 检测 vCPU 热插拔：记录周期内在线数量的变化序列
+	if counts, _, err := a.store.QueryValuesOnly(storage.MetricTypeCPUOnline, start, end); err == nil {
+		stats.CPUOnlineChanges = onlineCPUChanges(counts)
 	}
 
 	// 计算随机 IO 统计
 	randomIOMetrics, _ := a.store.Query(storage.MetricTypeRandomIO, start, end)
+	randomIOMetrics = a.maskMetrics(randomIOMetrics)
 	if len(randomIOMetrics) > 0 {
 		var writeLatencies, readLatencies []float64
 		for _, m := range randomIOMetrics {
@@ -208,6 +539,9 @@ func (a *Analyzer) AnalyzePeriod(period string, start, end time.Time) (*PeriodSt
 				if wl, ok := m.Extra["write_latency_ms"].(float64); ok {
 					writeLatencies = append(writeLatencies, wl)
 				}
+				if fs, ok := m.Extra["fs_type"].(string); ok && fs != "" {
+					stats.FilesystemType = fs // 取最近一次测试所在的文件系统
+				}
 				if rl, ok := m.Extra["read_latency_ms"].(float64); ok {
 					readLatencies = append(readLatencies, rl)
 				}
@@ -229,213 +563,1131 @@ func (a *Analyzer) AnalyzePeriod(period string, start, end time.Time) (*PeriodSt
 			stats.StorageType = collector.DetectStorageTypeByLatency(stats.RandomIOReadAvg)
 		}
 	}
+	if a.forcedStorage != "" {
+		stats.StorageType = a.forcedStorage
+		stats.StorageTypeForced = true
+	}
+	if stats.FilesystemType != "" {
+		stats.FilesystemKind = collector.ClassifyFilesystem(stats.FilesystemType)
+	}
 
-	// 计算磁盘繁忙度（从 disk_stats 采集的增量数据）
-	diskStatsMetrics, _ := a.store.Query(storage.MetricTypeDiskStats, start, end)
-	if len(diskStatsMetrics) >= 2 {
-		// 计算时间段内的平均繁忙度
-		var busyPercents []float64
-		for _, m := range diskStatsMetrics {
+	// 最差时段（写延迟按存储类型阈值归一化，需在推断出存储类型之后计算）
+	stats.WorstHour, stats.WorstHourScore = findWorstHour(stats.HourlyBreakdown, ioExpectation(stats))
+
+	// 计算 I/O 延迟统计（依赖上面推断出的存储类型来识别缓存污染样本）
+	if ioLatency.len() > 0 {
+		values, times, excluded := a.excludeCacheContaminated(ioLatency.values, ioLatency.times, stats.StorageType)
+		stats.IOLatencyCacheSamples = excluded
+		if len(values) > 0 {
+			w := a.recencyWeights(times, end)
+			stats.IOLatencyAvg = weightedAvg(values, w)
+			stats.IOLatencyP95 = weightedPercentile(values, w, 95)
+			stats.IOLatencyP99 = weightedPercentile(values, w, 99)
+		}
+	}
+
+	// 单轮离散度：一轮内多次测量的延迟差异，反映分钟级之下的抖动
+	if ioLatency.len() > 0 {
+		stats.IOLatencySpreadAvg, stats.IOSamplesPerRun = a.calculateIOSpread(start, end)
+	}
+
+	// 落盘探测（依赖推断出的存储类型判断 fsync 耗时是否低得不合理）
+	syncProbeMetrics, _ := a.store.Query(storage.MetricTypeSyncProbe, start, end)
+	syncProbeMetrics = a.maskMetrics(syncProbeMetrics)
+	if len(syncProbeMetrics) > 0 {
+		values := make([]float64, len(syncProbeMetrics))
+		for i, m := range syncProbeMetrics {
+			values[i] = m.Value
+		}
+		stats.SyncProbeMs = percentile(values, 50)
+		stats.SyncSuspect = syncImplausible(stats.SyncProbeMs, stats.StorageType, stats.FilesystemType)
+	}
+
+	// 计算内存统计（使用平均可用率，而非单点值）
+	if len(memoryMetrics) > 0 {
+		var availPercents []float64
+		var availTimes []time.Time
+		for _, m := range memoryMetrics {
 			if m.Extra != nil {
-				if bp, ok := m.Extra["busy_percent"].(float64); ok {
-					busyPercents = append(busyPercents, bp)
+				if availPct, ok := m.Extra["available_percent"].(float64); ok {
+					availPercents = append(availPercents, availPct)
+					availTimes = append(availTimes, m.Timestamp)
 				}
 			}
 		}
-		if len(busyPercents) > 0 {
-			stats.DiskBusyPercent = avg(busyPercents)
-			stats.DiskBusyP95 = percentile(busyPercents, 95) // 添加 P95 感知 IO 抖动
+		if len(availPercents) > 0 {
+			stats.MemoryAvailablePercent = weightedAvg(availPercents, a.recencyWeights(availTimes, end))
+		} else {
+			// 降级：从 Value（使用率）计算可用率
+			values := extractValues(memoryMetrics)
+			times := make([]time.Time, len(memoryMetrics))
+			for i, m := range memoryMetrics {
+				times[i] = m.Timestamp
+			}
+			stats.MemoryAvailablePercent = 100 - weightedAvg(values, a.recencyWeights(times, end))
+		}
+	}
+
+	// 计算内存缺页延迟统计
+	memFault := a.maskSeries(a.querySeries(storage.MetricTypeMemFault, start, end))
+	if memFault.len() > 0 {
+		stats.MemFaultAvg = avg(memFault.values)
+		stats.MemFaultCV = coefficientOfVariation(memFault.values)
+		stats.MemBallooningSuspected = stats.MemFaultCV >= memFaultUnstableCV && stats.MemoryAvailablePercent > 50
+	}
+
+	// 计算 CPU Load 统计
+	cpuLoad := a.maskSeries(a.querySeries(storage.MetricTypeCPULoad, start, end))
+	if cpuLoad.len() > 0 {
+		values := cpuLoad.values
+		stats.CPULoadAvg = avg(values)
+		stats.CPULoadMax = percentile(values, 99) // 使用 P99 作为实用峰值
+	}
+
+	runQueue := a.maskSeries(a.querySeries(storage.MetricTypeRunQueue, start, end))
+	if runQueue.len() > 0 {
+		stats.RunQueueAvg = avg(runQueue.values)
+		stats.RunQueueP95 = percentile(runQueue.values, 95)
+	}
+
+	if period == "weekly" || period == "monthly" {
+		stats.DayTypes = calculateDayTypeComparison(cpuSteal, cpuLoad)
+	}
+
+	a.detectBurstCredit(stats, cpuSteal, cpuLoad)
+
+	// 计算磁盘繁忙度与队列深度（由相邻两次 disk_stats 累计值的差分得出）
+	diskStatsMetrics, _ := a.store.Query(storage.MetricTypeDiskStats, start, end)
+	diskStatsMetrics = a.maskMetrics(diskStatsMetrics)
+	if d := calculateDiskDeltas(diskStatsMetrics); len(d.busy) > 0 {
+		stats.DiskBusyPercent = avg(d.busy)
+		stats.DiskBusyP95 = percentile(d.busy, 95) // 添加 P95 感知 IO 抖动
+		stats.DiskIOPS = avg(d.iops)
+		if len(d.queueDepth) > 0 {
+			stats.DiskQueueDepth = avg(d.queueDepth)
+		}
+		if d.ops+d.merges > 0 {
+			stats.DiskMergePercent = d.merges / (d.ops + d.merges) * 100
+		}
+		stats.DiskQueueCongested = stats.DiskQueueDepth >= congestedQueueDepth && stats.DiskIOPS < congestedMaxIOPS
+	}
+
+	// 计算网络流量（由相邻两次 network 累计值的差分得出）
+	networkMetrics, _ := a.store.Query(storage.MetricTypeNetwork, start, end)
+	networkMetrics = a.maskMetrics(networkMetrics)
+	if n := calculateNetworkDeltas(networkMetrics); len(n.rx) > 0 {
+		stats.NetworkInterface = n.iface
+		stats.NetworkRxMbps = avg(n.rx)
+		stats.NetworkTxMbps = avg(n.tx)
+		stats.NetworkP95Mbps = percentile(n.total, 95)
+	}
+
+	// 计算调度等待（由相邻两次 schedstat 累计值的差分得出）
+	schedMetrics, _ := a.store.Query(storage.MetricTypeSchedStat, start, end)
+	schedMetrics = a.maskMetrics(schedMetrics)
+	if d := calculateSchedDeltas(schedMetrics); len(d.waitPercent) > 0 {
+		stats.SchedWaitAvg = avg(d.waitPercent)
+		stats.SchedWaitP95 = percentile(d.waitPercent, 95)
+		if d.slices > 0 {
+			stats.SchedWaitPerSliceMs = d.delayNs / d.slices / 1e6
+		}
+		// 本地负载判定与超售可信度加成一致（归一化 load1 < 0.7）
+		stats.SchedContention = stats.SchedWaitAvg >= schedContentionPercent && stats.CPULoadAvg < 0.7
+	}
+
+	// 计算 NUMA 远程访问比例（由相邻两次 numa 累计值的差分得出）
+	numaMetrics, _ := a.store.Query(storage.MetricTypeNUMA, start, end)
+	numaMetrics = a.maskMetrics(numaMetrics)
+	if d := calculateNUMADeltas(numaMetrics); d.nodes >= 2 {
+		stats.NUMANodes = d.nodes
+		if d.local+d.other > 0 {
+			stats.NUMARemotePercent = d.other / (d.local + d.other) * 100
+		}
+		if d.hit+d.miss > 0 {
+			stats.NUMAMissPercent = d.miss / (d.hit + d.miss) * 100
 		}
 	}
 
+	stats.Samples = map[string]int{
+		"cpu_steal":  cpuSteal.len(),
+		"iowait":     cpuIoWait.len(),
+		"io_latency": ioLatency.len(),
+		"random_io":  len(randomIOMetrics),
+		"disk_busy":  len(diskStatsMetrics),
+		"memory":     len(memoryMetrics),
+		"load":       cpuLoad.len(),
+		"network":    len(networkMetrics),
+		"sync_probe": len(syncProbeMetrics),
+		"numa":       len(numaMetrics),
+		"schedstat":  len(schedMetrics),
+	}
+
+	// 计算自定义指标统计
+	stats.CustomMetrics = a.calculateCustomMetrics(start, end)
+
+	// 计算多指标一致性（Steal 与 I/O 延迟、基准测试是否同步劣化）
+	stats.Consistency = calculateConsistency(cpuSteal, ioLatency, cpuBench, stats.CPUTenancy, ioExpectation(stats))
+
 	// 计算基线偏离
 	stats.BaselineDeviation, stats.BaselineStatus = a.calculateBaselineDeviation(stats)
 
 	// 计算综合评分
 	a.calculateScore(stats)
 
+	// 月报给出续费建议
+	if period == "monthly" {
+		dailyScores := a.calculateDailyScores(cpuSteal, cpuIoWait, ioLatency, ioExpectation(stats), stats.CPUTenancy)
+		a.calculateRecommendation(stats, dailyScores)
+		stats.SinceInstall = a.calculateSinceInstall(stats)
+	}
+
 	return stats, nil
 }
 
+// calculateDailyScores 按自然日计算简化评分，用于衡量评分在周期内的波动
+// 仅使用有连续时间序列的核心指标（Steal、IOWait、顺序写延迟），按原权重归一化
+func (a *Analyzer) calculateDailyScores(steal, iowait, ioLatency series, storageType collector.StorageType, tenancy CPUTenancy) []float64 {
+	type dayData struct {
+		steal, iowait, io []float64
+	}
+	days := make(map[string]*dayData)
+	var keys []string
+	bucket := func(sr series, pick func(d *dayData, v float64)) {
+		for i, v := range sr.values {
+			key := sr.times[i].Format("2006-01-02")
+			if days[key] == nil {
+				days[key] = &dayData{}
+				keys = append(keys, key)
+			}
+			pick(days[key], v)
+		}
+	}
+	bucket(steal, func(d *dayData, v float64) { d.steal = append(d.steal, v) })
+	bucket(iowait, func(d *dayData, v float64) { d.iowait = append(d.iowait, v) })
+	bucket(ioLatency, func(d *dayData, v float64) { d.io = append(d.io, v) })
+	sort.Strings(keys)
+
+	var scores []float64
+	for _, key := range keys {
+		d := days[key]
+		var total, weight float64
+		if len(d.steal) > 0 {
+			total += a.scoreCPUSteal(avg(d.steal), tenancy) * WeightCPUSteal
+			weight += WeightCPUSteal
+		}
+		if len(d.iowait) > 0 {
+			total += a.scoreCPUIoWait(avg(d.iowait)) * WeightCPUIoWait
+			weight += WeightCPUIoWait
+		}
+		if len(d.io) > 0 {
+			total += a.scoreIOLatency(percentile(d.io, 95), storageType) * WeightIOLatency
+			weight += WeightIOLatency
+		}
+		if weight > 0 {
+			scores = append(scores, total/weight)
+		}
+	}
+	return scores
+}
+
+// calculateRecommendation 综合当前评分、基线趋势和评分波动给出续费建议
+//   - 建议更换：评分严重（<50），或评分中等（<70）且在持续恶化
+//   - 建议续费：评分良好（>=70）、未恶化且日评分波动不大
+//   - 其余情况继续观察
+//
+// 置信度取决于数据天数和波动：数据越多、波动越小越可信；趋势与结论相反时降一级
+func (a *Analyzer) calculateRecommendation(stats *PeriodStats, dailyScores []float64) {
+	stats.DailyScoreDays = len(dailyScores)
+	stats.DailyScoreVolatility = stdDev(dailyScores)
+	degrading := stats.BaselineStatus == "degrading"
+	volatility := stats.DailyScoreVolatility
+
+	switch {
+	case stats.TotalScore < 50 || (stats.TotalScore < 70 && degrading):
+		stats.Recommendation = RecommendationSwitch
+	case stats.TotalScore >= 70 && !degrading && volatility < 15:
+		stats.Recommendation = RecommendationRenew
+	default:
+		stats.Recommendation = RecommendationMonitor
+	}
+
+	switch {
+	case stats.DailyScoreDays < 7 || volatility >= 20:
+		stats.RecommendationConfidence = ConfidenceLow
+	case stats.DailyScoreDays >= 20 && volatility < 8:
+		stats.RecommendationConfidence = ConfidenceHigh
+	default:
+		stats.RecommendationConfidence = ConfidenceMedium
+	}
+
+	// 建议更换但趋势在改善，降低置信度
+	if stats.Recommendation == RecommendationSwitch && stats.BaselineStatus == "improving" {
+		switch stats.RecommendationConfidence {
+		case ConfidenceHigh:
+			stats.RecommendationConfidence = ConfidenceMedium
+		case ConfidenceMedium:
+			stats.RecommendationConfidence = ConfidenceLow
+		}
+	}
+}
+
+// installWindow 安装初期对比窗口长度
+const installWindow = 24 * time.Hour
+
+// calculateSinceInstall 与数据库中最早的完整 24 小时窗口对比
+// 与滚动基线不同，该锚点固定为机器最初的状态，可暴露缓慢累积的退化（如宿主机逐渐被塞满）。
+// 初始窗口与当前周期重叠（数据不足）时返回 nil
+func (a *Analyzer) calculateSinceInstall(stats *PeriodStats) *InstallComparison {
+	earliest, err := a.store.EarliestTimestamp()
+	if err != nil || earliest.IsZero() {
+		return nil
+	}
+
+	windowEnd := earliest.Add(installWindow)
+	if windowEnd.After(stats.StartTime) {
+		return nil
+	}
+
+	// 最小基准值，避免初始值极小导致变化百分比被过度放大（与基线偏离计算一致）
+	items := []struct {
+		name       string
+		unit       string
+		metricType storage.MetricType
+		current    float64
+		minBase    float64
+	}{
+		{"CPU Steal", "%", storage.MetricTypeCPUSteal, stats.CPUStealAvg, 0.5},
+		{"IOWait", "%", storage.MetricTypeCPUIoWait, stats.CPUIoWaitAvg, 0.5},
+		{"顺序写延迟", "ms", storage.MetricTypeIOLatency, stats.IOLatencyAvg, 5.0},
+		{"CPU 基准耗时", "ms", storage.MetricTypeCPUBench, stats.CPUBenchAvg, 1.0},
+		{"内存缺页耗时", "ms", storage.MetricTypeMemFault, stats.MemFaultAvg, 1.0},
+	}
+
+	result := &InstallComparison{WindowStart: earliest, WindowEnd: windowEnd}
+	for _, item := range items {
+		values := a.querySeries(item.metricType, earliest, windowEnd).values
+		if len(values) == 0 || item.current == 0 {
+			continue
+		}
+		initial := avg(values)
+		base := initial
+		if base < item.minBase {
+			base = item.minBase
+		}
+		result.Changes = append(result.Changes, MetricChange{
+			Name:          item.name,
+			Unit:          item.unit,
+			Initial:       initial,
+			Current:       item.current,
+			ChangePercent: (item.current - initial) / base * 100,
+		})
+	}
+
+	if len(result.Changes) == 0 {
+		return nil
+	}
+	return result
+}
+
+// calculateCustomMetrics 汇总周期内出现过的全部自定义指标
+func (a *Analyzer) calculateCustomMetrics(start, end time.Time) []CustomMetricStats {
+	types, err := a.store.ListMetricTypes(storage.CustomMetricPrefix, start, end)
+	if err != nil {
+		return nil
+	}
+
+	var result []CustomMetricStats
+	for _, t := range types {
+		values, _, _ := a.store.QueryValuesOnly(t, start, end)
+		if len(values) == 0 {
+			continue
+		}
+		result = append(result, CustomMetricStats{
+			Name:    strings.TrimPrefix(string(t), storage.CustomMetricPrefix),
+			Avg:     avg(values),
+			Min:     min(values),
+			Max:     max(values),
+			Samples: len(values),
+		})
+	}
+	return result
+}
+
+// calculateCoverage 检测数据缺口并计算覆盖率
+// 相邻样本（含周期起止边界）间隔超过 gap_factor 倍采集间隔即视为缺口，
+// 缺口时长扣除一个正常采集间隔后计入缺失时间
+func (a *Analyzer) calculateCoverage(sr series, start, end time.Time) (float64, time.Duration) {
+	period := end.Sub(start)
+	if period <= 0 {
+		return 0, 0
+	}
+	if sr.len() == 0 {
+		return 0, period
+	}
+
+	interval := a.config.ExpectedCPUStealInterval()
+	if sr.resolution > interval {
+		interval = sr.resolution // 聚合序列的样本间隔为桶宽
+	}
+	threshold := time.Duration(float64(interval) * a.config.Analysis.GapFactor)
+
+	var missing time.Duration
+	addGap := func(from, to time.Time) {
+		if gap := to.Sub(from); gap > threshold {
+			missing += gap - interval
+		}
+	}
+
+	addGap(start, sr.times[0])
+	for i := 1; i < sr.len(); i++ {
+		addGap(sr.times[i-1], sr.times[i])
+	}
+	addGap(sr.times[sr.len()-1], end)
+
+	if missing > period {
+		missing = period
+	}
+	return float64(period-missing) / float64(period) * 100, missing
+}
+
+// excludeCacheContaminated 排除疑似命中缓存的 I/O 延迟样本
+// 当 O_DIRECT 不可用或 fsync 被忽略时，写入落在页缓存，延迟可低至 0.01ms，
+// 这类样本会把平均值拉向"优秀"并掩盖真实的卡顿。仅在确认为 SSD/HDD 时过滤，
+// 未知存储类型（可能就是内存盘）保留原值。返回过滤后的样本、对应时间及被排除的数量
+func (a *Analyzer) excludeCacheContaminated(values []float64, times []time.Time, storageType collector.StorageType) ([]float64, []time.Time, int) {
+	floor := a.config.Analysis.IOLatencyFloorMs
+	if floor <= 0 || (storageType != collector.StorageTypeSSD && storageType != collector.StorageTypeHDD) {
+		return values, times, 0
+	}
+
+	kept := make([]float64, 0, len(values))
+	keptTimes := make([]time.Time, 0, len(times))
+	for i, v := range values {
+		if v >= floor {
+			kept = append(kept, v)
+			keptTimes = append(keptTimes, times[i])
+		}
+	}
+	return kept, keptTimes, len(values) - len(kept)
+}
+
+// calculateIOSpread 汇总 io_latency 样本记录的单轮离散度（spread_ms），
+// 只统计多次测量的样本，返回平均离散度与最近一次的每轮测量次数
+func (a *Analyzer) calculateIOSpread(start, end time.Time) (float64, int) {
+	metrics, err := a.store.Query(storage.MetricTypeIOLatency, start, end)
+	if err != nil {
+		return 0, 0
+	}
+	var spreads []float64
+	samples := 0
+	for _, m := range a.maskMetrics(metrics) {
+		n, _ := m.Extra["samples"].(float64)
+		if n < 2 {
+			continue
+		}
+		if spread, ok := m.Extra["spread_ms"].(float64); ok {
+			spreads = append(spreads, spread)
+			samples = int(n)
+		}
+	}
+	if len(spreads) == 0 {
+		return 0, 0
+	}
+	return avg(spreads), samples
+}
+
+// 真正落盘的 4KB fdatasync 耗时下限：HDD 至少需要一次盘片写入（毫秒级）；
+// SSD 与云盘即使带掉电保护也需要一次设备/网络往返（数十微秒），
+// 只写入页缓存或宿主机 cache=unsafe 时通常只有几微秒
+const (
+	syncFloorHDDMs = 1.0
+	syncFloorMs    = 0.02
+)
+
+// syncImplausible 判断 fsync 中位耗时是否低于存储类别的物理下限
+// 测试目录位于内存文件系统时 fsync 本就无需落盘，不做判断
+func syncImplausible(medianMs float64, storageType collector.StorageType, fsType string) bool {
+	if medianMs <= 0 || fsType == "tmpfs" || fsType == "ramfs" {
+		return false
+	}
+	if storageType == collector.StorageTypeHDD {
+		return medianMs < syncFloorHDDMs
+	}
+	return medianMs < syncFloorMs
+}
+
 // calculateScore 计算综合评分
+// 每个评分项同时记录计算过程（ScoreTrace），供 -explain 输出；
+// 被动模式下跳过依赖主动测试的评分项，其余项权重按比例放大
 func (a *Analyzer) calculateScore(stats *PeriodStats) {
 	var totalScore float64
+	stats.ScoreTrace = nil
+	weights := weightsFor(stats)
+	loc := a.locale()
+	add := func(item ScoreTraceItem) {
+		if a.config.Analysis.SmoothScoringEnabled(item.Key) {
+			if item.Note != "" {
+				item.Note += loc.T("；")
+			}
+			item.Note += loc.T("线性插值")
+		}
+		item.Weight = weights[item.Key]
+		item.Contribution = item.Score * item.Weight
+		totalScore += item.Contribution
+		stats.ScoreBreakdown[item.Key] = item.Contribution
+		stats.ScoreTrace = append(stats.ScoreTrace, item)
+	}
 
-	// 计算超售可信度加成（基于本地负载佐证）
+	// 计算超售可信度加成（基于本地负载佐证，多项指标同步劣化时进一步加成）
 	confidenceBoost := a.calculateOversellConfidenceBoost(stats)
+	stats.ScoreBoostReason = a.describeConfidenceBoost(stats, confidenceBoost)
+	if !stats.BurstCreditSuspected {
+		if boosted := consistencyBoost(confidenceBoost, stats.Consistency); boosted != confidenceBoost {
+			stats.ScoreBoostReason += loc.Sprintf("；多项指标同步劣化，加成 ×%.2f", boosted/confidenceBoost)
+			confidenceBoost = boosted
+		}
+	}
+	stats.ScoreBoost = confidenceBoost
 
 	// 1. CPU Steal 评分 (35%) - 应用佐证因子
-	cpuStealScore := a.scoreCPUSteal(stats.CPUStealAvg)
+	steal := stealBands(stats.CPUTenancy)
+	cpuStealScore := a.scoreCPUSteal(stats.CPUStealAvg, stats.CPUTenancy)
+	stealItem := ScoreTraceItem{Key: "cpu_steal", Value: loc.Sprintf("均值 %.2f%%", stats.CPUStealAvg),
+		Bucket: steal.describe(stats.CPUStealAvg), SubScore: cpuStealScore, Boost: 1}
+	if stats.CPUTenancy == CPUTenancyDedicated {
+		stealItem.Note = loc.T("按独享核心阈值")
+	}
 	// 当 confidenceBoost > 1 时，低分会变得更低（更严厉）
 	if confidenceBoost > 1.0 && cpuStealScore < 100 {
 		cpuStealScore = cpuStealScore / confidenceBoost
+		stealItem.Boost = confidenceBoost
 	}
-	totalScore += cpuStealScore * WeightCPUSteal
-	stats.RiskDetails["cpu_steal"] = a.describeCPUStealRisk(stats.CPUStealAvg, stats.CPUStealMax)
+	stealItem.Score = cpuStealScore
+	add(stealItem)
+	stats.RiskDetails["cpu_steal"] = a.describeCPUStealRisk(stats.CPUStealAvg, stats.CPUStealMax, stats.CPUTenancy)
 
 	// 2. CPU IOWait 评分 (10%) - 应用佐证因子
 	cpuIoWaitScore := a.scoreCPUIoWait(stats.CPUIoWaitAvg)
+	ioWaitItem := ScoreTraceItem{Key: "cpu_iowait", Value: loc.Sprintf("均值 %.2f%%", stats.CPUIoWaitAvg),
+		Bucket: ioWaitBands.describe(stats.CPUIoWaitAvg), SubScore: cpuIoWaitScore, Boost: 1}
 	if confidenceBoost > 1.0 && cpuIoWaitScore < 100 {
 		cpuIoWaitScore = cpuIoWaitScore / confidenceBoost
+		ioWaitItem.Boost = confidenceBoost
 	}
-	totalScore += cpuIoWaitScore * WeightCPUIoWait
+	ioWaitItem.Score = cpuIoWaitScore
+	add(ioWaitItem)
 	stats.RiskDetails["cpu_iowait"] = a.describeCPUIoWaitRisk(stats.CPUIoWaitAvg)
 
-	// 3. CPU 稳定性评分 (10%)
-	cpuStabilityScore := a.scoreCPUStability(stats.CPUBenchCV)
-	totalScore += cpuStabilityScore * WeightCPUStability
-	stats.RiskDetails["cpu_stability"] = a.describeCPUStabilityRisk(stats.CPUBenchCV)
-
-	// 4. I/O 顺序延迟评分 (15%)
-	ioScore := a.scoreIOLatency(stats.IOLatencyP95, stats.StorageType)
-	totalScore += ioScore * WeightIOLatency
-	stats.RiskDetails["io_latency"] = a.describeIOLatencyRisk(stats.IOLatencyP95, stats.StorageType)
-
-	// 5. I/O 随机延迟评分 (10%)
-	randomIOScore := a.scoreRandomIO(stats.RandomIOP95, stats.StorageType)
-	totalScore += randomIOScore * WeightRandomIO
-	stats.RiskDetails["random_io"] = a.describeRandomIORisk(stats.RandomIOWriteAvg, stats.RandomIOReadAvg, stats.StorageType)
+	if !stats.PassiveOnly {
+		// 3. CPU 稳定性评分 (10%)
+		cpuStabilityScore := a.scoreCPUStability(stats.CPUBenchCV)
+		add(ScoreTraceItem{Key: "cpu_stability", Value: loc.Sprintf("基准测试 CV %.3f", stats.CPUBenchCV),
+			Bucket: stabilityBands.describe(stats.CPUBenchCV), SubScore: cpuStabilityScore, Boost: 1, Score: cpuStabilityScore})
+		stats.RiskDetails["cpu_stability"] = a.describeCPUStabilityRisk(stats.CPUBenchCV)
+
+		// 4. I/O 顺序延迟评分 (15%)
+		ioStorage := ioExpectation(stats)
+		ioScore := a.scoreIOLatency(stats.IOLatencyP95, ioStorage)
+		add(ScoreTraceItem{Key: "io_latency", Value: loc.Sprintf("P95 %.2fms", stats.IOLatencyP95),
+			Bucket: ioLatencyBands(ioStorage).describe(stats.IOLatencyP95), SubScore: ioScore, Boost: 1, Score: ioScore,
+			Note: loc.Sprintf("按 %s 阈值", strings.ToUpper(string(ioStorage)))})
+		stats.RiskDetails["io_latency"] = a.describeIOLatencyRisk(stats.IOLatencyP95, ioStorage)
+
+		// 5. I/O 随机延迟评分 (10%)
+		randomIOScore := a.scoreRandomIO(stats.RandomIOP95, ioStorage)
+		add(ScoreTraceItem{Key: "random_io", Value: loc.Sprintf("P95 %.2fms", stats.RandomIOP95),
+			Bucket: randomIOBands(ioStorage).describe(stats.RandomIOP95), SubScore: randomIOScore, Boost: 1, Score: randomIOScore,
+			Note: loc.Sprintf("按 %s 阈值", strings.ToUpper(string(ioStorage)))})
+		stats.RiskDetails["random_io"] = a.describeRandomIORisk(stats.RandomIOWriteAvg, stats.RandomIOReadAvg, ioStorage)
+	}
 
 	// 6. 磁盘繁忙度评分 (5%)
 	diskBusyScore := a.scoreDiskBusy(stats.DiskBusyPercent)
-	totalScore += diskBusyScore * WeightDiskBusy
+	add(ScoreTraceItem{Key: "disk_busy", Value: loc.Sprintf("均值 %.1f%%", stats.DiskBusyPercent),
+		Bucket: diskBusyBands.describe(stats.DiskBusyPercent), SubScore: diskBusyScore, Boost: 1, Score: diskBusyScore})
 	stats.RiskDetails["disk_busy"] = a.describeDiskBusyRisk(stats.DiskBusyPercent)
 
-	// 7. 内存评分 (10%)
-	memoryScore := a.scoreMemory(stats.MemoryAvailablePercent)
-	totalScore += memoryScore * WeightMemory
-	stats.RiskDetails["memory"] = a.describeMemoryRisk(stats.MemoryAvailablePercent)
+	// 磁盘综合分：已参与评分的磁盘项按其在总分中的权重归一化（顺序 50% / 随机 33% / 繁忙度 17%）
+	var diskTotal, diskWeight float64
+	for _, key := range []string{"io_latency", "random_io", "disk_busy"} {
+		if contribution, ok := stats.ScoreBreakdown[key]; ok {
+			diskTotal += contribution
+			diskWeight += weights[key]
+		}
+	}
+	stats.DiskHealthScore = diskTotal / diskWeight
+
+	// 7. 内存评分 (10%)：可用率为主，有缺页延迟数据时按 7:3 计入其稳定性
+	memoryScore := a.scoreMemory(stats.MemoryAvailablePercent)
+	memoryItem := ScoreTraceItem{Key: "memory", Value: loc.Sprintf("可用 %.1f%%", stats.MemoryAvailablePercent),
+		Bucket: memoryBands.describe(stats.MemoryAvailablePercent), SubScore: memoryScore, Boost: 1}
+	if stats.MemFaultAvg > 0 {
+		faultScore := a.scoreMemFaultStability(stats.MemFaultCV)
+		memoryScore = memoryScore*0.7 + faultScore*0.3
+		memoryItem.Note = loc.Sprintf("档位分 ×0.7 + 缺页延迟 CV %.3f（%s）档位分 %.0f ×0.3", stats.MemFaultCV, memFaultBands.describe(stats.MemFaultCV), faultScore)
+		stats.RiskDetails["mem_fault"] = a.describeMemFaultRisk(stats.MemFaultCV)
+	}
+	memoryItem.Score = memoryScore
+	add(memoryItem)
+	stats.RiskDetails["memory"] = a.describeMemoryRisk(stats.MemoryAvailablePercent)
+
+	// NUMA 远程访问 - 反映宿主机的 vCPU/内存放置，与超售无直接关系，仅展示不参与评分
+	if stats.NUMANodes >= 2 {
+		stats.RiskDetails["numa"] = a.describeNUMARisk(stats.NUMARemotePercent)
+	}
+
+	// 8. CPU Load - 仅作为参考显示，不参与评分
+	stats.RiskDetails["cpu_load"] = a.describeCPULoadReference(stats.CPULoadAvg, stats.CPULoadMax)
+
+	// 9. 基线偏离评分 (5%)
+	baselineScore := a.scoreBaselineDeviation(stats.BaselineDeviation)
+	add(ScoreTraceItem{Key: "baseline", Value: loc.Sprintf("偏离 %.1f%%", stats.BaselineDeviation),
+		Bucket: baselineBands.describe(stats.BaselineDeviation), SubScore: baselineScore, Boost: 1, Score: baselineScore})
+	stats.RiskDetails["baseline"] = a.describeBaselineStatus(stats.BaselineDeviation, stats.BaselineStatus, stats.BaselineConfidence)
+
+	stats.TotalScore = totalScore
+
+	// 确定风险等级
+	stats.RiskLevel = riskLevelForScore(totalScore)
+	if margin := a.config.Alert.LevelHysteresis; margin > 0 {
+		value, _, ok, err := a.store.GetState("risk_level:" + stats.Period)
+		if previous := RiskLevel(value); err == nil && ok && previous != stats.RiskLevel && previous.holds(totalScore, margin) {
+			stats.RiskLevel = previous
+			stats.RiskLevelHeld = true
+		}
+	}
+}
+
+// riskLevelScores 各风险等级的评分区间 [min, max)
+var riskLevelScores = map[RiskLevel][2]float64{
+	RiskLevelExcellent: {90, math.Inf(1)},
+	RiskLevelGood:      {70, 90},
+	RiskLevelMedium:    {50, 70},
+	RiskLevelSevere:    {math.Inf(-1), 50},
+}
+
+// riskLevelForScore 按评分划分风险等级
+func riskLevelForScore(score float64) RiskLevel {
+	switch {
+	case score >= 90:
+		return RiskLevelExcellent
+	case score >= 70:
+		return RiskLevelGood
+	case score >= 50:
+		return RiskLevelMedium
+	default:
+		return RiskLevelSevere
+	}
+}
+
+// holds 评分是否仍在该等级区间向外扩展 margin 分的范围内（滞回区间）
+func (l RiskLevel) holds(score, margin float64) bool {
+	r, ok := riskLevelScores[l]
+	return ok && score >= r[0]-margin && score < r[1]+margin
+}
+
+// describeConfidenceBoost 说明超售可信度加成的来源，与 calculateOversellConfidenceBoost 的判断顺序一致
+func (a *Analyzer) describeConfidenceBoost(stats *PeriodStats, boost float64) string {
+	loc := a.locale()
+	switch {
+	case stats.BurstCreditSuspected:
+		return loc.T("疑似突发额度耗尽，不加成")
+	case stats.CPULoadAvg >= 0.7:
+		return loc.Sprintf("本地负载 %.2f 偏高，不加成", stats.CPULoadAvg)
+	case boost > 1.0:
+		return loc.Sprintf("本地负载 %.2f 偏低且 Steal/IOWait 超过加成阈值，加成 ×%.2f", stats.CPULoadAvg, boost)
+	default:
+		return loc.T("Steal/IOWait 未超过加成阈值，不加成")
+	}
+}
+
+// stealThresholds 返回 Steal 的三级阈值（低/中/高）
+// 独享核心理应长期为零，任何持续 Steal 都意味着宿主机超卖，阈值收紧
+func stealThresholds(tenancy CPUTenancy) (low, medium, high float64) {
+	if tenancy == CPUTenancyDedicated {
+		return 0.5, 2, 5
+	}
+	return 3, 8, 15
+}
+
+// TopRiskFactor 返回扣分最多的评分项（键与 RiskDetails 一致），满分时返回空字符串
+func TopRiskFactor(stats *PeriodStats) string {
+	weights := weightsFor(stats)
+	keys := make([]string, 0, len(weights))
+	for key := range weights {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys) // 扣分相同时结果稳定
+
+	var top string
+	var topLoss float64
+	for _, key := range keys {
+		contribution, ok := stats.ScoreBreakdown[key]
+		if !ok {
+			continue
+		}
+		if loss := weights[key]*100 - contribution; loss > topLoss {
+			top, topLoss = key, loss
+		}
+	}
+	return top
+}
+
+const (
+	// diskDeltaMaxGap 相邻 disk_stats / network 样本间隔超过该值时不计算差分（停机、业务时段外）
+	diskDeltaMaxGap = 2 * time.Hour // 聚合层每小时只保留一个样本，间隔可略超 1 小时
+	// 平均队列深度超过 congestedQueueDepth 且 IOPS 低于 congestedMaxIOPS 时判定后端拥塞
+	congestedQueueDepth = 2.0
+	congestedMaxIOPS    = 100.0
+)
+
+// diskDeltas 相邻 disk_stats 样本差分得到的区间指标
+type diskDeltas struct {
+	busy       []float64 // 每个区间的 IO 时间占比（%）
+	iops       []float64 // 每个区间的 IOPS
+	queueDepth []float64 // 每个有 IO 的区间的平均队列深度
+	ops        float64   // 完成的请求总数
+	merges     float64   // 合并的请求总数
+}
+
+// calculateDiskDeltas 对累计计数器做差分，计数器回绕（重启）或间隔过大的区间跳过
+func calculateDiskDeltas(metrics []*storage.Metric) diskDeltas {
+	var d diskDeltas
+	counter := func(m *storage.Metric, key string) float64 {
+		v, _ := m.Extra[key].(float64)
+		return v
+	}
+
+	for i := 1; i < len(metrics); i++ {
+		prev, cur := metrics[i-1], metrics[i]
+		if prev.Extra == nil || cur.Extra == nil {
+			continue
+		}
+		gap := cur.Timestamp.Sub(prev.Timestamp)
+		if gap <= 0 || gap > diskDeltaMaxGap {
+			continue
+		}
+
+		ioTime := counter(cur, "io_time_ms") - counter(prev, "io_time_ms")
+		weighted := counter(cur, "weighted_io_ms") - counter(prev, "weighted_io_ms")
+		ops := counter(cur, "read_ops") + counter(cur, "write_ops") - counter(prev, "read_ops") - counter(prev, "write_ops")
+		if ioTime < 0 || weighted < 0 || ops < 0 {
+			continue // 计数器被重置
+		}
+
+		elapsedMs := float64(gap.Milliseconds())
+		if busy, ok := cur.Extra["busy_percent"].(float64); ok {
+			d.busy = append(d.busy, busy) // 采集端按设备计算并已限制在 [0, 100]
+		} else {
+			d.busy = append(d.busy, math.Min(ioTime/elapsedMs*100, 100)) // 旧数据：多块盘累加可能超过 100%
+		}
+		d.iops = append(d.iops, ops/gap.Seconds())
+		if ioTime > 0 {
+			d.queueDepth = append(d.queueDepth, weighted/ioTime)
+		}
+
+		merges := counter(cur, "read_merges") + counter(cur, "write_merges") - counter(prev, "read_merges") - counter(prev, "write_merges")
+		if merges >= 0 {
+			d.ops += ops
+			d.merges += merges
+		}
+	}
+	return d
+}
+
+// networkDeltas 相邻 network 样本差分得到的区间速率（Mbps）
+type networkDeltas struct {
+	iface string    // 最近一个样本的网卡
+	rx    []float64 // 每个区间的接收速率
+	tx    []float64 // 每个区间的发送速率
+	total []float64 // 每个区间的收发合计
+}
+
+// calculateNetworkDeltas 对网卡累计字节数做差分
+// 网卡切换（auto 模式下默认路由变化）、计数器重置或间隔过大的区间跳过
+func calculateNetworkDeltas(metrics []*storage.Metric) networkDeltas {
+	var n networkDeltas
+	counter := func(m *storage.Metric, key string) float64 {
+		v, _ := m.Extra[key].(float64)
+		return v
+	}
+
+	for i := 1; i < len(metrics); i++ {
+		prev, cur := metrics[i-1], metrics[i]
+		if prev.Extra == nil || cur.Extra == nil {
+			continue
+		}
+		n.iface, _ = cur.Extra["interface"].(string)
+		if prevIface, _ := prev.Extra["interface"].(string); prevIface != n.iface {
+			continue
+		}
+		gap := cur.Timestamp.Sub(prev.Timestamp)
+		if gap <= 0 || gap > diskDeltaMaxGap {
+			continue
+		}
+
+		rx := counter(cur, "rx_bytes") - counter(prev, "rx_bytes")
+		tx := counter(cur, "tx_bytes") - counter(prev, "tx_bytes")
+		if rx < 0 || tx < 0 {
+			continue // 计数器被重置（网卡重建、重启）
+		}
+
+		seconds := gap.Seconds()
+		rxMbps := rx * 8 / seconds / 1e6
+		txMbps := tx * 8 / seconds / 1e6
+		n.rx = append(n.rx, rxMbps)
+		n.tx = append(n.tx, txMbps)
+		n.total = append(n.total, rxMbps+txMbps)
+	}
+	return n
+}
+
+// schedContentionPercent 本地负载偏低时调度等待达到该比例视为宿主机 CPU 争抢
+// 负载偏低时运行队列本应几乎为空，等待主要来自 vCPU 被宿主机挂起
+const schedContentionPercent = 5.0
+
+// schedDeltas 相邻 schedstat 样本差分得到的调度等待
+type schedDeltas struct {
+	waitPercent     []float64 // 每个区间的等待时间占每 vCPU 时间的百分比
+	delayNs, slices float64   // 周期内累计的等待时间与时间片数
+}
+
+// calculateSchedDeltas 对 schedstat 累计值做差分
+// CPU 数变化（热插拔）、计数器重置或间隔过大的区间跳过
+func calculateSchedDeltas(metrics []*storage.Metric) schedDeltas {
+	var d schedDeltas
+	counter := func(m *storage.Metric, key string) float64 {
+		v, _ := m.Extra[key].(float64)
+		return v
+	}
+
+	for i := 1; i < len(metrics); i++ {
+		prev, cur := metrics[i-1], metrics[i]
+		if prev.Extra == nil || cur.Extra == nil {
+			continue
+		}
+		cpus := counter(cur, "cpus")
+		if cpus <= 0 || counter(prev, "cpus") != cpus {
+			continue
+		}
+		gap := cur.Timestamp.Sub(prev.Timestamp)
+		if gap <= 0 || gap > diskDeltaMaxGap {
+			continue
+		}
+
+		delay := counter(cur, "run_delay_ns") - counter(prev, "run_delay_ns")
+		slices := counter(cur, "timeslices") - counter(prev, "timeslices")
+		if delay < 0 || slices < 0 {
+			continue // 计数器被重置（重启）
+		}
+		d.waitPercent = append(d.waitPercent, delay/(float64(gap.Nanoseconds())*cpus)*100)
+		d.delayNs += delay
+		d.slices += slices
+	}
+	return d
+}
+
+// numaDeltas 相邻 numa 样本差分累加得到的周期内页分配计数
+type numaDeltas struct {
+	nodes                   int // 最近一个样本的节点数
+	hit, miss, local, other float64
+}
+
+// calculateNUMADeltas 对 numastat 累计计数做差分并累加
+// 节点数变化、计数器重置或间隔过大的区间跳过
+func calculateNUMADeltas(metrics []*storage.Metric) numaDeltas {
+	var d numaDeltas
+	counter := func(m *storage.Metric, key string) float64 {
+		v, _ := m.Extra[key].(float64)
+		return v
+	}
+
+	for i := 1; i < len(metrics); i++ {
+		prev, cur := metrics[i-1], metrics[i]
+		if prev.Extra == nil || cur.Extra == nil {
+			continue
+		}
+		d.nodes = int(counter(cur, "nodes"))
+		if int(counter(prev, "nodes")) != d.nodes {
+			continue
+		}
+		gap := cur.Timestamp.Sub(prev.Timestamp)
+		if gap <= 0 || gap > diskDeltaMaxGap {
+			continue
+		}
+
+		hit := counter(cur, "numa_hit") - counter(prev, "numa_hit")
+		miss := counter(cur, "numa_miss") - counter(prev, "numa_miss")
+		local := counter(cur, "local_node") - counter(prev, "local_node")
+		other := counter(cur, "other_node") - counter(prev, "other_node")
+		if hit < 0 || miss < 0 || local < 0 || other < 0 {
+			continue // 计数器被重置（重启）
+		}
+		d.hit += hit
+		d.miss += miss
+		d.local += local
+		d.other += other
+	}
+	return d
+}
+
+// maxOnlineCPUChanges 报告中保留的 vCPU 数量变化序列最大长度（保留最近的变化）
+const maxOnlineCPUChanges = 8
+
+// onlineCPUChanges 压缩在线 vCPU 数量序列中的连续重复值，未发生变化时返回 nil
+func onlineCPUChanges(counts []float64) []int {
+	var changes []int
+	for _, c := range counts {
+		n := int(c)
+		if len(changes) == 0 || changes[len(changes)-1] != n {
+			changes = append(changes, n)
+		}
+	}
+	if len(changes) < 2 {
+		return nil
+	}
+	if len(changes) > maxOnlineCPUChanges {
+		changes = changes[len(changes)-maxOnlineCPUChanges:]
+	}
+	return changes
+}
+
+// RecordRiskLevel 持久化本次周期的风险等级并返回上一次的等级（按报告类型分别记录）
+// 同时维护连续处于 escalate_level 及以上的周期数，写入 stats.RiskStreak / stats.Escalated
+// 首次记录时 ok 为 false
+func (a *Analyzer) RecordRiskLevel(stats *PeriodStats) (previous RiskLevel, ok bool, err error) {
+	key := "risk_level:" + stats.Period
+	value, _, ok, err := a.store.GetState(key)
+	if err != nil {
+		return "", false, err
+	}
+	if err := a.store.SetState(key, string(stats.RiskLevel)); err != nil {
+		return "", false, err
+	}
+	if err := a.store.SetState("score:"+stats.Period, strconv.FormatFloat(stats.TotalScore, 'f', 1, 64)); err != nil {
+		return "", false, err
+	}
+
+	if err := a.recordRiskStreak(stats); err != nil {
+		log.Printf("记录连续风险周期失败: %v", err)
+	}
+	return RiskLevel(value), ok, nil
+}
 
-	// 8. CPU Load - 仅作为参考显示，不参与评分
-	stats.RiskDetails["cpu_load"] = a.describeCPULoadReference(stats.CPULoadAvg, stats.CPULoadMax)
+// LastReport 返回最近一次定时报告记录的评分、风险等级与时间（-motd 等轻量查询使用，不重新分析）
+// 尚未生成过该类型报告时 ok 为 false
+func (a *Analyzer) LastReport(period string) (score float64, level RiskLevel, at time.Time, ok bool, err error) {
+	value, at, ok, err := a.store.GetState("score:" + period)
+	if err != nil || !ok {
+		return 0, "", time.Time{}, false, err
+	}
+	score, _ = strconv.ParseFloat(value, 64)
+	levelValue, _, _, err := a.store.GetState("risk_level:" + period)
+	if err != nil {
+		return 0, "", time.Time{}, false, err
+	}
+	return score, RiskLevel(levelValue), at, true, nil
+}
 
-	// 9. 基线偏离评分 (5%)
-	baselineScore := a.scoreBaselineDeviation(stats.BaselineDeviation)
-	totalScore += baselineScore * WeightBaseline
-	stats.RiskDetails["baseline"] = a.describeBaselineStatus(stats.BaselineDeviation, stats.BaselineStatus)
+// recordRiskStreak 累计连续处于升级阈值及以上的报告周期数，低于阈值时清零
+func (a *Analyzer) recordRiskStreak(stats *PeriodStats) error {
+	key := "risk_streak:" + stats.Period
+	streak := 0
+	if stats.RiskLevel.Severity() >= RiskLevel(a.config.Alert.EscalateLevel).Severity() {
+		value, _, _, err := a.store.GetState(key)
+		if err != nil {
+			return err
+		}
+		streak, _ = strconv.Atoi(value)
+		streak++
+	}
+	if err := a.store.SetState(key, strconv.Itoa(streak)); err != nil {
+		return err
+	}
 
-	stats.TotalScore = totalScore
+	stats.RiskStreak = streak
+	stats.Escalated = a.config.Alert.EscalateAfter > 0 && streak >= a.config.Alert.EscalateAfter
+	return nil
+}
 
-	// 确定风险等级
-	switch {
-	case totalScore >= 90:
-		stats.RiskLevel = RiskLevelExcellent
-	case totalScore >= 70:
-		stats.RiskLevel = RiskLevelGood
-	case totalScore >= 50:
-		stats.RiskLevel = RiskLevelMedium
-	default:
-		stats.RiskLevel = RiskLevelSevere
+// bandScore 按评分项配置选择档位评分或线性插值评分
+func (a *Analyzer) bandScore(item string, bands scoreBands, v float64) float64 {
+	if a.config.Analysis.SmoothScoringEnabled(item) {
+		return bands.interpolate(v)
 	}
+	return bands.score(v)
 }
 
 // scoreCPUSteal CPU Steal 评分
-func (a *Analyzer) scoreCPUSteal(avgSteal float64) float64 {
-	switch {
-	case avgSteal < 3:
-		return 100
-	case avgSteal < 8:
-		return 70
-	case avgSteal < 15:
-		return 40
-	default:
-		return 0
-	}
+func (a *Analyzer) scoreCPUSteal(avgSteal float64, tenancy CPUTenancy) float64 {
+	return a.bandScore("cpu_steal", stealBands(tenancy), avgSteal)
+}
+
+// locale 风险描述所用的报告语言（report.locale）
+func (a *Analyzer) locale() *locale.Locale {
+	return locale.New(a.config.Report.Locale, a.config.Report.DecimalSeparator)
 }
 
 // describeCPUStealRisk 描述 CPU Steal 风险
-func (a *Analyzer) describeCPUStealRisk(avg, max float64) string {
+func (a *Analyzer) describeCPUStealRisk(avg, max float64, tenancy CPUTenancy) string {
+	loc := a.locale()
+	low, medium, _ := stealThresholds(tenancy)
 	switch {
-	case avg < 3:
-		return "✅ 低"
-	case avg < 8:
-		return "⚠️ 中等"
+	case avg < low:
+		return loc.T("✅ 低")
+	case avg < medium:
+		return loc.T("⚠️ 中等")
 	default:
-		return "🔴 严重"
+		return loc.T("🔴 严重")
+	}
+}
+
+// CPU 独享判定参数
+const (
+	cpuTenancyStateKey       = "cpu_tenancy"
+	cpuTenancyReasonStateKey = "cpu_tenancy_reason"
+	cpuTenancyWindow         = 7 * 24 * time.Hour // 回看窗口
+	cpuTenancyMinSpan        = 24 * time.Hour     // 至少需要覆盖的时长
+)
+
+// classifyCPUTenancy 推断实例为独享核心还是共享核心
+// 依据：只有识别为物理机（有 flags 行且无 hypervisor 标志、DMI 与容器特征均未命中）才视为独享；
+// 否则看首批至少 24 小时的 Steal 分布，独享核心的 Steal 应持续接近零且几乎无波动。
+// 通用 vCPU 型号（未透传宿主机 CPU）多见于共享套餐，判定独享时要求更严格。
+// 容器内看到的是宿主机 CPU 且 Steal 不可信，无法判定；没有正面依据时返回未知。
+// 首次得出的判定持久化后不再重新推导：判定依赖的 Steal 正是被评分的指标，
+// 否则独享实例出现 Steal 后会被改判为共享、反而按宽松阈值评分
+func (a *Analyzer) classifyCPUTenancy(end time.Time, virt collector.Virtualization) (CPUTenancy, string) {
+	switch a.config.Analysis.CPUTenancy {
+	case config.CPUTenancyDedicated:
+		return CPUTenancyDedicated, "配置指定"
+	case config.CPUTenancyShared:
+		return CPUTenancyShared, "配置指定"
+	}
+	if virt.Container() {
+		return CPUTenancyUnknown, fmt.Sprintf("%s 容器共享宿主机内核，无法判定", virt.DisplayName())
+	}
+
+	if virt == collector.VirtNone {
+		return CPUTenancyDedicated, "未检测到 hypervisor 标志与虚拟化特征，疑似物理机"
+	}
+
+	if value, updatedAt, ok, err := a.store.GetState(cpuTenancyStateKey); err == nil && ok {
+		reason, _, _, _ := a.store.GetState(cpuTenancyReasonStateKey)
+		if reason == "" {
+			return CPUTenancy(value), fmt.Sprintf("沿用 %s 的判定", updatedAt.Format("2006-01-02"))
+		}
+		return CPUTenancy(value), fmt.Sprintf("%s 首次判定：%s", updatedAt.Format("2006-01-02"), reason)
+	}
+
+	info, _ := collector.ReadCPUInfo(collector.DefaultProcPath)
+	steal := a.querySeries(storage.MetricTypeCPUSteal, end.Add(-cpuTenancyWindow), end)
+	values, times, err := steal.values, steal.times, steal.err
+	if err == nil && len(values) > 1 && times[len(times)-1].Sub(times[0]) >= cpuTenancyMinSpan {
+		p99 := percentile(values, 99)
+		sd := stdDev(values)
+
+		limit := 0.5
+		generic := info != nil && info.GenericModel()
+		if generic {
+			limit = 0.2
+		}
+
+		tenancy := CPUTenancyShared
+		if p99 < limit && sd < limit/2 {
+			tenancy = CPUTenancyDedicated
+		}
+
+		reason := fmt.Sprintf("近 %.0f 天 Steal P99 %.2f%%、标准差 %.2f", times[len(times)-1].Sub(times[0]).Hours()/24, p99, sd)
+		if generic {
+			reason += "，通用 vCPU 型号"
+		}
+
+		if err := a.store.SetState(cpuTenancyReasonStateKey, reason); err != nil {
+			log.Printf("保存 CPU 独享判定依据失败: %v", err)
+		}
+		if err := a.store.SetState(cpuTenancyStateKey, string(tenancy)); err != nil {
+			log.Printf("保存 CPU 独享判定失败: %v", err)
+		}
+		return tenancy, reason
 	}
+
+	return CPUTenancyUnknown, "数据不足（需至少 24 小时 Steal 采样）"
 }
 
 // scoreCPUIoWait CPU IOWait 评分
 func (a *Analyzer) scoreCPUIoWait(avgIoWait float64) float64 {
-	switch {
-	case avgIoWait < 5:
-		return 100
-	case avgIoWait < 15:
-		return 70
-	case avgIoWait < 30:
-		return 40
-	default:
-		return 0
-	}
+	return a.bandScore("cpu_iowait", ioWaitBands, avgIoWait)
 }
 
 // describeCPUIoWaitRisk 描述 CPU IOWait 风险
 func (a *Analyzer) describeCPUIoWaitRisk(avg float64) string {
+	loc := a.locale()
 	switch {
 	case avg < 5:
-		return "✅ 低"
+		return loc.T("✅ 低")
 	case avg < 15:
-		return "⚠️ 中等"
+		return loc.T("⚠️ 中等")
 	default:
-		return "🔴 严重"
+		return loc.T("🔴 严重")
 	}
 }
 
 // scoreCPUStability CPU 稳定性评分
 func (a *Analyzer) scoreCPUStability(cv float64) float64 {
-	switch {
-	case cv < 0.05:
-		return 100
-	case cv < 0.15:
-		return 70
-	default:
-		return 30
-	}
+	return a.bandScore("cpu_stability", stabilityBands, cv)
 }
 
 // describeCPUStabilityRisk 描述 CPU 稳定性风险
 func (a *Analyzer) describeCPUStabilityRisk(cv float64) string {
+	loc := a.locale()
 	switch {
 	case cv < 0.05:
-		return "✅ 稳定"
+		return loc.T("✅ 稳定")
 	case cv < 0.15:
-		return "⚠️ 轻微波动"
+		return loc.T("⚠️ 轻微波动")
 	default:
-		return "🔴 波动严重"
+		return loc.T("🔴 波动严重")
 	}
 }
 
-// scoreIOLatency I/O 延迟评分
-func (a *Analyzer) scoreIOLatency(p95 float64, storageType collector.StorageType) float64 {
-	// SSD 和 HDD 使用不同阈值
-	if storageType == collector.StorageTypeHDD {
-		switch {
-		case p95 < 50:
-			return 100
-		case p95 < 100:
-			return 70
-		case p95 < 200:
-			return 40
-		default:
-			return 0
-		}
+// LabelPairs 按标签名排序返回 "key=value" 列表，保证报告中的顺序稳定
+func (s *PeriodStats) LabelPairs() []string {
+	keys := make([]string, 0, len(s.Labels))
+	for k := range s.Labels {
+		keys = append(keys, k)
 	}
+	sort.Strings(keys)
 
-	// SSD 或未知类型
-	switch {
-	case p95 < 20:
-		return 100
-	case p95 < 50:
-		return 70
-	case p95 < 100:
-		return 40
-	default:
-		return 0
+	pairs := make([]string, len(keys))
+	for i, k := range keys {
+		pairs[i] = k + "=" + s.Labels[k]
+	}
+	return pairs
+}
+
+// ioExpectation 返回 I/O 评分所用的存储类型阈值
+// 写时复制文件系统的 fsync 伴随写放大、网络文件系统包含网络往返，延迟偏高并非超售，
+// 按 HDD 的宽松阈值评分，避免 ZFS/NFS 上的机器被误判。
+// 用户以 -force-storage 指定的类型优先，不再按文件系统放宽
+func ioExpectation(stats *PeriodStats) collector.StorageType {
+	if stats.StorageTypeForced {
+		return stats.StorageType
 	}
+	if stats.FilesystemKind == collector.FilesystemCOW || stats.FilesystemKind == collector.FilesystemNetwork {
+		return collector.StorageTypeHDD
+	}
+	return stats.StorageType
+}
+
+// scoreIOLatency I/O 延迟评分
+func (a *Analyzer) scoreIOLatency(p95 float64, storageType collector.StorageType) float64 {
+	return a.bandScore("io_latency", ioLatencyBands(storageType), p95)
 }
 
 // describeIOLatencyRisk 描述 I/O 延迟风险
 func (a *Analyzer) describeIOLatencyRisk(p95 float64, storageType collector.StorageType) string {
+	loc := a.locale()
 	threshold := 20.0
 	if storageType == collector.StorageTypeHDD {
 		threshold = 50.0
@@ -443,45 +1695,22 @@ func (a *Analyzer) describeIOLatencyRisk(p95 float64, storageType collector.Stor
 
 	switch {
 	case p95 < threshold:
-		return "✅ 低"
+		return loc.T("✅ 低")
 	case p95 < threshold*2.5:
-		return "⚠️ 中等"
+		return loc.T("⚠️ 中等")
 	default:
-		return "🔴 严重"
+		return loc.T("🔴 严重")
 	}
 }
 
 // scoreRandomIO 随机 IO 延迟评分
 func (a *Analyzer) scoreRandomIO(p95 float64, storageType collector.StorageType) float64 {
-	// 随机 IO 通常比顺序 IO 慢，阈值放宽
-	if storageType == collector.StorageTypeHDD {
-		switch {
-		case p95 < 100:
-			return 100
-		case p95 < 200:
-			return 70
-		case p95 < 500:
-			return 40
-		default:
-			return 0
-		}
-	}
-
-	// SSD 或未知类型
-	switch {
-	case p95 < 30:
-		return 100
-	case p95 < 80:
-		return 70
-	case p95 < 150:
-		return 40
-	default:
-		return 0
-	}
+	return a.bandScore("random_io", randomIOBands(storageType), p95)
 }
 
 // describeRandomIORisk 描述随机 IO 风险
 func (a *Analyzer) describeRandomIORisk(writeAvg, readAvg float64, storageType collector.StorageType) string {
+	loc := a.locale()
 	// 使用写延迟作为主要指标
 	threshold := 30.0
 	if storageType == collector.StorageTypeHDD {
@@ -490,75 +1719,104 @@ func (a *Analyzer) describeRandomIORisk(writeAvg, readAvg float64, storageType c
 
 	switch {
 	case writeAvg < threshold:
-		return fmt.Sprintf("✅ 低 (写:%.1fms 读:%.1fms)", writeAvg, readAvg)
+		return loc.Sprintf("✅ 低 (写:%.1fms 读:%.1fms)", writeAvg, readAvg)
 	case writeAvg < threshold*2.5:
-		return fmt.Sprintf("⚠️ 中等 (写:%.1fms 读:%.1fms)", writeAvg, readAvg)
+		return loc.Sprintf("⚠️ 中等 (写:%.1fms 读:%.1fms)", writeAvg, readAvg)
 	default:
-		return fmt.Sprintf("🔴 严重 (写:%.1fms 读:%.1fms)", writeAvg, readAvg)
+		return loc.Sprintf("🔴 严重 (写:%.1fms 读:%.1fms)", writeAvg, readAvg)
 	}
 }
 
 // scoreDiskBusy 磁盘繁忙度评分
 func (a *Analyzer) scoreDiskBusy(busyPercent float64) float64 {
-	switch {
-	case busyPercent < 30:
-		return 100
-	case busyPercent < 60:
-		return 70
-	case busyPercent < 85:
-		return 40
-	default:
-		return 0
-	}
+	return a.bandScore("disk_busy", diskBusyBands, busyPercent)
 }
 
 // describeDiskBusyRisk 描述磁盘繁忙度风险
 func (a *Analyzer) describeDiskBusyRisk(busyPercent float64) string {
+	loc := a.locale()
 	switch {
 	case busyPercent < 30:
-		return fmt.Sprintf("✅ 低 (%.1f%%)", busyPercent)
+		return loc.Sprintf("✅ 低 (%.1f%%)", busyPercent)
 	case busyPercent < 60:
-		return fmt.Sprintf("⚠️ 中等 (%.1f%%)", busyPercent)
+		return loc.Sprintf("⚠️ 中等 (%.1f%%)", busyPercent)
 	default:
-		return fmt.Sprintf("🔴 高 (%.1f%%)", busyPercent)
+		return loc.Sprintf("🔴 高 (%.1f%%)", busyPercent)
 	}
 }
 
 // scoreMemory 内存评分
 func (a *Analyzer) scoreMemory(availablePercent float64) float64 {
-	switch {
-	case availablePercent > 90:
-		return 100
-	case availablePercent > 80:
-		return 80
-	default:
-		return 50
-	}
+	return a.bandScore("memory", memoryBands, availablePercent)
 }
 
 // describeMemoryRisk 描述内存风险
 func (a *Analyzer) describeMemoryRisk(availablePercent float64) string {
+	loc := a.locale()
 	switch {
 	case availablePercent > 80:
-		return "✅ 正常"
+		return loc.T("✅ 正常")
 	case availablePercent > 50:
-		return "⚠️ 偏低"
+		return loc.T("⚠️ 偏低")
+	default:
+		return loc.T("🔴 不足")
+	}
+}
+
+// describeNUMARisk 描述 NUMA 远程访问比例
+// 远程内存访问延迟通常是本地的 1.5-2 倍，比例越高访存越慢
+func (a *Analyzer) describeNUMARisk(remotePercent float64) string {
+	loc := a.locale()
+	switch {
+	case remotePercent < 10:
+		return loc.T("✅ 低")
+	case remotePercent < 30:
+		return loc.T("⚠️ 中等")
+	default:
+		return loc.T("🔴 严重")
+	}
+}
+
+// memFaultUnstableCV 缺页延迟变异系数达到该值视为不稳定
+// 缺页涉及内核分配与清零，本身波动大于纯计算，阈值比 CPU 基准测试宽松
+const memFaultUnstableCV = 0.25
+
+// scoreMemFaultStability 内存缺页延迟稳定性评分
+func (a *Analyzer) scoreMemFaultStability(cv float64) float64 {
+	return a.bandScore("memory", memFaultBands, cv)
+}
+
+// describeMemFaultRisk 描述内存缺页延迟稳定性
+func (a *Analyzer) describeMemFaultRisk(cv float64) string {
+	loc := a.locale()
+	switch {
+	case cv < 0.10:
+		return loc.T("✅ 稳定")
+	case cv < memFaultUnstableCV:
+		return loc.T("⚠️ 轻微波动")
 	default:
-		return "🔴 不足"
+		return loc.T("🔴 波动严重")
 	}
 }
 
 // calculateOversellConfidenceBoost 计算超售可信度加成
-// 当本地负载低但 steal/iowait 高时，增加超售检测的可信度
+// 当本地负载低但 steal/iowait 高时，增加超售检测的可信度；
+// Steal 先扣除平台固有底噪（analysis.steal_baseline），避免恒定的低水平 Steal 被当作超售证据
 func (a *Analyzer) calculateOversellConfidenceBoost(stats *PeriodStats) float64 {
+	// Steal 源于自身耗尽突发额度，不是超售，不加成
+	if stats.BurstCreditSuspected {
+		return 1.0
+	}
+
 	// 只有当本地负载较低时才应用加成
 	if stats.CPULoadAvg >= 0.7 {
 		return 1.0 // 本地负载高，不加成
 	}
 
 	// 本地负载低，检查是否有超售迹象
-	hasStealIssue := stats.CPUStealAvg > 3.0
-	hasIoWaitIssue := stats.CPUIoWaitAvg > 5.0
+	cfg := a.config.Analysis
+	hasStealIssue := stats.CPUStealAvg-cfg.StealBaseline > cfg.BoostStealThreshold
+	hasIoWaitIssue := stats.CPUIoWaitAvg > cfg.BoostIoWaitThreshold
 
 	if hasStealIssue || hasIoWaitIssue {
 		// 负载越低，可信度加成越高（最高 1.2）
@@ -572,73 +1830,209 @@ func (a *Analyzer) calculateOversellConfidenceBoost(stats *PeriodStats) float64
 	return 1.0
 }
 
+// 突发额度耗尽判定参数
+const (
+	burstBusyLoad       = 0.7 // 归一化 load 高于此值视为自身高负载
+	burstIdleLoad       = 0.3 // 归一化 load 低于此值视为空闲
+	burstMinBusySteal   = 5.0 // 高负载时 Steal 至少达到该值才考虑
+	burstMaxIdleSteal   = 1.0 // 空闲时 Steal 应接近零
+	burstMinCorrelation = 0.6
+	burstMinPairs       = 30
+	burstMinGroup       = 5
+)
+
+// detectBurstCredit 识别突发额度耗尽
+// 积分型实例在自身持续高负载耗尽额度后被限速，表现为 Steal 与自身负载同步升高、空闲时几乎为零；
+// 超售则相反，Steal 与自身负载无关（空闲时同样出现）。两者的处理方式完全不同（升级规格 vs 更换服务商）。
+func (a *Analyzer) detectBurstCredit(stats *PeriodStats, steal, load series) {
+	stealValues, loadValues := alignSeries(steal, load, 30*time.Second)
+	if len(stealValues) < burstMinPairs {
+		return
+	}
+
+	var busy, idle []float64
+	for i, l := range loadValues {
+		switch {
+		case l >= burstBusyLoad:
+			busy = append(busy, stealValues[i])
+		case l < burstIdleLoad:
+			idle = append(idle, stealValues[i])
+		}
+	}
+	if len(busy) < burstMinGroup || len(idle) < burstMinGroup {
+		return
+	}
+
+	stats.BurstBusySteal = avg(busy)
+	stats.BurstIdleSteal = avg(idle)
+	stats.StealLoadCorrelation = correlation(stealValues, loadValues)
+	stats.BurstCreditSuspected = stats.BurstBusySteal >= burstMinBusySteal &&
+		stats.BurstIdleSteal < burstMaxIdleSteal &&
+		stats.StealLoadCorrelation >= burstMinCorrelation
+}
+
+// alignSeries 按时间对齐两个序列，返回时间差不超过 tolerance 的样本对
+func alignSeries(a, b series, tolerance time.Duration) ([]float64, []float64) {
+	var av, bv []float64
+	j := 0
+	for i, t := range a.times {
+		for j < b.len() && b.times[j].Before(t.Add(-tolerance)) {
+			j++
+		}
+		if j < b.len() && b.times[j].Sub(t) <= tolerance {
+			av = append(av, a.values[i])
+			bv = append(bv, b.values[j])
+		}
+	}
+	return av, bv
+}
+
+// correlation 皮尔逊相关系数，任一序列无波动时返回 0
+func correlation(x, y []float64) float64 {
+	if len(x) != len(y) || len(x) < 2 {
+		return 0
+	}
+	mx, my := avg(x), avg(y)
+	var sxy, sxx, syy float64
+	for i := range x {
+		dx, dy := x[i]-mx, y[i]-my
+		sxy += dx * dy
+		sxx += dx * dx
+		syy += dy * dy
+	}
+	if sxx == 0 || syy == 0 {
+		return 0
+	}
+	return sxy / math.Sqrt(sxx*syy)
+}
+
 // describeCPULoadReference 描述 CPU Load 参考值（不参与评分）
 func (a *Analyzer) describeCPULoadReference(avg, max float64) string {
+	loc := a.locale()
 	var status string
 	switch {
 	case avg < 0.7:
-		status = "空闲"
+		status = loc.T("空闲")
 	case avg < 1.0:
-		status = "正常"
+		status = loc.T("正常")
 	case avg < 2.0:
-		status = "较高"
+		status = loc.T("较高")
 	default:
-		status = "过载"
+		status = loc.T("过载")
 	}
-	return fmt.Sprintf("📊 %.2f (%s) [参考值]", avg, status)
+	return loc.Sprintf("📊 %.2f (%s) [参考值]", avg, status)
 }
 
 // scoreBaselineDeviation 基线偏离评分
 // deviation: 0-100，0 表示无偏离
 func (a *Analyzer) scoreBaselineDeviation(deviation float64) float64 {
-	switch {
-	case deviation < 10:
-		return 100
-	case deviation < 25:
-		return 70
-	case deviation < 50:
-		return 40
-	default:
-		return 20
-	}
+	return a.bandScore("baseline", baselineBands, deviation)
 }
 
-// describeBaselineStatus 描述基线状态
-func (a *Analyzer) describeBaselineStatus(deviation float64, status string) string {
-	if status == "" {
-		status = "stable"
-	}
+// describeBaselineStatus 描述基线状态，历史数据不足时明确说明未做对比，避免被误读为"确认稳定"
+func (a *Analyzer) describeBaselineStatus(deviation float64, status string, confidence BaselineConfidence) string {
+	loc := a.locale()
+	var desc string
 	switch status {
-	case "stable":
-		return "✅ 稳定"
 	case "improving":
-		return "📈 改善中"
+		desc = loc.T("📈 改善中")
 	case "degrading":
 		if deviation > 25 {
-			return "🔴 明显下降"
+			desc = loc.T("🔴 明显下降")
+		} else {
+			desc = loc.T("⚠️ 轻微下降")
 		}
-		return "⚠️ 轻微下降"
 	default:
-		return "✅ 稳定"
+		desc = loc.T("✅ 稳定")
+	}
+
+	switch confidence {
+	case BaselineConfidenceNone:
+		return loc.T("ℹ️ 基线数据不足，暂无对比")
+	case BaselineConfidenceLow:
+		return desc + loc.T(" (历史较短，仅供参考)")
+	case BaselineConfidenceHigh:
+		return desc + loc.T(" (历史充足)")
+	}
+	return desc
+}
+
+// BaselineConfidence 基线对比的可信度，取决于基线窗口内有多少历史数据
+type BaselineConfidence string
+
+const (
+	BaselineConfidenceNone BaselineConfidence = "none" // 样本不足 baselineMinSamples，未做对比
+	BaselineConfidenceLow  BaselineConfidence = "low"  // 历史跨度不足 baselineHighSpan
+	BaselineConfidenceHigh BaselineConfidence = "high"
+)
+
+// 基线可信度阈值
+const (
+	baselineMinSamples = 10                 // Steal 与 I/O 延迟样本均少于该值时不做对比
+	baselineHighSpan   = 3 * 24 * time.Hour // 基线样本覆盖的时长达到该值视为历史充足
+)
+
+// baselineConfidence 按样本数与样本覆盖的时长判断基线可信度
+// 用时长而非行数衡量：分层保留后旧数据每小时只剩一行，行数与采集间隔、保留策略强相关
+func baselineConfidence(stealTimes, ioTimes []time.Time) BaselineConfidence {
+	if len(stealTimes) < baselineMinSamples && len(ioTimes) < baselineMinSamples {
+		return BaselineConfidenceNone
+	}
+	span := func(times []time.Time) time.Duration {
+		if len(times) < baselineMinSamples {
+			return 0
+		}
+		return times[len(times)-1].Sub(times[0])
 	}
+	if span(stealTimes) >= baselineHighSpan || span(ioTimes) >= baselineHighSpan {
+		return BaselineConfidenceHigh
+	}
+	return BaselineConfidenceLow
+}
+
+// BaselineAverages 基线窗口内各指标的均值（未做最小基准值修正）
+type BaselineAverages struct {
+	Start            time.Time `json:"start"`
+	End              time.Time `json:"end"`
+	CPUStealAvg      float64   `json:"cpu_steal_avg"`
+	CPUStealSamples  int       `json:"cpu_steal_samples"`
+	IOLatencyAvg     float64   `json:"io_latency_avg"`
+	IOLatencySamples int       `json:"io_latency_samples"`
+	CPULoadAvg       float64   `json:"cpu_load_avg"`
+	CPULoadSamples   int       `json:"cpu_load_samples"`
 }
 
 // calculateBaselineDeviation 计算与历史基线的偏离度
+// 同时将基线均值写入 stats.Baseline、可信度写入 stats.BaselineConfidence
 func (a *Analyzer) calculateBaselineDeviation(stats *PeriodStats) (float64, string) {
 	// 查询过去 14 天的历史数据作为基线（更长的窗口使基线更稳定）
 	baselineEnd := stats.StartTime
 	baselineStart := baselineEnd.AddDate(0, 0, -14)
 
-	// 获取基线期间的各项指标
-	baselineSteal, _ := a.store.Query(storage.MetricTypeCPUSteal, baselineStart, baselineEnd)
-	baselineIO, _ := a.store.Query(storage.MetricTypeIOLatency, baselineStart, baselineEnd)
-	baselineLoad, _ := a.store.Query(storage.MetricTypeCPULoad, baselineStart, baselineEnd)
+	// 获取基线期间的各项指标（经 querySeries 还原去重跳过的样本）
+	stealSeries := a.querySeries(storage.MetricTypeCPUSteal, baselineStart, baselineEnd)
+	ioSeries := a.querySeries(storage.MetricTypeIOLatency, baselineStart, baselineEnd)
+	baselineSteal, stealTimes := stealSeries.values, stealSeries.times
+	baselineIO, ioTimes := ioSeries.values, ioSeries.times
+	baselineLoad := a.querySeries(storage.MetricTypeCPULoad, baselineStart, baselineEnd).values
 
-	// 如果没有足够的历史数据，返回稳定状态
-	if len(baselineSteal) < 10 && len(baselineIO) < 10 {
+	// 如果没有足够的历史数据，返回稳定状态（评分不扣分），由可信度标明未做对比
+	stats.BaselineConfidence = baselineConfidence(stealTimes, ioTimes)
+	if stats.BaselineConfidence == BaselineConfidenceNone {
 		return 0, "stable"
 	}
 
+	stats.Baseline = &BaselineAverages{
+		Start:            baselineStart,
+		End:              baselineEnd,
+		CPUStealAvg:      avg(baselineSteal),
+		CPUStealSamples:  len(baselineSteal),
+		IOLatencyAvg:     avg(baselineIO),
+		IOLatencySamples: len(baselineIO),
+		CPULoadAvg:       avg(baselineLoad),
+		CPULoadSamples:   len(baselineLoad),
+	}
+
 	// 最小基准值阈值，避免极小值作为分母导致偏离度被过度放大
 	const (
 		minStealBaseline = 0.5 // CPU Steal 最小基准：0.5%
@@ -651,7 +2045,7 @@ func (a *Analyzer) calculateBaselineDeviation(stats *PeriodStats) (float64, stri
 
 	// 计算 CPU Steal 偏离
 	if len(baselineSteal) > 0 {
-		baselineStealAvg := avg(extractValues(baselineSteal))
+		baselineStealAvg := avg(baselineSteal)
 		// 使用最小基准值，避免分母过小导致放大
 		if baselineStealAvg < minStealBaseline {
 			baselineStealAvg = minStealBaseline
@@ -662,7 +2056,7 @@ func (a *Analyzer) calculateBaselineDeviation(stats *PeriodStats) (float64, stri
 
 	// 计算 I/O 延迟偏离
 	if len(baselineIO) > 0 {
-		baselineIOAvg := avg(extractValues(baselineIO))
+		baselineIOAvg := avg(baselineIO)
 		// 使用最小基准值，避免分母过小导致放大
 		if baselineIOAvg < minIOBaseline {
 			baselineIOAvg = minIOBaseline
@@ -673,7 +2067,7 @@ func (a *Analyzer) calculateBaselineDeviation(stats *PeriodStats) (float64, stri
 
 	// 计算 CPU Load 偏离
 	if len(baselineLoad) > 0 {
-		baselineLoadAvg := avg(extractValues(baselineLoad))
+		baselineLoadAvg := avg(baselineLoad)
 		// 使用最小基准值，避免分母过小导致放大
 		if baselineLoadAvg < minLoadBaseline {
 			baselineLoadAvg = minLoadBaseline
@@ -710,6 +2104,156 @@ func (a *Analyzer) calculateBaselineDeviation(stats *PeriodStats) (float64, stri
 
 // 辅助函数
 
+// series 单一指标的时间序列（仅主值，values 与 times 一一对应）
+type series struct {
+	values []float64
+	times  []time.Time
+
+	// 样本过多时为数据库聚合后的分桶序列，resolution 为桶宽；原始序列为 0
+	resolution time.Duration
+	exact      bool // 分布须基于 values 计算（已按业务时段过滤或跨越聚合层），不能用数据库直方图
+	metricType storage.MetricType
+	start, end time.Time
+	err        error // 查询失败时的错误（此时序列为空）
+}
+
+func (sr series) len() int {
+	return len(sr.values)
+}
+
+// 大样本量阈值：超过后改走数据库聚合路径，避免月报一次性加载数百万行
+const (
+	maxExactSamples    = 200000
+	aggregateBucket    = time.Minute
+	histogramBucketMin = 0.01 // 直方图桶宽，百分比/毫秒类指标的分位数误差不超过该值
+)
+
+// querySeries 查询指标主值序列
+// 先用 QueryCount 检查规模：常规情况下走 QueryValuesOnly 快速路径返回原始样本；
+// 样本量超过 maxExactSamples（如采集间隔被误配为秒级）时返回按分钟聚合的序列
+func (a *Analyzer) querySeries(metricType storage.MetricType, start, end time.Time) series {
+	sr := series{metricType: metricType, start: start, end: end}
+
+	// 周期跨越小时聚合层时统一按小时分桶：原始层与聚合层每小时各计一个值，权重一致；
+	// 分位数基于小时均值计算（会低估短时尖峰）
+	if raw := a.config.GetRawRetention(); raw > 0 && start.Before(time.Now().Add(-raw)) {
+		sr.values, sr.times, sr.err = a.store.QueryBucketed(metricType, start, end, time.Hour)
+		sr.resolution = time.Hour
+		sr.exact = true
+		return sr
+	}
+
+	count, err := a.store.QueryCount(metricType, start, end)
+	if err != nil {
+		sr.err = err
+		return sr
+	}
+	if count > maxExactSamples {
+		sr.values, sr.times, sr.err = a.store.QueryBucketed(metricType, start, end, aggregateBucket)
+		sr.resolution = aggregateBucket
+		return sr
+	}
+
+	// 开启去重的类型需要读取 extra 中的跳过计数，还原后各样本按其代表的时长等权
+	if _, ok := a.config.Storage.Dedup[string(metricType)]; ok {
+		values, times, repeats, err := a.store.QueryValuesWithRepeats(metricType, start, end)
+		if err != nil {
+			sr.err = err
+			return sr
+		}
+		sr.values, sr.times = expandRepeats(values, times, repeats)
+		return sr
+	}
+
+	sr.values, sr.times, sr.err = a.store.QueryValuesOnly(metricType, start, end)
+	return sr
+}
+
+// expandRepeats 还原去重时跳过的样本：第 i 行之前跳过的样本取第 i-1 行的值，时间在两行之间均匀分布
+// 窗口内第一行之前跳过的样本其值在窗口外，无法还原，直接忽略（最多 heartbeat-1 个）
+func expandRepeats(rowValues []float64, rowTimes []time.Time, repeats []int) ([]float64, []time.Time) {
+	values := make([]float64, 0, len(rowValues))
+	times := make([]time.Time, 0, len(rowTimes))
+	for i, v := range rowValues {
+		if n := repeats[i]; i > 0 && n > 0 {
+			step := rowTimes[i].Sub(rowTimes[i-1]) / time.Duration(n+1)
+			for k := 1; k <= n; k++ {
+				values = append(values, rowValues[i-1])
+				times = append(times, rowTimes[i-1].Add(step*time.Duration(k)))
+			}
+		}
+		values = append(values, v)
+		times = append(times, rowTimes[i])
+	}
+	return values, times
+}
+
+// businessHours 返回业务时段判定函数，未配置时返回 nil
+func (a *Analyzer) businessHours() func(t time.Time) bool {
+	from, to, ok, _ := a.config.Report.BusinessHoursRange()
+	if !ok {
+		return nil
+	}
+	return func(t time.Time) bool {
+		minute := t.Hour()*60 + t.Minute()
+		if from < to {
+			return minute >= from && minute < to
+		}
+		return minute >= from || minute < to // 跨午夜，如 22:00-06:00
+	}
+}
+
+// maskSeries 仅保留业务时段内的样本，未配置业务时段时原样返回
+func (a *Analyzer) maskSeries(sr series) series {
+	keep := a.businessHours()
+	if keep == nil {
+		return sr
+	}
+	masked := sr
+	masked.values, masked.times = nil, nil
+	masked.exact = true
+	for i, t := range sr.times {
+		if keep(t) {
+			masked.values = append(masked.values, sr.values[i])
+			masked.times = append(masked.times, t)
+		}
+	}
+	return masked
+}
+
+// maskMetrics 仅保留业务时段内的指标，未配置业务时段时原样返回
+func (a *Analyzer) maskMetrics(metrics []*storage.Metric) []*storage.Metric {
+	keep := a.businessHours()
+	if keep == nil {
+		return metrics
+	}
+	var kept []*storage.Metric
+	for _, m := range metrics {
+		if keep(m.Timestamp) {
+			kept = append(kept, m)
+		}
+	}
+	return kept
+}
+
+// distribution 计算序列的平均值、P95、P99
+// 原始序列直接精确计算；聚合序列改用数据库直方图（平均值精确，分位数误差不超过一个桶宽）。
+// 启用近期加权时直方图无法携带时间信息，改为对序列样本（聚合序列即各桶均值）按时间加权计算；
+// 开启自适应 Steal 间隔时各样本再按覆盖时长加权
+func (a *Analyzer) distribution(sr series) (avgValue, p95, p99 float64) {
+	if w := a.sampleWeights(sr); w != nil {
+		return weightedAvg(sr.values, w), weightedPercentile(sr.values, w, 95), weightedPercentile(sr.values, w, 99)
+	}
+	if sr.resolution == 0 || sr.exact {
+		return avg(sr.values), percentile(sr.values, 95), percentile(sr.values, 99)
+	}
+	h, err := a.store.QueryHistogram(sr.metricType, sr.start, sr.end, histogramBucketMin)
+	if err != nil {
+		return avg(sr.values), percentile(sr.values, 95), percentile(sr.values, 99)
+	}
+	return h.Avg(), h.Percentile(95), h.Percentile(99)
+}
+
 func extractValues(metrics []*storage.Metric) []float64 {
 	values := make([]float64, len(metrics))
 	for i, m := range metrics {
@@ -729,6 +2273,19 @@ func avg(values []float64) float64 {
 	return sum / float64(len(values))
 }
 
+func min(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	m := values[0]
+	for _, v := range values[1:] {
+		if v < m {
+			m = v
+		}
+	}
+	return m
+}
+
 func max(values []float64) float64 {
 	if len(values) == 0 {
 		return 0
@@ -761,6 +2318,21 @@ func percentile(values []float64, p float64) float64 {
 	return sorted[index]
 }
 
+// stdDev 计算总体标准差
+func stdDev(values []float64) float64 {
+	if len(values) < 2 {
+		return 0
+	}
+
+	mean := avg(values)
+	sumSquares := 0.0
+	for _, v := range values {
+		diff := v - mean
+		sumSquares += diff * diff
+	}
+	return math.Sqrt(sumSquares / float64(len(values)))
+}
+
 func coefficientOfVariation(values []float64) float64 {
 	if len(values) < 2 {
 		return 0
@@ -783,50 +2355,123 @@ func coefficientOfVariation(values []float64) float64 {
 }
 
 // findMaxWithTime 找到最大值及其发生时间
-func findMaxWithTime(metrics []*storage.Metric) (float64, time.Time) {
-	if len(metrics) == 0 {
+func findMaxWithTime(sr series) (float64, time.Time) {
+	if sr.len() == 0 {
 		return 0, time.Time{}
 	}
 
-	maxVal := metrics[0].Value
-	maxTime := metrics[0].Timestamp
+	maxVal := sr.values[0]
+	maxTime := sr.times[0]
 
-	for _, m := range metrics[1:] {
-		if m.Value > maxVal {
-			maxVal = m.Value
-			maxTime = m.Timestamp
+	for i, v := range sr.values[1:] {
+		if v > maxVal {
+			maxVal = v
+			maxTime = sr.times[i+1]
 		}
 	}
 
 	return maxVal, maxTime
 }
 
-// calculateHourlyBreakdown 按小时聚合 CPU Steal 和 IOWait 统计
-func calculateHourlyBreakdown(stealMetrics, iowaitMetrics []*storage.Metric) []HourlyStats {
+// isWeekend 是否为周末
+func isWeekend(t time.Time) bool {
+	wd := t.Weekday()
+	return wd == time.Saturday || wd == time.Sunday
+}
+
+// splitByDayType 将序列拆分为工作日与周末两组
+func splitByDayType(sr series) (weekday, weekend []float64) {
+	for i, v := range sr.values {
+		if isWeekend(sr.times[i]) {
+			weekend = append(weekend, v)
+		} else {
+			weekday = append(weekday, v)
+		}
+	}
+	return weekday, weekend
+}
+
+// calculateDayTypeComparison 计算工作日与周末的 Steal/Load 对比，任一组缺少 Steal 数据时返回 nil
+func calculateDayTypeComparison(steal, load series) *DayTypeComparison {
+	weekdaySteal, weekendSteal := splitByDayType(steal)
+	if len(weekdaySteal) == 0 || len(weekendSteal) == 0 {
+		return nil
+	}
+	weekdayLoad, weekendLoad := splitByDayType(load)
+
+	return &DayTypeComparison{
+		WeekdayStealAvg: avg(weekdaySteal),
+		WeekendStealAvg: avg(weekendSteal),
+		WeekdayLoadAvg:  avg(weekdayLoad),
+		WeekendLoadAvg:  avg(weekendLoad),
+		WeekdaySamples:  len(weekdaySteal),
+		WeekendSamples:  len(weekendSteal),
+	}
+}
+
+// worstHourLatencyPoints 写延迟达到评分阈值（SSD 20ms / HDD 50ms）时计入的劣化分，
+// 与 10% Steal 相当，使三项指标量级可比
+const worstHourLatencyPoints = 10.0
+
+// latencyThreshold 顺序写延迟开始扣分的阈值（ms），与 scoreIOLatency 的满分线一致
+func latencyThreshold(storageType collector.StorageType) float64 {
+	if storageType == collector.StorageTypeHDD {
+		return 50
+	}
+	return 20
+}
+
+// hourBadness 小时综合劣化分：Steal% + IOWait% + 归一化写延迟
+func hourBadness(h HourlyStats, storageType collector.StorageType) float64 {
+	return h.CPUStealAvg + h.CPUIoWaitAvg + h.IOLatencyAvg/latencyThreshold(storageType)*worstHourLatencyPoints
+}
+
+// findWorstHour 返回综合劣化分最高的小时及其分数，全部为 0 时返回 nil
+func findWorstHour(hourly []HourlyStats, storageType collector.StorageType) (*HourlyStats, float64) {
+	var worst *HourlyStats
+	worstScore := 0.0
+	for i := range hourly {
+		if score := hourBadness(hourly[i], storageType); score > worstScore {
+			worst, worstScore = &hourly[i], score
+		}
+	}
+	return worst, worstScore
+}
+
+// calculateHourlyBreakdown 按小时聚合 CPU Steal、IOWait 与顺序写延迟统计
+func calculateHourlyBreakdown(steal, iowait, ioLatency series) []HourlyStats {
 	// 按小时分组数据
 	type hourData struct {
-		stealValues  []float64
-		iowaitValues []float64
+		stealValues   []float64
+		iowaitValues  []float64
+		latencyValues []float64
 	}
 
 	hourlyData := make(map[int]*hourData)
 
 	// 收集 CPU Steal 数据
-	for _, m := range stealMetrics {
-		hour := m.Timestamp.Hour()
+	for i, v := range steal.values {
+		hour := steal.times[i].Hour()
 		if hourlyData[hour] == nil {
 			hourlyData[hour] = &hourData{}
 		}
-		hourlyData[hour].stealValues = append(hourlyData[hour].stealValues, m.Value)
+		hourlyData[hour].stealValues = append(hourlyData[hour].stealValues, v)
 	}
 
 	// 收集 IOWait 数据
-	for _, m := range iowaitMetrics {
-		hour := m.Timestamp.Hour()
+	for i, v := range iowait.values {
+		hour := iowait.times[i].Hour()
 		if hourlyData[hour] == nil {
 			hourlyData[hour] = &hourData{}
 		}
-		hourlyData[hour].iowaitValues = append(hourlyData[hour].iowaitValues, m.Value)
+		hourlyData[hour].iowaitValues = append(hourlyData[hour].iowaitValues, v)
+	}
+
+	// 收集顺序写延迟数据（间隔较长，只用于补充已有 CPU 样本的小时）
+	for i, v := range ioLatency.values {
+		if data := hourlyData[ioLatency.times[i].Hour()]; data != nil {
+			data.latencyValues = append(data.latencyValues, v)
+		}
 	}
 
 	// 生成按小时的统计结果
@@ -853,6 +2498,10 @@ func calculateHourlyBreakdown(stealMetrics, iowaitMetrics []*storage.Metric) []H
 			hs.CPUIoWaitMax = max(data.iowaitValues)
 		}
 
+		if len(data.latencyValues) > 0 {
+			hs.IOLatencyAvg = avg(data.latencyValues)
+		}
+
 		result = append(result, hs)
 	}
 
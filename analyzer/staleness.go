@@ -0,0 +1,102 @@
+package analyzer
+
+import (
+	"log"
+	"sort"
+	"time"
+
+	"github.com/Catker/chaoleme/config"
+	"github.com/Catker/chaoleme/storage"
+)
+
+// staleIntervals 最新样本超过该倍数的采集间隔未更新时视为采集器失效
+const staleIntervals = 6
+
+// StaleMetric 长时间未更新的指标（采集器可能已失效）
+type StaleMetric struct {
+	Type        storage.MetricType `json:"type"`
+	LastUpdated time.Time          `json:"last_updated"`
+	Age         time.Duration      `json:"age_ns"`
+}
+
+// MetricIntervals 按当前配置返回各指标类型的预期写入间隔（与守护进程的定时任务分组一致）
+// 开启自适应 Steal 间隔时按最短间隔计（用于时间戳取整等需要下限的场景）
+func MetricIntervals(cfg *config.Config) map[storage.MetricType]time.Duration {
+	stealInterval := cfg.GetCPUStealInterval()
+	if cfg.Collect.AdaptiveInterval {
+		stealInterval, _ = cfg.GetAdaptiveIntervalBounds()
+	}
+	return metricIntervals(cfg, stealInterval)
+}
+
+// metricIntervals 以给定的 Steal 组间隔构造各指标类型的写入间隔
+func metricIntervals(cfg *config.Config, stealInterval time.Duration) map[storage.MetricType]time.Duration {
+	groups := []struct {
+		interval time.Duration
+		types    []storage.MetricType
+	}{
+		{stealInterval, []storage.MetricType{storage.MetricTypeCPUSteal, storage.MetricTypeCPUIoWait, storage.MetricTypeCPULoad,
+			storage.MetricTypeRunQueue, storage.MetricTypeCPUOnline, storage.MetricTypeNetwork, storage.MetricTypeNUMA,
+			storage.MetricTypeSchedStat}},
+		{cfg.GetCPUBenchInterval(), []storage.MetricType{storage.MetricTypeCPUBench, storage.MetricTypeMemFault, storage.MetricTypeCPUTemp}},
+		{cfg.GetIOTestInterval(), []storage.MetricType{storage.MetricTypeIOLatency, storage.MetricTypeRandomIO, storage.MetricTypeSyncProbe, storage.MetricTypeMemory, storage.MetricTypeDiskStats}},
+	}
+
+	intervals := make(map[storage.MetricType]time.Duration)
+	for _, g := range groups {
+		for _, t := range g.types {
+			intervals[t] = g.interval
+		}
+	}
+	for i := range cfg.Collect.CustomCommands {
+		cc := &cfg.Collect.CustomCommands[i]
+		intervals[storage.CustomMetricType(cc.Name)] = cc.GetInterval()
+	}
+	return intervals
+}
+
+// detectStaleMetrics 找出最新样本已超过 staleIntervals 个采集间隔未更新的指标
+// 只检查曾经写入过的类型（从未采集到的类型如无 thermal zone 的温度不算失效）；
+// 被动模式与关闭缺页/随机 I/O/落盘探测时跳过对应的主动测试指标，开启去重的类型按心跳间隔放宽；
+// 自适应 Steal 间隔平稳期会延长到上限，Steal 组按上限计，避免被误报为失效
+func (a *Analyzer) detectStaleMetrics(now time.Time) []StaleMetric {
+	var stale []StaleMetric
+	for metricType, interval := range metricIntervals(a.config, a.config.ExpectedCPUStealInterval()) {
+		switch metricType {
+		case storage.MetricTypeCPUBench, storage.MetricTypeIOLatency:
+			if a.config.Collect.PassiveOnly {
+				continue
+			}
+		case storage.MetricTypeRandomIO:
+			if a.config.Collect.PassiveOnly || !a.config.Collect.RandomIOTest {
+				continue
+			}
+		case storage.MetricTypeSyncProbe:
+			if a.config.Collect.PassiveOnly || !a.config.Collect.SyncProbe {
+				continue
+			}
+		case storage.MetricTypeMemFault:
+			if a.config.Collect.PassiveOnly || a.config.Collect.MemBenchSizeMB == 0 {
+				continue
+			}
+		}
+		if rule, ok := a.config.Storage.Dedup[string(metricType)]; ok {
+			interval *= time.Duration(rule.Heartbeat)
+		}
+
+		latest, err := a.store.GetLatestMetric(metricType)
+		if err != nil {
+			log.Printf("查询 %s 最新样本失败: %v", metricType, err)
+			continue
+		}
+		if latest == nil {
+			continue
+		}
+		if age := now.Sub(latest.Timestamp); age > staleIntervals*interval {
+			stale = append(stale, StaleMetric{Type: metricType, LastUpdated: latest.Timestamp, Age: age})
+		}
+	}
+
+	sort.Slice(stale, func(i, j int) bool { return stale[i].Type < stale[j].Type })
+	return stale
+}
@@ -0,0 +1,32 @@
+package analyzer
+
+import (
+	"log"
+
+	"github.com/Catker/chaoleme/collector"
+)
+
+// virtualizationStateKey 持久化虚拟化平台的状态键
+const virtualizationStateKey = "virtualization"
+
+// untrustedStealFactor Steal 不可信（容器环境）时其评分权重的缩放系数，其余项按比例放大
+const untrustedStealFactor = 0.5
+
+// RecordVirtualization 识别虚拟化平台并持久化，守护进程启动时调用一次
+// 报告从数据库读取，不必每次分析都探测；迁移到其他平台后重启即可更新
+func (a *Analyzer) RecordVirtualization() (collector.Virtualization, string) {
+	virt, source := collector.DetectVirtualization()
+	if err := a.store.SetState(virtualizationStateKey, string(virt)); err != nil {
+		log.Printf("保存虚拟化平台失败: %v", err)
+	}
+	return virt, source
+}
+
+// virtualization 读取持久化的虚拟化平台，尚未记录时（如从未运行过守护进程）现场识别
+func (a *Analyzer) virtualization() collector.Virtualization {
+	if value, _, ok, err := a.store.GetState(virtualizationStateKey); err == nil && ok {
+		return collector.Virtualization(value)
+	}
+	virt, _ := collector.DetectVirtualization()
+	return virt
+}
@@ -0,0 +1,26 @@
+package collector
+
+import (
+	"fmt"
+	"syscall"
+	"unsafe"
+)
+
+// maxAffinityCPU sched_setaffinity 掩码支持的最大 CPU 数
+const maxAffinityCPU = 1024
+
+// pinCurrentThread 将调用线程绑定到指定 CPU（调用方需已 runtime.LockOSThread）
+// 容器 cpuset 限制、seccomp 或 CPU 不存在/离线时返回错误，由调用方决定是否降级
+func pinCurrentThread(cpu int) error {
+	if cpu < 0 || cpu >= maxAffinityCPU {
+		return fmt.Errorf("CPU 序号超出范围: %d", cpu)
+	}
+	var mask [maxAffinityCPU / 64]uint64
+	mask[cpu/64] |= 1 << (uint(cpu) % 64)
+	// pid 为 0 表示调用线程本身
+	_, _, errno := syscall.RawSyscall(syscall.SYS_SCHED_SETAFFINITY, 0, unsafe.Sizeof(mask), uintptr(unsafe.Pointer(&mask)))
+	if errno != 0 {
+		return fmt.Errorf("绑定 CPU %d 失败: %w", cpu, errno)
+	}
+	return nil
+}
@@ -3,8 +3,11 @@ package collector
 import (
 	"bufio"
 	"fmt"
+	"log"
 	"math"
 	"os"
+	"runtime"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -22,6 +25,8 @@ type CPUStats struct {
 	Steal     uint64
 	Guest     uint64
 	GuestNice uint64
+
+	Raw string // 原始 cpu 行，用于原始快照与事后回放
 }
 
 // Total 计算总 CPU 时间
@@ -29,21 +34,41 @@ func (s *CPUStats) Total() uint64 {
 	return s.User + s.Nice + s.System + s.Idle + s.IOWait + s.IRQ + s.SoftIRQ + s.Steal + s.Guest + s.GuestNice
 }
 
+// SuspendJumpThreshold 两次采样间墙钟与单调时钟的差值超过该阈值，视为虚拟机被挂起或迁移
+// 挂起期间单调时钟停止而墙钟继续（或恢复后经 NTP 追平），/proc/stat 会出现一次性的 Steal 尖峰
+const SuspendJumpThreshold = 10 * time.Second
+
 // CPUCollector CPU 数据采集器
 type CPUCollector struct {
+	procPath  string
 	lastStats *CPUStats
+	lastTime  time.Time // 上次采样时间（含单调时钟读数）
+
+	// 本工具自身 I/O 测试窗口内的计数器增量，下次 Collect 时从区间增量中扣除
+	selfTestStart *CPUStats
+	selfTestDelta CPUStats
+
+	benchCPU  int  // 基准测试绑定的 CPU，-1 表示不绑定
+	pinWarned bool // 绑定失败的提示只打印一次
 }
 
-// NewCPUCollector 创建 CPU 采集器
-func NewCPUCollector() *CPUCollector {
-	return &CPUCollector{}
+// NewCPUCollector 创建 CPU 采集器，procPath 为 procfs 根目录（通常为 DefaultProcPath）
+func NewCPUCollector(procPath string) *CPUCollector {
+	return &CPUCollector{procPath: procPath, benchCPU: -1}
 }
 
-// readCPUStats 从 /proc/stat 读取 CPU 统计
-func readCPUStats() (*CPUStats, error) {
-	file, err := os.Open("/proc/stat")
+// PinBenchmark 设置基准测试绑定的 CPU（-1 表示不绑定）
+// 调度器在负载不同的核心间迁移会让耗时混入调度噪声，固定核心后历次结果才可比
+func (c *CPUCollector) PinBenchmark(cpu int) {
+	c.benchCPU = cpu
+}
+
+// readCPUStats 从 <procPath>/stat 读取 CPU 统计
+func readCPUStats(procPath string) (*CPUStats, error) {
+	path := procFile(procPath, "stat")
+	file, err := os.Open(path)
 	if err != nil {
-		return nil, fmt.Errorf("无法打开 /proc/stat: %w", err)
+		return nil, fmt.Errorf("无法打开 %s: %w", path, err)
 	}
 	defer file.Close()
 
@@ -51,37 +76,150 @@ func readCPUStats() (*CPUStats, error) {
 	for scanner.Scan() {
 		line := scanner.Text()
 		if strings.HasPrefix(line, "cpu ") {
-			fields := strings.Fields(line)
-			if len(fields) < 11 {
-				return nil, fmt.Errorf("cpu 行字段不足: %s", line)
-			}
+			return ParseCPUStatLine(line)
+		}
+	}
+
+	return nil, fmt.Errorf("未找到 cpu 行")
+}
+
+// ParseCPUStatLine 解析 /proc/stat 的汇总 cpu 行（"cpu  user nice system ..."）
+func ParseCPUStatLine(line string) (*CPUStats, error) {
+	fields := strings.Fields(line)
+	if len(fields) < 11 || fields[0] != "cpu" {
+		return nil, fmt.Errorf("cpu 行字段不足: %s", line)
+	}
+
+	values := make([]uint64, 10)
+	for i := 0; i < 10; i++ {
+		v, err := strconv.ParseUint(fields[i+1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("解析 CPU 统计失败: %w", err)
+		}
+		values[i] = v
+	}
+
+	return &CPUStats{
+		User:      values[0],
+		Nice:      values[1],
+		System:    values[2],
+		Idle:      values[3],
+		IOWait:    values[4],
+		IRQ:       values[5],
+		SoftIRQ:   values[6],
+		Steal:     values[7],
+		Guest:     values[8],
+		GuestNice: values[9],
+		Raw:       line,
+	}, nil
+}
+
+// sub 逐字段计算 s - o，单个字段不足时取 0
+func (s *CPUStats) sub(o *CPUStats) *CPUStats {
+	minus := func(a, b uint64) uint64 {
+		if a < b {
+			return 0
+		}
+		return a - b
+	}
+	return &CPUStats{
+		User:      minus(s.User, o.User),
+		Nice:      minus(s.Nice, o.Nice),
+		System:    minus(s.System, o.System),
+		Idle:      minus(s.Idle, o.Idle),
+		IOWait:    minus(s.IOWait, o.IOWait),
+		IRQ:       minus(s.IRQ, o.IRQ),
+		SoftIRQ:   minus(s.SoftIRQ, o.SoftIRQ),
+		Steal:     minus(s.Steal, o.Steal),
+		Guest:     minus(s.Guest, o.Guest),
+		GuestNice: minus(s.GuestNice, o.GuestNice),
+		Raw:       s.Raw,
+	}
+}
+
+// add 逐字段累加 o
+func (s *CPUStats) add(o *CPUStats) {
+	s.User += o.User
+	s.Nice += o.Nice
+	s.System += o.System
+	s.Idle += o.Idle
+	s.IOWait += o.IOWait
+	s.IRQ += o.IRQ
+	s.SoftIRQ += o.SoftIRQ
+	s.Steal += o.Steal
+	s.Guest += o.Guest
+	s.GuestNice += o.GuestNice
+}
 
-			stats := &CPUStats{}
-			values := make([]uint64, 10)
-			for i := 0; i < 10 && i+1 < len(fields); i++ {
-				v, err := strconv.ParseUint(fields[i+1], 10, 64)
-				if err != nil {
-					return nil, fmt.Errorf("解析 CPU 统计失败: %w", err)
+// UsageBetween 由前后两次 CPU 统计计算 Steal 与 IOWait 百分比，总时间无增长时均为 0
+// 差值按有符号计算：个别计数器回退（如 iowait 在多核间统计的竞争）时不会因无符号回绕得到天文数字，
+// 结果限制在 [0, 100]
+func UsageBetween(prev, cur *CPUStats) (stealPercent, iowaitPercent float64) {
+	totalDelta := float64(cur.Total()) - float64(prev.Total())
+	if totalDelta <= 0 {
+		return 0, 0
+	}
+	stealDelta := float64(cur.Steal) - float64(prev.Steal)
+	iowaitDelta := float64(cur.IOWait) - float64(prev.IOWait)
+	return clampPercent("CPU Steal", stealDelta/totalDelta*100), clampPercent("CPU IOWait", iowaitDelta/totalDelta*100)
+}
+
+// CPUInfo /proc/cpuinfo 中与虚拟化相关的信息
+type CPUInfo struct {
+	ModelName  string
+	Hypervisor bool // flags 中包含 hypervisor，说明运行在虚拟机中
+	HasFlags   bool // 是否有 flags 行（ARM 等架构没有），没有时 Hypervisor 为 false 不代表物理机
+}
+
+// GenericModel 是否为虚拟化层提供的通用 CPU 型号（未透传宿主机 CPU）
+// 通用型号多见于廉价共享套餐，独享套餐通常透传真实型号
+func (i *CPUInfo) GenericModel() bool {
+	name := strings.ToLower(i.ModelName)
+	for _, generic := range []string{"qemu virtual cpu", "common kvm processor", "kvm processor"} {
+		if strings.Contains(name, generic) {
+			return true
+		}
+	}
+	return false
+}
+
+// ReadCPUInfo 读取 <procPath>/cpuinfo（仅解析第一个处理器），procPath 通常为 DefaultProcPath
+func ReadCPUInfo(procPath string) (*CPUInfo, error) {
+	path := procFile(procPath, "cpuinfo")
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("无法打开 %s: %w", path, err)
+	}
+	defer file.Close()
+
+	info := &CPUInfo{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			break // 第一个处理器结束
+		}
+		key, value, found := strings.Cut(line, ":")
+		if !found {
+			continue
+		}
+		switch strings.TrimSpace(key) {
+		case "model name":
+			info.ModelName = strings.TrimSpace(value)
+		case "flags":
+			info.HasFlags = true
+			for _, flag := range strings.Fields(value) {
+				if flag == "hypervisor" {
+					info.Hypervisor = true
 				}
-				values[i] = v
 			}
-
-			stats.User = values[0]
-			stats.Nice = values[1]
-			stats.System = values[2]
-			stats.Idle = values[3]
-			stats.IOWait = values[4]
-			stats.IRQ = values[5]
-			stats.SoftIRQ = values[6]
-			stats.Steal = values[7]
-			stats.Guest = values[8]
-			stats.GuestNice = values[9]
-
-			return stats, nil
 		}
 	}
 
-	return nil, fmt.Errorf("未找到 cpu 行")
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("读取 %s 失败: %w", path, err)
+	}
+	return info, nil
 }
 
 // CPUUsageResult CPU 使用率采集结果（统一采集，确保数据准确性）
@@ -89,40 +227,211 @@ func readCPUStats() (*CPUStats, error) {
 type CPUUsage struct {
 	StealPercent  float64
 	IOWaitPercent float64
+	// 自上次采样以来墙钟与单调时钟的偏差，超过 SuspendJumpThreshold 时本次 Steal 为迁移/挂起伪影
+	ClockJump time.Duration
+	RawStat   string // 本次读取的原始 cpu 行
+	// 因扣除本工具 I/O 测试窗口而从 IOWait 中去掉的百分点（未扣除值 - IOWaitPercent）
+	SelfTestIOWait float64
+	// 本次 Steal/IOWait 覆盖的时长：正常为距上次采样（或 Prime/Resume 起点）的间隔
+	Window time.Duration
+	// 没有起点时临时采样 bootstrapWindow 得到的自举样本，窗口远短于采集间隔，与常规样本不可比
+	Bootstrap bool
+}
+
+// Suspended 本次采样期间是否疑似发生了虚拟机挂起/迁移
+func (u *CPUUsage) Suspended() bool {
+	return u.ClockJump >= SuspendJumpThreshold
+}
+
+// bootstrapWindow 没有起点时首次 Collect 临时等待的时长
+const bootstrapWindow = 500 * time.Millisecond
+
+// Prime 记录当前 CPU 统计作为下次采集的起点
+// 启动等待期间调用，使首次采集覆盖整个等待窗口，而不是临时采样的 500ms
+func (c *CPUCollector) Prime() error {
+	current, err := readCPUStats(c.procPath)
+	if err != nil {
+		return err
+	}
+	c.lastStats = current
+	c.lastTime = time.Now()
+	return nil
+}
+
+// Resume 以上一个进程保存的 cpu 行（见 Checkpoint）作为下次采集的起点，
+// 使 -collect-once 的样本覆盖两次运行之间的完整间隔；系统重启后计数器归零时返回错误
+func (c *CPUCollector) Resume(raw string, at time.Time) error {
+	prev, err := ParseCPUStatLine(raw)
+	if err != nil {
+		return err
+	}
+	current, err := readCPUStats(c.procPath)
+	if err != nil {
+		return err
+	}
+	if current.Total() <= prev.Total() {
+		return fmt.Errorf("CPU 计数器小于保存的起点（系统可能已重启）")
+	}
+	c.lastStats = prev
+	c.lastTime = at
+	return nil
+}
+
+// Checkpoint 返回最近一次采样的原始 cpu 行，供下一个进程 Resume
+func (c *CPUCollector) Checkpoint() (string, bool) {
+	if c.lastStats == nil || c.lastStats.Raw == "" {
+		return "", false
+	}
+	return c.lastStats.Raw, true
+}
+
+// BeginSelfTest 标记本工具的 I/O 测试开始
+// 延迟与随机 I/O 测试会在测试盘上制造真实的 I/O 等待，若不处理，短间隔采样时
+// 这部分 iowait 会被算作磁盘争抢。Begin/End 之间的 /proc/stat 增量在下次 Collect 时
+// 整体扣除（总时间与各分项同时扣除），相当于把测试窗口从采样区间中剪掉
+func (c *CPUCollector) BeginSelfTest() {
+	start, err := readCPUStats(c.procPath)
+	if err != nil {
+		return
+	}
+	c.selfTestStart = start
+}
+
+// EndSelfTest 标记本工具的 I/O 测试结束，累计测试窗口内的计数器增量
+func (c *CPUCollector) EndSelfTest() {
+	if c.selfTestStart == nil {
+		return
+	}
+	start := c.selfTestStart
+	c.selfTestStart = nil
+	end, err := readCPUStats(c.procPath)
+	if err != nil {
+		return
+	}
+	c.selfTestDelta.add(end.sub(start))
 }
 
 // Collect 统一采集 CPU 指标（Steal 和 IOWait）
 func (c *CPUCollector) Collect() (*CPUUsage, error) {
-	current, err := readCPUStats()
+	current, err := readCPUStats(c.procPath)
 	if err != nil {
 		return nil, err
 	}
 
-	if c.lastStats == nil {
+	now := time.Now()
+
+	bootstrap := c.lastStats == nil
+	if bootstrap {
 		c.lastStats = current
+		c.lastTime = now
+		c.selfTestDelta = CPUStats{} // 起点之前的测试窗口与本区间无关
 		// 等待一小段时间再采集，确保有时间差
 		// 使用 500ms 而非 100ms，减少瞬时波动对 Steal/IOWait 计算的影响
-		time.Sleep(500 * time.Millisecond)
-		current, err = readCPUStats()
+		time.Sleep(bootstrapWindow)
+		current, err = readCPUStats(c.procPath)
 		if err != nil {
 			return nil, err
 		}
+		now = time.Now()
 	}
 
-	totalDelta := current.Total() - c.lastStats.Total()
-	stealDelta := current.Steal - c.lastStats.Steal
-	iowaitDelta := current.IOWait - c.lastStats.IOWait
+	stealPercent, iowaitPercent := UsageBetween(c.lastStats, current)
+
+	// 扣除区间内本工具 I/O 测试窗口的增量；扣除后区间为空（整段都在测试）时保留原值
+	var selfTestIOWait float64
+	if c.selfTestDelta.Total() > 0 {
+		adjusted := current.sub(&c.selfTestDelta)
+		if adjusted.Total() > c.lastStats.Total() {
+			raw := iowaitPercent
+			stealPercent, iowaitPercent = UsageBetween(c.lastStats, adjusted)
+			selfTestIOWait = math.Max(0, raw-iowaitPercent)
+		}
+		c.selfTestDelta = CPUStats{}
+	}
+
+	// 墙钟间隔（Round(0) 去掉单调时钟读数）与单调时钟间隔之差
+	clockJump := now.Round(0).Sub(c.lastTime.Round(0)) - now.Sub(c.lastTime)
+	if clockJump < 0 {
+		clockJump = -clockJump
+	}
+
+	window := now.Sub(c.lastTime)
 
 	// 更新 lastStats
 	c.lastStats = current
+	c.lastTime = now
 
-	if totalDelta == 0 {
-		return &CPUUsage{0, 0}, nil
+	return &CPUUsage{
+		StealPercent:   stealPercent,
+		IOWaitPercent:  iowaitPercent,
+		ClockJump:      clockJump,
+		RawStat:        current.Raw,
+		SelfTestIOWait: selfTestIOWait,
+		Window:         window,
+		Bootstrap:      bootstrap,
+	}, nil
+}
+
+// StealWindowResult 短窗口高精度 Steal 测量结果
+type StealWindowResult struct {
+	Duration       time.Duration
+	SampleInterval time.Duration
+	Samples        int
+	Min            float64
+	Avg            float64
+	Max            float64
+	P95            float64
+}
+
+// MeasureSteal 在指定时长内以 sampleInterval 高频采样 Steal
+// 用于怀疑邻居刚上线时的临时排查：每个子窗口独立计算 Steal 百分比，
+// 不读取也不修改守护进程使用的 lastStats
+func (c *CPUCollector) MeasureSteal(duration, sampleInterval time.Duration) (*StealWindowResult, error) {
+	prev, err := readCPUStats(c.procPath)
+	if err != nil {
+		return nil, err
 	}
 
-	return &CPUUsage{
-		StealPercent:  float64(stealDelta) / float64(totalDelta) * 100,
-		IOWaitPercent: float64(iowaitDelta) / float64(totalDelta) * 100,
+	var samples []float64
+	deadline := time.Now().Add(duration)
+	for time.Now().Before(deadline) {
+		time.Sleep(sampleInterval)
+		current, err := readCPUStats(c.procPath)
+		if err != nil {
+			return nil, err
+		}
+		if current.Total() > prev.Total() {
+			steal, _ := UsageBetween(prev, current)
+			samples = append(samples, steal)
+		}
+		prev = current
+	}
+
+	if len(samples) == 0 {
+		return nil, fmt.Errorf("测量窗口内无有效样本")
+	}
+
+	sorted := make([]float64, len(samples))
+	copy(sorted, samples)
+	sort.Float64s(sorted)
+
+	sum := 0.0
+	for _, v := range sorted {
+		sum += v
+	}
+	p95Index := int(math.Ceil(0.95*float64(len(sorted)))) - 1
+	if p95Index < 0 {
+		p95Index = 0
+	}
+
+	return &StealWindowResult{
+		Duration:       duration,
+		SampleInterval: sampleInterval,
+		Samples:        len(sorted),
+		Min:            sorted[0],
+		Avg:            sum / float64(len(sorted)),
+		Max:            sorted[len(sorted)-1],
+		P95:            sorted[p95Index],
 	}, nil
 }
 
@@ -132,8 +441,28 @@ type BenchmarkResult struct {
 }
 
 // RunBenchmark 执行 CPU 基准测试
-// 计算一定数量的素数，返回耗时
+// 计算一定数量的素数，返回耗时；设置了 PinBenchmark 时在绑定到该 CPU 的独立线程上执行，
+// 绑定失败（容器限制、CPU 不存在等）时退回不绑定
 func (c *CPUCollector) RunBenchmark() (*BenchmarkResult, error) {
+	if c.benchCPU < 0 {
+		return runPrimeBenchmark(), nil
+	}
+
+	done := make(chan *BenchmarkResult, 1)
+	go func() {
+		// 不调用 UnlockOSThread：goroutine 退出时带着绑定掩码的线程随之销毁，不会回到调度器的线程池
+		runtime.LockOSThread()
+		if err := pinCurrentThread(c.benchCPU); err != nil && !c.pinWarned {
+			log.Printf("CPU 基准测试%v，改为不绑定运行", err)
+			c.pinWarned = true
+		}
+		done <- runPrimeBenchmark()
+	}()
+	return <-done, nil
+}
+
+// runPrimeBenchmark 计算前 10000 个素数并计时
+func runPrimeBenchmark() *BenchmarkResult {
 	start := time.Now()
 
 	// 使用埃拉托斯特尼筛法找前 10000 个素数
@@ -152,7 +481,7 @@ func (c *CPUCollector) RunBenchmark() (*BenchmarkResult, error) {
 
 	return &BenchmarkResult{
 		DurationMs: float64(duration.Microseconds()) / 1000.0,
-	}, nil
+	}
 }
 
 // isPrime 判断是否为素数
@@ -0,0 +1,50 @@
+package collector
+
+import "testing"
+
+func TestReadCPUStats(t *testing.T) {
+	tests := []struct {
+		procPath                  string
+		user, idle, iowait, steal uint64
+	}{
+		{fixtureKVM, 1843562, 98234511, 45123, 352118},
+		{fixtureCentOS7, 123456, 9876543, 2345, 91011},
+	}
+	for _, tt := range tests {
+		stats, err := readCPUStats(tt.procPath)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.procPath, err)
+		}
+		if stats.User != tt.user || stats.Idle != tt.idle || stats.IOWait != tt.iowait || stats.Steal != tt.steal {
+			t.Errorf("%s: got user=%d idle=%d iowait=%d steal=%d, want %d %d %d %d",
+				tt.procPath, stats.User, stats.Idle, stats.IOWait, stats.Steal, tt.user, tt.idle, tt.iowait, tt.steal)
+		}
+	}
+}
+
+func TestReadCPUStatsMissing(t *testing.T) {
+	if _, err := readCPUStats(t.TempDir()); err == nil {
+		t.Fatal("expected error for missing stat file")
+	}
+}
+
+func TestReadCPUInfo(t *testing.T) {
+	tests := []struct {
+		procPath   string
+		model      string
+		hypervisor bool
+		generic    bool
+	}{
+		{fixtureKVM, "Intel Xeon Processor (Cascadelake)", true, false},
+		{fixtureCentOS7, "QEMU Virtual CPU version 2.5+", true, true},
+	}
+	for _, tt := range tests {
+		info, err := ReadCPUInfo(tt.procPath)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.procPath, err)
+		}
+		if info.ModelName != tt.model || info.Hypervisor != tt.hypervisor || !info.HasFlags || info.GenericModel() != tt.generic {
+			t.Errorf("%s: got %+v generic=%v", tt.procPath, info, info.GenericModel())
+		}
+	}
+}
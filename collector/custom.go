@@ -0,0 +1,41 @@
+package collector
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RunCustomCommand 执行自定义指标命令，将 stdout 解析为浮点数
+// 命令通过 /bin/sh -c 执行，超时后强制终止；失败时错误信息包含 stderr 便于排查
+func RunCustomCommand(command string, timeout time.Duration) (float64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "/bin/sh", "-c", command)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	// 子进程残留持有管道时，超时后最多再等待 1s
+	cmd.WaitDelay = time.Second
+
+	if err := cmd.Run(); err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return 0, fmt.Errorf("命令执行超时 (%v)", timeout)
+		}
+		return 0, fmt.Errorf("命令执行失败: %w, stderr: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	output := strings.TrimSpace(stdout.String())
+	value, err := strconv.ParseFloat(output, 64)
+	if err != nil {
+		return 0, fmt.Errorf("无法将输出解析为数值: %q", output)
+	}
+
+	return value, nil
+}
@@ -1,11 +1,15 @@
 package collector
 
 import (
-	"crypto/rand"
+	"encoding/binary"
 	"fmt"
+	"log"
+	"math/rand/v2"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 	"unsafe"
@@ -13,16 +17,48 @@ import (
 
 // DiskCollector 磁盘 I/O 采集器
 type DiskCollector struct {
-	testDir  string
-	testSize int // 测试文件大小（字节）
+	testDir        string
+	testSize       int             // 测试文件大小（字节）
+	excludeDevices map[string]bool // 不计入统计的设备名
+	prealloc       bool            // 使用预分配的持久测试文件
+	samples        int             // 每次 I/O 测试重复测量的次数
+	procPath       string          // procfs 根目录
+	preallocDone   bool            // 持久测试文件是否已就绪
+	fsType         string          // 测试目录所在文件系统类型（来自 /proc/mounts）
+
+	testMu sync.Mutex // I/O 测试与卡顿探测互斥
+	canary *os.File   // 卡顿探测文件，首次探测时打开并常驻
+
+	statsMu    sync.Mutex        // 保护上次 /proc/diskstats 快照
+	prevIOTime map[string]uint64 // 上次采集时各设备的累计 IO 耗时（毫秒）
+	prevAt     time.Time         // 上次采集时间
 }
 
-// isTmpfs 检测指定路径是否挂载为 tmpfs（内存盘）
-// 注意：在 tmpfs 上进行 I/O 测试会测量内存速度而非磁盘速度
-func isTmpfs(path string) bool {
-	data, err := os.ReadFile("/proc/mounts")
+// preallocFileName 持久测试文件名（prealloc 模式下跨周期、跨重启复用）
+const preallocFileName = "chaoleme-io-test.dat"
+
+// canaryFileName 卡顿探测文件名（常驻，每次原地覆写 1 字节）
+const canaryFileName = "chaoleme-canary.dat"
+
+// testFilePrefix 临时测试文件的统一前缀，启动时据此识别上次崩溃遗留的文件
+const testFilePrefix = "chaoleme-tmp-"
+
+// legacyTestFilePrefixes 旧版本使用的临时文件前缀（不含持久测试文件 chaoleme-io-test.dat）
+var legacyTestFilePrefixes = []string{"chaoleme-io-test-", "chaoleme-random-io-"}
+
+// staleTestFileAge 早于该时长的临时测试文件视为遗留文件；
+// 留出余量，避免误删同时运行的另一个实例（如 -collect-once）正在使用的文件
+const staleTestFileAge = 5 * time.Minute
+
+// mountOf 返回路径所在的挂载点及其文件系统类型（取 <procPath>/mounts 中最长匹配的挂载点）
+func mountOf(procPath, path string) (mountPoint, fsType string) {
+	data, err := os.ReadFile(procFile(procPath, "mounts"))
 	if err != nil {
-		return false
+		return "", ""
+	}
+
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
 	}
 
 	lines := strings.Split(string(data), "\n")
@@ -31,28 +67,83 @@ func isTmpfs(path string) bool {
 		if len(fields) < 3 {
 			continue
 		}
-		mountPoint := fields[1]
-		fsType := fields[2]
+		mp := fields[1]
 
-		// 检查路径是否以此挂载点开头，且文件系统类型为 tmpfs
-		if strings.HasPrefix(path, mountPoint) && fsType == "tmpfs" {
-			// 精确匹配或目录前缀匹配
-			if path == mountPoint || strings.HasPrefix(path, mountPoint+"/") {
-				return true
+		// 精确匹配或目录前缀匹配，取最深的挂载点
+		if path == mp || mp == "/" || strings.HasPrefix(path, mp+"/") {
+			if len(mp) >= len(mountPoint) {
+				mountPoint = mp
+				fsType = fields[2]
 			}
 		}
 	}
+	return mountPoint, fsType
+}
+
+// isTmpfs 检测指定路径是否挂载为 tmpfs（内存盘）
+// 注意：在 tmpfs 上进行 I/O 测试会测量内存速度而非磁盘速度
+func isTmpfs(procPath, path string) bool {
+	_, fsType := mountOf(procPath, path)
+	return fsType == "tmpfs"
+}
+
+// FilesystemKind 文件系统类别，决定 I/O 延迟的合理预期
+type FilesystemKind string
+
+const (
+	FilesystemLocal   FilesystemKind = "local"   // 本地块设备上的常规文件系统（ext4、xfs 等）
+	FilesystemCOW     FilesystemKind = "cow"     // 写时复制文件系统，fsync 伴随写放大，延迟天然偏高
+	FilesystemNetwork FilesystemKind = "network" // 网络/虚拟共享文件系统，延迟包含网络往返
+)
+
+// cowFilesystems 写时复制文件系统
+var cowFilesystems = map[string]bool{
+	"btrfs": true, "zfs": true, "bcachefs": true,
+}
+
+// networkFilesystems 网络或宿主机共享的文件系统（fuse.* 另行判断）
+var networkFilesystems = map[string]bool{
+	"nfs": true, "nfs4": true, "cifs": true, "smb3": true, "ceph": true,
+	"glusterfs": true, "9p": true, "virtiofs": true, "lustre": true,
+}
+
+// ClassifyFilesystem 按 /proc/mounts 中的类型名判断文件系统类别，未知类型视为本地
+func ClassifyFilesystem(fsType string) FilesystemKind {
+	switch {
+	case cowFilesystems[fsType]:
+		return FilesystemCOW
+	case networkFilesystems[fsType], strings.HasPrefix(fsType, "fuse."):
+		return FilesystemNetwork
+	default:
+		return FilesystemLocal
+	}
+}
+
+// isExcludedMount 检测路径所在挂载点是否在排除列表中
+func isExcludedMount(procPath, path string, excludeMounts []string) bool {
+	if len(excludeMounts) == 0 {
+		return false
+	}
+	mountPoint, _ := mountOf(procPath, path)
+	for _, excluded := range excludeMounts {
+		if filepath.Clean(excluded) == mountPoint {
+			return true
+		}
+	}
 	return false
 }
 
-// selectTestDir 选择合适的测试目录，避免使用 tmpfs
+// selectTestDir 选择合适的测试目录，避免使用 tmpfs 和被排除的挂载点
 // 优先级：/tmp（非tmpfs） > /var/tmp > 程序当前目录
-func selectTestDir() string {
+func selectTestDir(procPath string, excludeMounts []string) string {
 	candidates := []string{"/tmp", "/var/tmp", "."}
 
 	for _, dir := range candidates {
 		if dir == "." {
 			// 当前目录作为最后手段
+			if isExcludedMount(procPath, dir, excludeMounts) {
+				log.Printf("⚠️ 未找到可用的 I/O 测试目录，当前目录位于被排除的挂载点，仍将使用")
+			}
 			return dir
 		}
 		// 检查目录是否存在且可写
@@ -60,7 +151,11 @@ func selectTestDir() string {
 			continue
 		}
 		// 检查是否为 tmpfs
-		if isTmpfs(dir) {
+		if isTmpfs(procPath, dir) {
+			continue
+		}
+		// 检查是否位于被排除的挂载点
+		if isExcludedMount(procPath, dir, excludeMounts) {
 			continue
 		}
 		return dir
@@ -68,13 +163,151 @@ func selectTestDir() string {
 	return "."
 }
 
+// DiskOptions 磁盘采集器选项
+type DiskOptions struct {
+	TestSizeMB     int      // 测试文件大小（MB）
+	TestDir        string   // I/O 测试目录，为空时自动选择
+	ExcludeDevices []string // 不计入 /proc/diskstats 统计的设备（如 sdb）
+	ExcludeMounts  []string // 自动选择测试目录时避开的挂载点
+	Prealloc       bool     // 预分配持久测试文件并原地覆写，而非每次创建/删除
+	Samples        int      // 每次 I/O 测试重复测量的次数，取中位数（<1 视为 1）
+	ProcPath       string   // procfs 根目录，为空时使用 DefaultProcPath
+}
+
 // NewDiskCollector 创建磁盘采集器
-// 自动检测并选择合适的测试目录，避免在 tmpfs 上测试
-func NewDiskCollector(testSizeMB int) *DiskCollector {
-	testDir := selectTestDir()
-	return &DiskCollector{
-		testDir:  testDir,
-		testSize: testSizeMB * 1024 * 1024,
+// TestDir 为空时自动检测并选择合适的测试目录，避免在 tmpfs 和被排除的挂载点上测试；
+// 指定 TestDir 时原样使用，若检测为 tmpfs 或被排除的挂载点仅记录警告
+func NewDiskCollector(opts DiskOptions) *DiskCollector {
+	testDir := opts.TestDir
+	if testDir == "" {
+		testDir = selectTestDir(opts.ProcPath, opts.ExcludeMounts)
+	} else if isTmpfs(opts.ProcPath, testDir) {
+		log.Printf("⚠️ I/O 测试目录 %s 位于 tmpfs，测试结果将反映内存速度而非磁盘速度", testDir)
+	} else if isExcludedMount(opts.ProcPath, testDir, opts.ExcludeMounts) {
+		log.Printf("⚠️ I/O 测试目录 %s 位于被排除的挂载点", testDir)
+	}
+
+	_, fsType := mountOf(opts.ProcPath, testDir)
+	if kind := ClassifyFilesystem(fsType); kind != FilesystemLocal {
+		log.Printf("ℹ️ I/O 测试目录 %s 位于 %s（%s），延迟预期与本地 ext4/xfs 不同，评分阈值将放宽", testDir, fsType, kind)
+	}
+
+	excludeDevices := make(map[string]bool, len(opts.ExcludeDevices))
+	for _, dev := range opts.ExcludeDevices {
+		excludeDevices[strings.TrimPrefix(dev, "/dev/")] = true
+	}
+
+	d := &DiskCollector{
+		testDir:        testDir,
+		testSize:       opts.TestSizeMB * 1024 * 1024,
+		excludeDevices: excludeDevices,
+		prealloc:       opts.Prealloc,
+		samples:        max(opts.Samples, 1),
+		procPath:       opts.ProcPath,
+		fsType:         fsType,
+	}
+	d.removeStaleTestFiles()
+	return d
+}
+
+// removeStaleTestFiles 删除测试目录中进程崩溃/被杀时遗留的临时测试文件
+// 每次测试写入 io_test_size_mb 大小的文件，反复崩溃时遗留文件会累积并占满 /tmp 等小分区
+func (d *DiskCollector) removeStaleTestFiles() {
+	entries, err := os.ReadDir(d.testDir)
+	if err != nil {
+		return
+	}
+
+	cutoff := time.Now().Add(-staleTestFileAge)
+	removed := 0
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() || !isTestFileName(entry.Name()) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || info.ModTime().After(cutoff) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(d.testDir, entry.Name())); err != nil {
+			log.Printf("⚠️ 删除遗留测试文件 %s 失败: %v", entry.Name(), err)
+			continue
+		}
+		removed++
+	}
+	if removed > 0 {
+		log.Printf("已清理 %s 中 %d 个遗留的临时测试文件", d.testDir, removed)
+	}
+}
+
+// isTestFileName 判断文件名是否为临时测试文件（持久测试文件除外）
+func isTestFileName(name string) bool {
+	if strings.HasPrefix(name, testFilePrefix) {
+		return true
+	}
+	for _, prefix := range legacyTestFilePrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// FilesystemType 返回测试目录所在文件系统类型（如 ext4、zfs），无法识别时为空
+func (d *DiskCollector) FilesystemType() string {
+	return d.fsType
+}
+
+// ensurePrealloc 创建并预分配持久测试文件（仅首次调用时执行，不计入测试耗时）
+// 文件系统不支持 fallocate 时退化为扩展文件长度，仍可原地覆写复用
+func (d *DiskCollector) ensurePrealloc() error {
+	if d.preallocDone {
+		return nil
+	}
+
+	path := filepath.Join(d.testDir, preallocFileName)
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("创建持久测试文件失败: %w", err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return fmt.Errorf("读取持久测试文件信息失败: %w", err)
+	}
+	if info.Size() < int64(d.testSize) {
+		if err := syscall.Fallocate(int(file.Fd()), 0, 0, int64(d.testSize)); err != nil {
+			log.Printf("⚠️ 预分配测试文件失败（%v），改为扩展文件长度", err)
+			if err := file.Truncate(int64(d.testSize)); err != nil {
+				return fmt.Errorf("扩展持久测试文件失败: %w", err)
+			}
+		}
+		if err := file.Sync(); err != nil {
+			return fmt.Errorf("同步持久测试文件失败: %w", err)
+		}
+	}
+
+	d.preallocDone = true
+	return nil
+}
+
+// testFile 返回本次测试使用的文件路径与打开标志
+// prealloc 模式下为持久文件（不截断，原地覆写）；否则为 chaoleme-tmp-<kind>-<时间戳> 临时文件
+func (d *DiskCollector) testFile(kind string) (path string, flag int, err error) {
+	if d.prealloc {
+		if err := d.ensurePrealloc(); err != nil {
+			return "", 0, err
+		}
+		return filepath.Join(d.testDir, preallocFileName), os.O_WRONLY, nil
+	}
+	path = filepath.Join(d.testDir, fmt.Sprintf("%s%s-%d", testFilePrefix, kind, time.Now().UnixNano()))
+	return path, os.O_CREATE | os.O_WRONLY | os.O_TRUNC, nil
+}
+
+// removeTestFile 删除临时测试文件，prealloc 模式下保留持久文件
+func (d *DiskCollector) removeTestFile(path string) {
+	if !d.prealloc {
+		os.Remove(path)
 	}
 }
 
@@ -83,30 +316,69 @@ type IOLatencyResult struct {
 	WriteLatencyMs float64 // 写入延迟（毫秒）
 	SyncLatencyMs  float64 // fsync 延迟（毫秒）
 	TotalLatencyMs float64 // 总延迟（毫秒）
+	Samples        int     // 测量次数，多次测量时以上各项为中位数
+	SpreadMs       float64 // 各次总延迟的最大值与最小值之差
 }
 
 // TestWriteLatency 测试写入延迟
+// 按 Samples 重复测量，各项取中位数，降低单次测量的噪声
 func (d *DiskCollector) TestWriteLatency() (*IOLatencyResult, error) {
+	d.testMu.Lock()
+	defer d.testMu.Unlock()
+
+	var write, sync, total []float64
+	for range d.samples {
+		r, err := d.testWriteLatencyOnce()
+		if err != nil {
+			return nil, err
+		}
+		write = append(write, r.WriteLatencyMs)
+		sync = append(sync, r.SyncLatencyMs)
+		total = append(total, r.TotalLatencyMs)
+	}
+
+	return &IOLatencyResult{
+		WriteLatencyMs: median(write),
+		SyncLatencyMs:  median(sync),
+		TotalLatencyMs: median(total),
+		Samples:        len(total),
+		SpreadMs:       slices.Max(total) - slices.Min(total),
+	}, nil
+}
+
+// median 返回中位数（偶数个时取两中间值的平均），会对 values 原地排序
+func median(values []float64) float64 {
+	slices.Sort(values)
+	n := len(values)
+	if n%2 == 1 {
+		return values[n/2]
+	}
+	return (values[n/2-1] + values[n/2]) / 2
+}
+
+// testWriteLatencyOnce 单次写入 + fsync 延迟测量，调用方需持有 testMu
+func (d *DiskCollector) testWriteLatencyOnce() (*IOLatencyResult, error) {
 	// 生成随机数据
 	data := make([]byte, d.testSize)
-	if _, err := rand.Read(data); err != nil {
-		return nil, fmt.Errorf("生成随机数据失败: %w", err)
-	}
+	fillRandom(data)
 
-	// 创建临时文件
-	tmpFile := filepath.Join(d.testDir, fmt.Sprintf("chaoleme-io-test-%d", time.Now().UnixNano()))
+	// 创建临时文件（prealloc 模式下复用持久文件）
+	tmpFile, flag, err := d.testFile("io")
+	if err != nil {
+		return nil, err
+	}
+	defer d.removeTestFile(tmpFile)
 
 	// 测试写入
 	writeStart := time.Now()
-	file, err := os.OpenFile(tmpFile, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	file, err := os.OpenFile(tmpFile, flag, 0600)
 	if err != nil {
 		return nil, fmt.Errorf("创建测试文件失败: %w", err)
 	}
 
-	_, err = file.Write(data)
+	_, err = file.WriteAt(data, 0)
 	if err != nil {
 		file.Close()
-		os.Remove(tmpFile)
 		return nil, fmt.Errorf("写入测试数据失败: %w", err)
 	}
 	writeLatency := time.Since(writeStart)
@@ -117,7 +389,6 @@ func (d *DiskCollector) TestWriteLatency() (*IOLatencyResult, error) {
 	syncLatency := time.Since(syncStart)
 
 	file.Close()
-	os.Remove(tmpFile)
 
 	if err != nil {
 		return nil, fmt.Errorf("fsync 失败: %w", err)
@@ -130,6 +401,96 @@ func (d *DiskCollector) TestWriteLatency() (*IOLatencyResult, error) {
 	}, nil
 }
 
+// syncProbeRounds 落盘探测的计时轮数（另有 1 轮预热，吸收首次分配块与元数据更新）
+const syncProbeRounds = 8
+
+// syncProbeBlock 落盘探测每轮覆写的数据块大小
+const syncProbeBlock = 4096
+
+// SyncProbeResult 落盘探测结果
+type SyncProbeResult struct {
+	MedianMs float64 // 4KB 覆写后 fdatasync 耗时的中位数（毫秒）
+	MinMs    float64 // 最短一次
+	Rounds   int
+}
+
+// TestSyncDurability 反复原地覆写 4KB 并 fdatasync，测量单次刷盘的真实耗时
+// 真正落盘至少需要一次设备写入往返（HDD 为毫秒级）；耗时远低于存储类别的物理下限时，
+// 说明 fsync 被宿主机写缓存直接确认（如 cache=unsafe），断电可能丢失已"同步"的数据
+func (d *DiskCollector) TestSyncDurability() (*SyncProbeResult, error) {
+	d.testMu.Lock()
+	defer d.testMu.Unlock()
+
+	tmpFile, flag, err := d.testFile("sync")
+	if err != nil {
+		return nil, err
+	}
+	defer d.removeTestFile(tmpFile)
+
+	file, err := os.OpenFile(tmpFile, flag, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("创建测试文件失败: %w", err)
+	}
+	defer file.Close()
+
+	block := make([]byte, syncProbeBlock)
+	latencies := make([]float64, 0, syncProbeRounds)
+	for i := 0; i <= syncProbeRounds; i++ {
+		fillRandom(block)
+		if _, err := file.WriteAt(block, 0); err != nil {
+			return nil, fmt.Errorf("写入测试数据失败: %w", err)
+		}
+		start := time.Now()
+		if err := syscall.Fdatasync(int(file.Fd())); err != nil {
+			return nil, fmt.Errorf("fdatasync 失败: %w", err)
+		}
+		if i > 0 {
+			latencies = append(latencies, float64(time.Since(start).Nanoseconds())/1e6)
+		}
+	}
+
+	slices.Sort(latencies)
+	return &SyncProbeResult{
+		MedianMs: latencies[len(latencies)/2],
+		MinMs:    latencies[0],
+		Rounds:   len(latencies),
+	}, nil
+}
+
+// CanaryWrite 向卡顿探测文件原地写入 1 字节并 fdatasync，返回耗时
+// 与 I/O 延迟测试互斥：测试进行中时跳过本次探测（ok 为 false），避免大块写入的刷盘被误计为卡顿
+func (d *DiskCollector) CanaryWrite() (latency time.Duration, ok bool, err error) {
+	if !d.testMu.TryLock() {
+		return 0, false, nil
+	}
+	defer d.testMu.Unlock()
+
+	if d.canary == nil {
+		file, err := os.OpenFile(filepath.Join(d.testDir, canaryFileName), os.O_CREATE|os.O_WRONLY, 0600)
+		if err != nil {
+			return 0, false, fmt.Errorf("打开卡顿探测文件失败: %w", err)
+		}
+		d.canary = file
+	}
+
+	start := time.Now()
+	if _, err := d.canary.WriteAt([]byte{byte(start.UnixNano())}, 0); err != nil {
+		d.closeCanary()
+		return 0, false, fmt.Errorf("写入卡顿探测文件失败: %w", err)
+	}
+	if err := syscall.Fdatasync(int(d.canary.Fd())); err != nil {
+		d.closeCanary()
+		return 0, false, fmt.Errorf("fdatasync 失败: %w", err)
+	}
+	return time.Since(start), true, nil
+}
+
+// closeCanary 关闭卡顿探测文件，下次探测时重新打开
+func (d *DiskCollector) closeCanary() {
+	d.canary.Close()
+	d.canary = nil
+}
+
 // StorageType 存储类型
 type StorageType string
 
@@ -194,24 +555,33 @@ func DetectStorageTypeByLatency(randomReadLatencyMs float64) StorageType {
 
 // DiskStats 系统级磁盘统计（从 /proc/diskstats 采集）
 type DiskStats struct {
-	ReadOps      uint64 // 读操作完成次数
-	WriteOps     uint64 // 写操作完成次数
-	ReadBytes    uint64 // 读取字节数
-	WriteBytes   uint64 // 写入字节数
-	IOTimeMs     uint64 // IO 操作耗时（毫秒）
-	WeightedIOMs uint64 // 加权 IO 耗时（反映队列深度）
+	ReadOps      uint64  // 读操作完成次数
+	WriteOps     uint64  // 写操作完成次数
+	ReadBytes    uint64  // 读取字节数
+	WriteBytes   uint64  // 写入字节数
+	IOTimeMs     uint64  // IO 操作耗时（毫秒）
+	WeightedIOMs uint64  // 加权 IO 耗时（反映队列深度）
+	ReadMerges   uint64  // 读请求合并次数
+	WriteMerges  uint64  // 写请求合并次数
+	InFlight     uint64  // 当前正在处理的 IO 数（瞬时值）
+	BusyPercent  float64 // 最繁忙设备自上次采集以来的 IO 时间占比（%），已限制在 [0, 100]
+	BusyValid    bool    // BusyPercent 是否有效（首次采集没有上次快照）
 }
 
 // CollectDiskStats 从 /proc/diskstats 采集磁盘统计
 // 开销极低：仅读取内核虚拟文件，无实际磁盘 IO
 func (d *DiskCollector) CollectDiskStats() (*DiskStats, error) {
-	data, err := os.ReadFile("/proc/diskstats")
+	path := procFile(d.procPath, "diskstats")
+	data, err := os.ReadFile(path)
 	if err != nil {
-		return nil, fmt.Errorf("读取 /proc/diskstats 失败: %w", err)
+		return nil, fmt.Errorf("读取 %s 失败: %w", path, err)
 	}
 
+	now := time.Now()
 	stats := &DiskStats{}
 	lines := strings.Split(string(data), "\n")
+	seen := make(map[string]bool)
+	ioTimes := make(map[string]uint64)
 
 	for _, line := range lines {
 		fields := strings.Fields(line)
@@ -220,32 +590,41 @@ func (d *DiskCollector) CollectDiskStats() (*DiskStats, error) {
 		}
 
 		deviceName := fields[2]
-		// 跳过分区（如 sda1, vda1）和虚拟设备
+		// 跳过分区，只统计整盘（内核总是先列出整盘再列出其分区）
+		partition := isPartition(deviceName, seen)
+		seen[deviceName] = true
+		if partition {
+			continue
+		}
+		// 跳过虚拟设备
 		if strings.HasPrefix(deviceName, "loop") ||
 			strings.HasPrefix(deviceName, "ram") ||
 			strings.HasPrefix(deviceName, "dm-") {
 			continue
 		}
-		// 跳过分区，只统计整盘
-		if len(deviceName) > 2 && deviceName[len(deviceName)-1] >= '0' && deviceName[len(deviceName)-1] <= '9' {
-			// 检查是否为分区（如 sda1, vda1, nvme0n1p1）
-			if strings.Contains(deviceName, "p") || (deviceName[len(deviceName)-2] >= 'a' && deviceName[len(deviceName)-2] <= 'z') {
-				continue
-			}
+		// 跳过用户排除的设备（如有意较慢的备份盘）
+		if d.excludeDevices[deviceName] {
+			continue
 		}
 
 		// 解析字段
 		// fields[3]: 读完成次数
+		// fields[4]: 读合并次数
 		// fields[5]: 读扇区数 (每扇区 512 字节)
 		// fields[7]: 写完成次数
+		// fields[8]: 写合并次数
 		// fields[9]: 写扇区数
+		// fields[11]: 正在处理的 IO 数
 		// fields[12]: IO 耗时 (毫秒)
 		// fields[13]: 加权 IO 耗时
 
 		readOps, _ := parseUint64(fields[3])
+		readMerges, _ := parseUint64(fields[4])
 		readSectors, _ := parseUint64(fields[5])
 		writeOps, _ := parseUint64(fields[7])
+		writeMerges, _ := parseUint64(fields[8])
 		writeSectors, _ := parseUint64(fields[9])
+		inFlight, _ := parseUint64(fields[11])
 		ioTime, _ := parseUint64(fields[12])
 		weightedIO, _ := parseUint64(fields[13])
 
@@ -254,12 +633,58 @@ func (d *DiskCollector) CollectDiskStats() (*DiskStats, error) {
 		stats.ReadBytes += readSectors * 512
 		stats.WriteBytes += writeSectors * 512
 		stats.IOTimeMs += ioTime
+		ioTimes[deviceName] = ioTime
 		stats.WeightedIOMs += weightedIO
+		stats.ReadMerges += readMerges
+		stats.WriteMerges += writeMerges
+		stats.InFlight += inFlight
 	}
 
+	stats.BusyPercent, stats.BusyValid = d.busyPercent(ioTimes, now)
 	return stats, nil
 }
 
+// busyPercent 按设备差分累计 IO 耗时，返回最繁忙设备在上次采集以来的 IO 时间占比
+// 单块盘的占比不应超过 100%，越界（时钟与内核计数不同步、计数器异常）时修正并记录日志
+func (d *DiskCollector) busyPercent(ioTimes map[string]uint64, now time.Time) (float64, bool) {
+	d.statsMu.Lock()
+	defer d.statsMu.Unlock()
+	prev, prevAt := d.prevIOTime, d.prevAt
+	d.prevIOTime, d.prevAt = ioTimes, now
+
+	elapsedMs := float64(now.Sub(prevAt).Milliseconds())
+	if prev == nil || elapsedMs <= 0 {
+		return 0, false
+	}
+	busiest, valid := 0.0, false
+	for dev, ioTime := range ioTimes {
+		last, ok := prev[dev]
+		if !ok || ioTime < last {
+			continue // 新出现的设备或计数器被重置
+		}
+		busy := clampPercent(fmt.Sprintf("磁盘 %s 繁忙度", dev), float64(ioTime-last)/elapsedMs*100)
+		busiest, valid = max(busiest, busy), true
+	}
+	return busiest, valid
+}
+
+// isPartition 判断设备是否为已出现的整盘的分区：名称为整盘名加数字（sda1、vda14），
+// 或整盘名以数字结尾时加 p 与数字（nvme0n1p1、mmcblk0p2）
+// 不能只看末尾字符：nvme0n1、mmcblk0 这类整盘本身也以数字结尾
+func isPartition(name string, disks map[string]bool) bool {
+	for disk := range disks {
+		rest, ok := strings.CutPrefix(name, disk)
+		if !ok || rest == "" {
+			continue
+		}
+		rest = strings.TrimPrefix(rest, "p")
+		if rest != "" && strings.Trim(rest, "0123456789") == "" {
+			return true
+		}
+	}
+	return false
+}
+
 // parseUint64 解析 uint64，失败返回 0
 func parseUint64(s string) (uint64, error) {
 	var v uint64
@@ -271,6 +696,24 @@ func parseUint64(s string) (uint64, error) {
 type RandomIOResult struct {
 	RandomWriteLatencyMs float64 // 4KB 随机写延迟
 	RandomReadLatencyMs  float64 // 4KB 随机读延迟
+	Samples              int     // 测量次数，多次测量时读写延迟为中位数
+	SpreadMs             float64 // 各次随机写延迟的最大值与最小值之差
+}
+
+// fillRandom 用非加密伪随机数填充测试数据
+// 只需不可压缩的字节以避开存储层压缩/去重，无需密码学随机性；
+// 避免 crypto/rand 在熵不足的小型 VPS 上阻塞，把等待熵的时间计入 I/O 延迟
+func fillRandom(buf []byte) {
+	rng := rand.New(rand.NewPCG(rand.Uint64(), uint64(time.Now().UnixNano())))
+	i := 0
+	for ; i+8 <= len(buf); i += 8 {
+		binary.LittleEndian.PutUint64(buf[i:], rng.Uint64())
+	}
+	if i < len(buf) {
+		var tail [8]byte
+		binary.LittleEndian.PutUint64(tail[:], rng.Uint64())
+		copy(buf[i:], tail[:])
+	}
 }
 
 // alignedBuffer 创建对齐的缓冲区（O_DIRECT 需要内存对齐）
@@ -286,33 +729,62 @@ func alignedBuffer(size, alignment int) []byte {
 	return buf[offset : offset+size]
 }
 
-// TestRandomIO 执行 4KB 随机读写测试
-// 使用 O_DIRECT 绕过页缓存，测量真实磁盘延迟
+// TestRandomIO 按 Samples 重复测量随机读写延迟，读写各取中位数
 func (d *DiskCollector) TestRandomIO() (*RandomIOResult, error) {
+	d.testMu.Lock()
+	defer d.testMu.Unlock()
+
+	var write, read []float64
+	for range d.samples {
+		r, err := d.testRandomIOOnce()
+		if err != nil {
+			return nil, err
+		}
+		write = append(write, r.RandomWriteLatencyMs)
+		read = append(read, r.RandomReadLatencyMs)
+	}
+
+	return &RandomIOResult{
+		RandomWriteLatencyMs: median(write),
+		RandomReadLatencyMs:  median(read),
+		Samples:              len(write),
+		SpreadMs:             slices.Max(write) - slices.Min(write),
+	}, nil
+}
+
+// testRandomIOOnce 执行 4KB 随机读写测试
+// 使用 O_DIRECT 绕过页缓存，测量真实磁盘延迟；调用方需持有 testMu
+func (d *DiskCollector) testRandomIOOnce() (*RandomIOResult, error) {
 	const blockSize = 4096 // 4KB，也是常见的磁盘扇区/页大小
 
 	// 创建对齐的写入缓冲区（O_DIRECT 需要）
 	writeData := alignedBuffer(blockSize, blockSize)
-	if _, err := rand.Read(writeData); err != nil {
-		return nil, fmt.Errorf("生成随机数据失败: %w", err)
+	fillRandom(writeData)
+
+	// 创建临时文件路径（prealloc 模式下复用持久文件，在随机的对齐偏移处覆写）
+	tmpFile, flag, err := d.testFile("random")
+	if err != nil {
+		return nil, err
 	}
+	defer d.removeTestFile(tmpFile)
 
-	// 创建临时文件路径
-	tmpFile := filepath.Join(d.testDir, fmt.Sprintf("chaoleme-random-io-%d", time.Now().UnixNano()))
-	defer os.Remove(tmpFile)
+	var offset int64
+	if d.prealloc && d.testSize >= blockSize {
+		offset = int64(rand.IntN(d.testSize/blockSize)) * blockSize
+	}
 
 	// ========== 测试随机写入（使用 O_DIRECT） ==========
 	writeStart := time.Now()
-	writeFile, err := os.OpenFile(tmpFile, os.O_CREATE|os.O_WRONLY|os.O_TRUNC|syscall.O_DIRECT, 0600)
+	writeFile, err := os.OpenFile(tmpFile, flag|syscall.O_DIRECT, 0600)
 	if err != nil {
 		// O_DIRECT 不支持时，回退到普通模式
-		writeFile, err = os.OpenFile(tmpFile, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+		writeFile, err = os.OpenFile(tmpFile, flag, 0600)
 		if err != nil {
 			return nil, fmt.Errorf("创建测试文件失败: %w", err)
 		}
 	}
 
-	_, err = writeFile.Write(writeData)
+	_, err = writeFile.WriteAt(writeData, offset)
 	if err != nil {
 		writeFile.Close()
 		return nil, fmt.Errorf("写入测试数据失败: %w", err)
@@ -340,7 +812,7 @@ func (d *DiskCollector) TestRandomIO() (*RandomIOResult, error) {
 		}
 	}
 
-	_, err = readFile.Read(readData)
+	_, err = readFile.ReadAt(readData, offset)
 	readLatency := time.Since(readStart)
 	readFile.Close()
 
@@ -0,0 +1,104 @@
+package collector
+
+import "testing"
+
+func TestCollectDiskStats(t *testing.T) {
+	tests := []struct {
+		name     string
+		procPath string
+		exclude  map[string]bool
+		want     DiskStats
+	}{
+		{
+			// 统计 vda、vdb、nvme0n1 三块整盘；vda1/vda14/vda15、nvme0n1p1 为分区，loop0 为虚拟设备
+			name:     "kvm",
+			procPath: fixtureKVM,
+			want: DiskStats{
+				ReadOps: 1453246, WriteOps: 3242367,
+				ReadBytes: 100533296 * 512, WriteBytes: 189636400 * 512,
+				IOTimeMs: 2197357, WeightedIOMs: 10826058,
+				ReadMerges: 31356, WriteMerges: 1824024, InFlight: 3,
+			},
+		},
+		{
+			name:     "kvm exclude vdb",
+			procPath: fixtureKVM,
+			exclude:  map[string]bool{"vdb": true},
+			want: DiskStats{
+				ReadOps: 1433123, WriteOps: 3232344,
+				ReadBytes: 98923456 * 512, WriteBytes: 188834560 * 512,
+				IOTimeMs: 2146123, WeightedIOMs: 10755812,
+				ReadMerges: 31344, WriteMerges: 1823612, InFlight: 2,
+			},
+		},
+		{
+			// 4.18 之前只有 11 个统计字段（无 discard/flush）；dm-* 为 LVM 映射设备，不重复计入
+			name:     "centos7",
+			procPath: fixtureCentOS7,
+			want: DiskStats{
+				ReadOps: 81234, WriteOps: 123456,
+				ReadBytes: 3123456 * 512, WriteBytes: 4567890 * 512,
+				IOTimeMs: 212345, WeightedIOMs: 903456,
+				ReadMerges: 2345, WriteMerges: 98765,
+			},
+		},
+	}
+	for _, tt := range tests {
+		d := &DiskCollector{procPath: tt.procPath, excludeDevices: tt.exclude}
+		stats, err := d.CollectDiskStats()
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if *stats != tt.want {
+			t.Errorf("%s: got %+v, want %+v", tt.name, *stats, tt.want)
+		}
+	}
+}
+
+func TestIsPartition(t *testing.T) {
+	disks := map[string]bool{"sda": true, "vda": true, "nvme0n1": true, "mmcblk0": true}
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"sda1", true},
+		{"vda15", true},
+		{"nvme0n1p1", true},
+		{"mmcblk0p2", true},
+		{"sdaa", false},
+		{"nvme0n2", false},
+		{"sdb1", false}, // 整盘尚未出现
+	}
+	for _, tt := range tests {
+		if got := isPartition(tt.name, disks); got != tt.want {
+			t.Errorf("isPartition(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestMountOf(t *testing.T) {
+	tests := []struct {
+		procPath, path     string
+		mountPoint, fsType string
+	}{
+		{fixtureKVM, "/data/chaoleme", "/data", "xfs"},
+		{fixtureKVM, "/tmp", "/tmp", "tmpfs"},
+		{fixtureKVM, "/var/tmp", "/", "ext4"},
+		{fixtureKVM, "/boot/efi/EFI", "/boot/efi", "vfat"},
+		// rootfs 与真实根文件系统同为 /，取后出现的一行
+		{fixtureCentOS7, "/var/lib", "/", "xfs"},
+		{fixtureCentOS7, "/mnt/nfs/share", "/mnt/nfs", "nfs4"},
+	}
+	for _, tt := range tests {
+		mp, fs := mountOf(tt.procPath, tt.path)
+		if mp != tt.mountPoint || fs != tt.fsType {
+			t.Errorf("mountOf(%s, %s) = %s %s, want %s %s", tt.procPath, tt.path, mp, fs, tt.mountPoint, tt.fsType)
+		}
+	}
+	if !isTmpfs(fixtureKVM, "/tmp/x") || isTmpfs(fixtureKVM, "/data") {
+		t.Error("isTmpfs misclassified fixture mounts")
+	}
+	if !isExcludedMount(fixtureKVM, "/data/x", []string{"/data/"}) {
+		t.Error("isExcludedMount should match cleaned mount point")
+	}
+}
@@ -0,0 +1,62 @@
+package collector
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// OnlineCPUResult vCPU 在线数量采集结果
+type OnlineCPUResult struct {
+	Online     int // 当前在线的 CPU 数量
+	Configured int // 系统配置（present）的 CPU 数量，读取失败时为 0
+}
+
+// CollectOnlineCPUs 读取 /sys/devices/system/cpu/online 与 present
+// 部分宿主机在资源争抢时会通过 CPU 热插拔下线 vCPU，在线数量变化本身即是超售信号
+func CollectOnlineCPUs() (*OnlineCPUResult, error) {
+	online, err := readCPUList("/sys/devices/system/cpu/online")
+	if err != nil {
+		return nil, err
+	}
+	// present 仅作参考，读取失败不影响在线数量
+	configured, _ := readCPUList("/sys/devices/system/cpu/present")
+	return &OnlineCPUResult{Online: online, Configured: configured}, nil
+}
+
+// readCPUList 读取 CPU 列表文件并返回 CPU 数量
+func readCPUList(path string) (int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, fmt.Errorf("无法读取 %s: %w", path, err)
+	}
+	n, err := parseCPUList(strings.TrimSpace(string(data)))
+	if err != nil {
+		return 0, fmt.Errorf("解析 %s 失败: %w", path, err)
+	}
+	return n, nil
+}
+
+// parseCPUList 解析内核 CPU 列表格式（如 "0-3,6,8-9"）并返回 CPU 数量
+func parseCPUList(list string) (int, error) {
+	if list == "" {
+		return 0, nil
+	}
+	count := 0
+	for _, part := range strings.Split(list, ",") {
+		lo, hi, isRange := strings.Cut(part, "-")
+		start, err := strconv.Atoi(lo)
+		if err != nil {
+			return 0, fmt.Errorf("无效的 CPU 编号: %s", part)
+		}
+		end := start
+		if isRange {
+			if end, err = strconv.Atoi(hi); err != nil || end < start {
+				return 0, fmt.Errorf("无效的 CPU 范围: %s", part)
+			}
+		}
+		count += end - start + 1
+	}
+	return count, nil
+}
@@ -13,23 +13,43 @@ type LoadResult struct {
 	Load1  float64 // 1 分钟平均负载
 	Load5  float64 // 5 分钟平均负载
 	Load15 float64 // 15 分钟平均负载
+
+	// 第 4 列 "running/total"：当前可运行的调度实体数与总数（读取不到时为 0）
+	// Running 已扣除读取 /proc/loadavg 的本进程自身
+	Running int
+	Total   int
+
+	Raw string // 原始 loadavg 内容，用于原始快照与事后回放
+}
+
+// RunQueuePerCPU 每个在线 vCPU 的可运行进程数，作为没有 PSI 的老内核上的运行队列压力近似
+func (r *LoadResult) RunQueuePerCPU(onlineCPUs float64) float64 {
+	if onlineCPUs <= 0 {
+		return 0
+	}
+	return float64(r.Running) / onlineCPUs
 }
 
 // CollectLoadAverage 采集系统 Load Average
-// 读取 /proc/loadavg 获取负载信息
-func CollectLoadAverage() (*LoadResult, error) {
-	file, err := os.Open("/proc/loadavg")
+// 读取 <procPath>/loadavg 获取负载信息，procPath 通常为 DefaultProcPath
+func CollectLoadAverage(procPath string) (*LoadResult, error) {
+	path := procFile(procPath, "loadavg")
+	file, err := os.Open(path)
 	if err != nil {
-		return nil, fmt.Errorf("无法打开 /proc/loadavg: %w", err)
+		return nil, fmt.Errorf("无法打开 %s: %w", path, err)
 	}
 	defer file.Close()
 
 	scanner := bufio.NewScanner(file)
 	if !scanner.Scan() {
-		return nil, fmt.Errorf("读取 /proc/loadavg 失败")
+		return nil, fmt.Errorf("读取 %s 失败", path)
 	}
 
-	line := scanner.Text()
+	return ParseLoadAvg(scanner.Text())
+}
+
+// ParseLoadAvg 解析 /proc/loadavg 的内容（"0.12 0.08 0.05 1/123 4567"）
+func ParseLoadAvg(line string) (*LoadResult, error) {
 	fields := strings.Fields(line)
 	if len(fields) < 3 {
 		return nil, fmt.Errorf("loadavg 格式错误: %s", line)
@@ -50,9 +70,24 @@ func CollectLoadAverage() (*LoadResult, error) {
 		return nil, fmt.Errorf("解析 load15 失败: %w", err)
 	}
 
-	return &LoadResult{
+	result := &LoadResult{
 		Load1:  load1,
 		Load5:  load5,
 		Load15: load15,
-	}, nil
+		Raw:    line,
+	}
+
+	// 解析 running/total（格式异常时忽略，不影响负载值）
+	if len(fields) >= 4 {
+		if running, total, ok := strings.Cut(fields[3], "/"); ok {
+			r, errR := strconv.Atoi(running)
+			t, errT := strconv.Atoi(total)
+			if errR == nil && errT == nil {
+				result.Running = max(r-1, 0) // 读取时本进程处于运行态，计数包含自身
+				result.Total = t
+			}
+		}
+	}
+
+	return result, nil
 }
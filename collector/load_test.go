@@ -0,0 +1,37 @@
+package collector
+
+import "testing"
+
+func TestCollectLoadAverage(t *testing.T) {
+	tests := []struct {
+		procPath             string
+		load1, load5, load15 float64
+		running, total       int
+	}{
+		// running 扣除读取 loadavg 的本进程自身
+		{fixtureKVM, 0.42, 0.31, 0.25, 2, 187},
+		{fixtureCentOS7, 1.05, 0.87, 0.66, 0, 112},
+	}
+	for _, tt := range tests {
+		load, err := CollectLoadAverage(tt.procPath)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.procPath, err)
+		}
+		if load.Load1 != tt.load1 || load.Load5 != tt.load5 || load.Load15 != tt.load15 || load.Running != tt.running || load.Total != tt.total {
+			t.Errorf("%s: got %+v", tt.procPath, load)
+		}
+	}
+}
+
+func TestParseLoadAvgMalformed(t *testing.T) {
+	if _, err := ParseLoadAvg("0.1 0.2"); err == nil {
+		t.Fatal("expected error for truncated loadavg")
+	}
+	load, err := ParseLoadAvg("0.10 0.20 0.30 garbage 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if load.Running != 0 || load.Total != 0 {
+		t.Errorf("malformed running/total should be ignored, got %+v", load)
+	}
+}
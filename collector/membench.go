@@ -0,0 +1,47 @@
+package collector
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+	"time"
+)
+
+// MemoryBenchResult 内存缺页延迟测试结果
+type MemoryBenchResult struct {
+	DurationMs float64 // 缓冲区全部页面完成缺页的耗时（毫秒）
+	SizeMB     int     // 缓冲区大小（MB）
+	Pages      int     // 触发缺页的页数
+}
+
+// BenchEnabled 是否启用缺页延迟测试
+func (c *MemoryCollector) BenchEnabled() bool {
+	return c.benchSize > 0
+}
+
+// RunFaultBenchmark 分配固定大小的匿名内存并逐页写入，测量全部页面完成缺页的耗时
+// 使用 mmap 而非 Go 堆，保证每次都是全新的页面（堆内存可能复用已缺页的区域）。
+// 宿主机内存气球、内存压缩或超售时，缺页需要宿主机分配物理页，耗时升高且波动变大，
+// 而可用率往往保持稳定，这是可用率指标无法反映的信号
+func (c *MemoryCollector) RunFaultBenchmark() (*MemoryBenchResult, error) {
+	buf, err := syscall.Mmap(-1, 0, c.benchSize, syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_ANON|syscall.MAP_PRIVATE)
+	if err != nil {
+		return nil, fmt.Errorf("分配测试内存失败: %w", err)
+	}
+	defer syscall.Munmap(buf)
+
+	pageSize := os.Getpagesize()
+	pages := 0
+	start := time.Now()
+	for i := 0; i < len(buf); i += pageSize {
+		buf[i] = 1
+		pages++
+	}
+	duration := time.Since(start)
+
+	return &MemoryBenchResult{
+		DurationMs: float64(duration.Microseconds()) / 1000.0,
+		SizeMB:     c.benchSize / 1024 / 1024,
+		Pages:      pages,
+	}, nil
+}
@@ -15,6 +15,8 @@ type MemoryStats struct {
 	MemAvailable uint64 // 可用内存（KB）
 	Buffers      uint64 // 缓冲区（KB）
 	Cached       uint64 // 缓存（KB）
+	Shmem        uint64 // 共享内存/tmpfs（KB），计入 Cached 但无法回收
+	SReclaimable uint64 // 可回收的 slab（KB）
 	SwapTotal    uint64 // 总交换空间（KB）
 	SwapFree     uint64 // 空闲交换空间（KB）
 }
@@ -24,8 +26,8 @@ func (m *MemoryStats) UsagePercent() float64 {
 	if m.MemTotal == 0 {
 		return 0
 	}
-	used := m.MemTotal - m.MemAvailable
-	return float64(used) / float64(m.MemTotal) * 100
+	used := float64(m.MemTotal) - float64(m.MemAvailable)
+	return clampPercent("内存使用率", used/float64(m.MemTotal)*100)
 }
 
 // AvailablePercent 计算内存可用率
@@ -33,7 +35,7 @@ func (m *MemoryStats) AvailablePercent() float64 {
 	if m.MemTotal == 0 {
 		return 0
 	}
-	return float64(m.MemAvailable) / float64(m.MemTotal) * 100
+	return clampPercent("内存可用率", float64(m.MemAvailable)/float64(m.MemTotal)*100)
 }
 
 // SwapUsagePercent 计算交换空间使用率
@@ -41,23 +43,28 @@ func (m *MemoryStats) SwapUsagePercent() float64 {
 	if m.SwapTotal == 0 {
 		return 0
 	}
-	used := m.SwapTotal - m.SwapFree
-	return float64(used) / float64(m.SwapTotal) * 100
+	used := float64(m.SwapTotal) - float64(m.SwapFree)
+	return clampPercent("Swap 使用率", used/float64(m.SwapTotal)*100)
 }
 
 // MemoryCollector 内存采集器
-type MemoryCollector struct{}
+type MemoryCollector struct {
+	procPath  string
+	benchSize int // 缺页延迟测试的缓冲区大小（字节），0 表示不测试
+}
 
-// NewMemoryCollector 创建内存采集器
-func NewMemoryCollector() *MemoryCollector {
-	return &MemoryCollector{}
+// NewMemoryCollector 创建内存采集器，procPath 为 procfs 根目录（通常为 DefaultProcPath），
+// benchSizeMB 为缺页延迟测试的缓冲区大小（MB），0 表示不测试
+func NewMemoryCollector(procPath string, benchSizeMB int) *MemoryCollector {
+	return &MemoryCollector{procPath: procPath, benchSize: benchSizeMB * 1024 * 1024}
 }
 
 // Collect 采集内存统计
 func (c *MemoryCollector) Collect() (*MemoryStats, error) {
-	file, err := os.Open("/proc/meminfo")
+	path := procFile(c.procPath, "meminfo")
+	file, err := os.Open(path)
 	if err != nil {
-		return nil, fmt.Errorf("无法打开 /proc/meminfo: %w", err)
+		return nil, fmt.Errorf("无法打开 %s: %w", path, err)
 	}
 	defer file.Close()
 
@@ -72,8 +79,8 @@ func (c *MemoryCollector) Collect() (*MemoryStats, error) {
 		}
 
 		key := strings.TrimSuffix(fields[0], ":")
-		value, err := strconv.ParseUint(fields[1], 10, 64)
-		if err != nil {
+		value, ok := parseMeminfoValue(fields[1:])
+		if !ok {
 			continue
 		}
 
@@ -88,6 +95,10 @@ func (c *MemoryCollector) Collect() (*MemoryStats, error) {
 			stats.Buffers = value
 		case "Cached":
 			stats.Cached = value
+		case "Shmem":
+			stats.Shmem = value
+		case "SReclaimable":
+			stats.SReclaimable = value
 		case "SwapTotal":
 			stats.SwapTotal = value
 		case "SwapFree":
@@ -96,13 +107,59 @@ func (c *MemoryCollector) Collect() (*MemoryStats, error) {
 	}
 
 	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("读取 /proc/meminfo 失败: %w", err)
+		return nil, fmt.Errorf("读取 %s 失败: %w", path, err)
 	}
 
 	// 如果 MemAvailable 不存在（老内核），估算它
 	if stats.MemAvailable == 0 {
-		stats.MemAvailable = stats.MemFree + stats.Buffers + stats.Cached
+		stats.MemAvailable = estimateAvailable(stats)
 	}
 
 	return stats, nil
 }
+
+// parseMeminfoValue 解析 /proc/meminfo 的数值与单位，统一换算为 KB
+// 无单位的行按 KB 处理（与内核惯例一致），无法识别的单位返回 false
+func parseMeminfoValue(fields []string) (uint64, bool) {
+	if len(fields) == 0 {
+		return 0, false
+	}
+	value, err := strconv.ParseUint(fields[0], 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	if len(fields) < 2 {
+		return value, true
+	}
+
+	switch strings.ToLower(fields[1]) {
+	case "b":
+		return value / 1024, true
+	case "kb":
+		return value, true
+	case "mb":
+		return value * 1024, true
+	case "gb":
+		return value * 1024 * 1024, true
+	default:
+		return 0, false
+	}
+}
+
+// estimateAvailable 在缺少 MemAvailable 的老内核上估算可用内存
+// 参照内核算法：Shmem 虽计入 Cached 但不可回收，需扣除；
+// slab 中只有 SReclaimable 可回收，且回收并不彻底，按一半计入
+func estimateAvailable(m *MemoryStats) uint64 {
+	cache := m.Cached
+	if m.Shmem < cache {
+		cache -= m.Shmem
+	} else {
+		cache = 0
+	}
+
+	available := m.MemFree + m.Buffers + cache + m.SReclaimable/2
+	if m.MemTotal > 0 && available > m.MemTotal {
+		available = m.MemTotal
+	}
+	return available
+}
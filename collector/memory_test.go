@@ -0,0 +1,24 @@
+package collector
+
+import "testing"
+
+func TestMemoryCollect(t *testing.T) {
+	tests := []struct {
+		procPath            string
+		total, available    uint64
+		swapTotal, swapFree uint64
+	}{
+		{fixtureKVM, 2014584, 1203876, 1048572, 786428},
+		// 3.10 内核没有 MemAvailable，按 MemFree + Buffers + (Cached - Shmem) + SReclaimable/2 估算
+		{fixtureCentOS7, 1016548, 756752, 0, 0},
+	}
+	for _, tt := range tests {
+		stats, err := NewMemoryCollector(tt.procPath, 0).Collect()
+		if err != nil {
+			t.Fatalf("%s: %v", tt.procPath, err)
+		}
+		if stats.MemTotal != tt.total || stats.MemAvailable != tt.available || stats.SwapTotal != tt.swapTotal || stats.SwapFree != tt.swapFree {
+			t.Errorf("%s: got %+v", tt.procPath, stats)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package collector
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// 网卡选择模式（collect.network_interface）
+const (
+	NetworkInterfaceAuto = "auto" // 默认路由所在网卡
+	NetworkInterfaceAll  = "all"  // 除 lo 外所有网卡之和
+)
+
+// NetworkStats 网卡累计收发字节数（从 /proc/net/dev 采集）
+type NetworkStats struct {
+	Interface string // 实际统计的网卡（all 模式为 "all"）
+	RxBytes   uint64
+	TxBytes   uint64
+}
+
+// NetworkCollector 网络流量采集器
+type NetworkCollector struct {
+	procPath string
+	iface    string
+}
+
+// NewNetworkCollector 创建网络采集器
+// iface 为网卡名、NetworkInterfaceAuto 或 NetworkInterfaceAll；多网卡/VPN 机器上
+// 汇总所有网卡会混入内网与隧道流量，auto 模式只统计承载默认路由（即服务商带宽）的网卡
+func NewNetworkCollector(procPath, iface string) *NetworkCollector {
+	if iface == "" {
+		iface = NetworkInterfaceAuto
+	}
+	return &NetworkCollector{procPath: procPath, iface: iface}
+}
+
+// Collect 采集所选网卡的累计收发字节数
+// auto 模式每次重新读取路由表，默认路由切换网卡后自动跟随
+func (c *NetworkCollector) Collect() (*NetworkStats, error) {
+	iface := c.iface
+	if iface == NetworkInterfaceAuto {
+		route, err := DefaultRouteInterface(c.procPath)
+		if err != nil {
+			return nil, err
+		}
+		iface = route
+	}
+
+	counters, err := readNetDev(c.procPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if iface == NetworkInterfaceAll {
+		stats := &NetworkStats{Interface: NetworkInterfaceAll}
+		for name, s := range counters {
+			if name == "lo" {
+				continue
+			}
+			stats.RxBytes += s.RxBytes
+			stats.TxBytes += s.TxBytes
+		}
+		return stats, nil
+	}
+
+	stats, ok := counters[iface]
+	if !ok {
+		return nil, fmt.Errorf("网卡 %s 不存在", iface)
+	}
+	return stats, nil
+}
+
+// DefaultRouteInterface 读取 <procPath>/net/route，返回默认路由（目标与掩码均为 0）所在的网卡
+// 存在多条默认路由时取 metric 最小的一条
+func DefaultRouteInterface(procPath string) (string, error) {
+	path := procFile(procPath, "net/route")
+	file, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("无法打开 %s: %w", path, err)
+	}
+	defer file.Close()
+
+	best, bestMetric := "", -1
+	scanner := bufio.NewScanner(file)
+	scanner.Scan() // 跳过表头
+	for scanner.Scan() {
+		// Iface Destination Gateway Flags RefCnt Use Metric Mask ...
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 8 || fields[1] != "00000000" || fields[7] != "00000000" {
+			continue
+		}
+		metric, err := strconv.Atoi(fields[6])
+		if err != nil {
+			continue
+		}
+		if bestMetric < 0 || metric < bestMetric {
+			best, bestMetric = fields[0], metric
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", fmt.Errorf("读取 %s 失败: %w", path, err)
+	}
+	if best == "" {
+		return "", fmt.Errorf("未找到默认路由，请通过 collect.network_interface 指定网卡")
+	}
+	return best, nil
+}
+
+// readNetDev 解析 <procPath>/net/dev，返回各网卡的累计收发字节数
+func readNetDev(procPath string) (map[string]*NetworkStats, error) {
+	path := procFile(procPath, "net/dev")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取 %s 失败: %w", path, err)
+	}
+
+	counters := make(map[string]*NetworkStats)
+	for _, line := range strings.Split(string(data), "\n") {
+		// "  eth0: rx_bytes rx_packets ... (8 列接收) tx_bytes ..."
+		name, rest, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		fields := strings.Fields(rest)
+		if len(fields) < 9 {
+			continue
+		}
+		rx, err1 := strconv.ParseUint(fields[0], 10, 64)
+		tx, err2 := strconv.ParseUint(fields[8], 10, 64)
+		if err1 != nil || err2 != nil {
+			continue
+		}
+		name = strings.TrimSpace(name)
+		counters[name] = &NetworkStats{Interface: name, RxBytes: rx, TxBytes: tx}
+	}
+	return counters, nil
+}
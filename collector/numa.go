@@ -0,0 +1,66 @@
+package collector
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// numaNodePath NUMA 节点的 sysfs 目录
+const numaNodePath = "/sys/devices/system/node"
+
+// NUMAStats 各节点 numastat 计数器之和（页数，自启动以来累计）
+type NUMAStats struct {
+	Nodes     int
+	Hit       uint64 // 在期望节点上分配成功
+	Miss      uint64 // 期望其他节点但分配到了本节点
+	Foreign   uint64 // 期望本节点但分配到了其他节点
+	LocalNode uint64 // 进程运行在本节点时在本节点分配
+	OtherNode uint64 // 进程运行在其他节点时在本节点分配（远端访问）
+}
+
+// CollectNUMAStats 读取 /sys/devices/system/node/node*/numastat 并按节点求和
+// 单节点机器（绝大多数 VPS）没有跨节点访问，返回 nil, nil，调用方直接跳过。
+func CollectNUMAStats() (*NUMAStats, error) {
+	nodes, err := filepath.Glob(filepath.Join(numaNodePath, "node[0-9]*"))
+	if err != nil {
+		return nil, fmt.Errorf("枚举 NUMA 节点失败: %w", err)
+	}
+	if len(nodes) < 2 {
+		return nil, nil
+	}
+
+	stats := &NUMAStats{Nodes: len(nodes)}
+	for _, node := range nodes {
+		path := filepath.Join(node, "numastat")
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("读取 %s 失败: %w", path, err)
+		}
+		for _, line := range strings.Split(string(data), "\n") {
+			fields := strings.Fields(line)
+			if len(fields) != 2 {
+				continue
+			}
+			v, err := strconv.ParseUint(fields[1], 10, 64)
+			if err != nil {
+				continue
+			}
+			switch fields[0] {
+			case "numa_hit":
+				stats.Hit += v
+			case "numa_miss":
+				stats.Miss += v
+			case "numa_foreign":
+				stats.Foreign += v
+			case "local_node":
+				stats.LocalNode += v
+			case "other_node":
+				stats.OtherNode += v
+			}
+		}
+	}
+	return stats, nil
+}
@@ -0,0 +1,25 @@
+package collector
+
+import (
+	"log"
+	"math"
+)
+
+// clampPercent 将百分比限制在 [0, 100]
+// 正常采样不会越界；越界通常意味着计数器回退、多核统计竞争或采样窗口过短，
+// 记录日志便于排查，同时避免异常值污染评分与报告
+func clampPercent(name string, v float64) float64 {
+	if v >= 0 && v <= 100 {
+		return v
+	}
+	clamped := 0.0
+	if v > 100 {
+		clamped = 100
+	}
+	if math.IsNaN(v) {
+		log.Printf("⚠️ %s 计算结果为 NaN，已按 0 处理（采样异常）", name)
+		return 0
+	}
+	log.Printf("⚠️ %s 计算结果 %.2f%% 超出 [0, 100]，已修正为 %.0f%%（计数器回退或采样异常）", name, v, clamped)
+	return clamped
+}
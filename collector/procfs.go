@@ -0,0 +1,16 @@
+package collector
+
+import "path/filepath"
+
+// DefaultProcPath procfs 默认挂载点
+// 采集器通过构造参数接收 procfs 根目录，便于指向采样自不同内核的样本文件验证解析逻辑，
+// 也可用于容器中读取挂载到其他位置的宿主机 /proc
+const DefaultProcPath = "/proc"
+
+// procFile 返回 procfs 根目录下的文件路径，root 为空时使用 DefaultProcPath
+func procFile(root, name string) string {
+	if root == "" {
+		root = DefaultProcPath
+	}
+	return filepath.Join(root, name)
+}
@@ -0,0 +1,9 @@
+package collector
+
+import "path/filepath"
+
+// 采样自不同内核的 procfs 样本（testdata/<环境>/），作为各采集器的 procPath 使用
+var (
+	fixtureKVM     = filepath.Join("testdata", "kvm-6.1")      // Debian 12 KVM 虚拟机，virtio 与 NVMe 磁盘
+	fixtureCentOS7 = filepath.Join("testdata", "centos7-3.10") // CentOS 7 QEMU 虚拟机，无 MemAvailable，LVM 根分区
+)
@@ -0,0 +1,73 @@
+package collector
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ThermalResult CPU 温度采集结果
+type ThermalResult struct {
+	MaxCelsius float64 // 最高温度（°C）
+	Zone       string  // 取值的 thermal zone 类型（如 x86_pkg_temp）
+}
+
+// cpuThermalZoneTypes 代表 CPU 封装/核心温度的 thermal zone 类型关键字
+var cpuThermalZoneTypes = []string{"x86_pkg_temp", "coretemp", "k10temp", "cpu", "soc"}
+
+// CollectCPUTemperature 读取 /sys/class/thermal/thermal_zone*/temp 中的最高 CPU 温度
+// 优先使用 CPU 封装温度类型的 zone，没有时退回所有 zone 的最大值。
+// 大多数虚拟机不暴露 thermal zone，此时返回 nil, nil，调用方直接跳过。
+func CollectCPUTemperature() (*ThermalResult, error) {
+	zones, err := filepath.Glob("/sys/class/thermal/thermal_zone*")
+	if err != nil {
+		return nil, fmt.Errorf("枚举 thermal zone 失败: %w", err)
+	}
+
+	var cpuMax, anyMax *ThermalResult
+	for _, zone := range zones {
+		data, err := os.ReadFile(filepath.Join(zone, "temp"))
+		if err != nil {
+			continue // 部分 zone 读取会返回 EIO/ENODATA
+		}
+		milli, err := strconv.ParseFloat(strings.TrimSpace(string(data)), 64)
+		if err != nil {
+			continue
+		}
+		celsius := milli / 1000
+		if celsius <= 0 || celsius > 150 {
+			continue // 传感器未就绪或读数异常
+		}
+
+		zoneType := filepath.Base(zone)
+		if t, err := os.ReadFile(filepath.Join(zone, "type")); err == nil {
+			zoneType = strings.TrimSpace(string(t))
+		}
+		result := &ThermalResult{MaxCelsius: celsius, Zone: zoneType}
+
+		if anyMax == nil || celsius > anyMax.MaxCelsius {
+			anyMax = result
+		}
+		if isCPUThermalZone(zoneType) && (cpuMax == nil || celsius > cpuMax.MaxCelsius) {
+			cpuMax = result
+		}
+	}
+
+	if cpuMax != nil {
+		return cpuMax, nil
+	}
+	return anyMax, nil
+}
+
+// isCPUThermalZone 判断 thermal zone 类型是否代表 CPU 温度
+func isCPUThermalZone(zoneType string) bool {
+	zoneType = strings.ToLower(zoneType)
+	for _, keyword := range cpuThermalZoneTypes {
+		if strings.Contains(zoneType, keyword) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,162 @@
+package collector
+
+import (
+	"os"
+	"strings"
+)
+
+// Virtualization 虚拟化平台
+type Virtualization string
+
+const (
+	VirtNone       Virtualization = "none" // 物理机
+	VirtKVM        Virtualization = "kvm"
+	VirtXen        Virtualization = "xen"
+	VirtVMware     Virtualization = "vmware"
+	VirtHyperV     Virtualization = "hyperv"
+	VirtVirtualBox Virtualization = "virtualbox"
+	VirtOpenVZ     Virtualization = "openvz"
+	VirtLXC        Virtualization = "lxc"
+	VirtDocker     Virtualization = "docker"
+	VirtUnknown    Virtualization = "vm" // 有 hypervisor 标志但无法识别具体平台
+
+	VirtUndetermined Virtualization = "unknown" // 没有任何依据（如 ARM 的 cpuinfo 无 flags 行），不能断定是否为虚拟机
+)
+
+// virtNames 报告中展示的平台名称
+var virtNames = map[Virtualization]string{
+	VirtNone:       "物理机",
+	VirtKVM:        "KVM",
+	VirtXen:        "Xen",
+	VirtVMware:     "VMware",
+	VirtHyperV:     "Hyper-V",
+	VirtVirtualBox: "VirtualBox",
+	VirtOpenVZ:     "OpenVZ",
+	VirtLXC:        "LXC",
+	VirtDocker:     "Docker",
+	VirtUnknown:    "未知虚拟机",
+
+	VirtUndetermined: "未能识别",
+}
+
+// DisplayName 报告中展示的平台名称
+func (v Virtualization) DisplayName() string {
+	if name, ok := virtNames[v]; ok {
+		return name
+	}
+	return string(v)
+}
+
+// Container 是否为容器（与宿主机共享内核）
+// 容器内 /proc/stat 的 steal 要么恒为 0（OpenVZ 虚拟化了 /proc/stat），要么是整台宿主机的值，
+// 不能反映本实例被争抢的程度
+func (v Virtualization) Container() bool {
+	return v == VirtOpenVZ || v == VirtLXC || v == VirtDocker
+}
+
+// dmiVirtHints DMI 字段中的虚拟化厂商特征（按顺序匹配，Xen 需排在云厂商之前：早期 EC2 为 Xen）
+var dmiVirtHints = []struct {
+	substr string
+	virt   Virtualization
+}{
+	{"xen", VirtXen},
+	{"kvm", VirtKVM},
+	{"qemu", VirtKVM},
+	{"amazon ec2", VirtKVM},
+	{"google", VirtKVM},
+	{"alibaba cloud", VirtKVM},
+	{"openstack", VirtKVM},
+	{"vmware", VirtVMware},
+	{"microsoft corporation", VirtHyperV},
+	{"innotek", VirtVirtualBox},
+	{"virtualbox", VirtVirtualBox},
+}
+
+// DetectVirtualization 识别虚拟化平台，返回平台与判定依据
+// 参照 systemd-detect-virt 的顺序：先识别容器（容器内看到的 CPU 与 DMI 是宿主机的），
+// 再依次查看 /sys/hypervisor/type、DMI 厂商信息与 /proc/cpuinfo 的 hypervisor 标志和 CPU 型号
+func DetectVirtualization() (Virtualization, string) {
+	if fileExists("/proc/vz") && !fileExists("/proc/bc") {
+		return VirtOpenVZ, "/proc/vz"
+	}
+	if environ, err := os.ReadFile("/proc/1/environ"); err == nil {
+		for _, kv := range strings.Split(string(environ), "\x00") {
+			name, found := strings.CutPrefix(kv, "container=")
+			if !found {
+				continue
+			}
+			if virt, ok := containerVirt(name); ok {
+				return virt, "/proc/1/environ"
+			}
+		}
+	}
+	if name := readTrimmed("/run/systemd/container"); name != "" {
+		if virt, ok := containerVirt(name); ok {
+			return virt, "/run/systemd/container"
+		}
+	}
+	if fileExists("/.dockerenv") {
+		return VirtDocker, "/.dockerenv"
+	}
+
+	if readTrimmed("/sys/hypervisor/type") == "xen" {
+		return VirtXen, "/sys/hypervisor/type"
+	}
+
+	for _, field := range []string{"sys_vendor", "product_name", "bios_vendor"} {
+		path := "/sys/class/dmi/id/" + field
+		value := strings.ToLower(readTrimmed(path))
+		if value == "" {
+			continue
+		}
+		for _, hint := range dmiVirtHints {
+			if strings.Contains(value, hint.substr) {
+				if hint.virt == VirtHyperV && !strings.Contains(strings.ToLower(readTrimmed("/sys/class/dmi/id/product_name")), "virtual") {
+					continue // 微软的物理设备（如 Surface）
+				}
+				return hint.virt, path
+			}
+		}
+	}
+
+	info, err := ReadCPUInfo(DefaultProcPath)
+	if err != nil {
+		return VirtUnknown, "无法读取 /proc/cpuinfo"
+	}
+	if info.GenericModel() {
+		return VirtKVM, "/proc/cpuinfo 型号 " + info.ModelName
+	}
+	if info.Hypervisor {
+		return VirtUnknown, "/proc/cpuinfo hypervisor 标志"
+	}
+	if !info.HasFlags {
+		return VirtUndetermined, "/proc/cpuinfo 无 flags 行"
+	}
+	return VirtNone, "/proc/cpuinfo 无 hypervisor 标志"
+}
+
+// containerVirt 将 container= 环境变量或 /run/systemd/container 的取值映射为平台
+func containerVirt(name string) (Virtualization, bool) {
+	switch {
+	case name == "openvz":
+		return VirtOpenVZ, true
+	case strings.HasPrefix(name, "lxc"):
+		return VirtLXC, true
+	case name == "docker" || name == "podman" || name == "oci":
+		return VirtDocker, true
+	}
+	return "", false
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+func readTrimmed(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
@@ -0,0 +1,61 @@
+package collector
+
+// SlidingWindow 固定容量的滑动窗口（仅内存，用于实时显示平滑）
+type SlidingWindow struct {
+	values []float64
+	next   int
+	full   bool
+}
+
+// NewSlidingWindow 创建容量为 size 的滑动窗口（size < 1 时按 1 处理）
+func NewSlidingWindow(size int) *SlidingWindow {
+	if size < 1 {
+		size = 1
+	}
+	return &SlidingWindow{values: make([]float64, size)}
+}
+
+// Add 追加一个样本，窗口满时覆盖最旧的样本
+func (w *SlidingWindow) Add(v float64) {
+	w.values[w.next] = v
+	w.next = (w.next + 1) % len(w.values)
+	if w.next == 0 {
+		w.full = true
+	}
+}
+
+// Len 当前窗口内的样本数
+func (w *SlidingWindow) Len() int {
+	if w.full {
+		return len(w.values)
+	}
+	return w.next
+}
+
+// Avg 窗口内样本平均值
+func (w *SlidingWindow) Avg() float64 {
+	n := w.Len()
+	if n == 0 {
+		return 0
+	}
+	var sum float64
+	for _, v := range w.values[:n] {
+		sum += v
+	}
+	return sum / float64(n)
+}
+
+// Max 窗口内样本最大值
+func (w *SlidingWindow) Max() float64 {
+	n := w.Len()
+	if n == 0 {
+		return 0
+	}
+	m := w.values[0]
+	for _, v := range w.values[1:n] {
+		if v > m {
+			m = v
+		}
+	}
+	return m
+}
@@ -1,27 +1,41 @@
 package config
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
 	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/Catker/chaoleme/locale"
 	"gopkg.in/yaml.v3"
 )
 
 // Config 主配置结构
 type Config struct {
-	Hostname string         `yaml:"hostname"` // 主机标识，用于多机器推送区分（可选，未填则自动获取系统主机名）
-	Telegram TelegramConfig `yaml:"telegram"`
-	Report   ReportConfig   `yaml:"report"`
-	Storage  StorageConfig  `yaml:"storage"`
-	Collect  CollectConfig  `yaml:"collect"`
-	AI       AIConfig       `yaml:"ai"`
+	Hostname string `yaml:"hostname"` // 主机标识，用于多机器推送区分（可选，未填则自动获取系统主机名）
+	// 自由标签（如 provider、plan、region、cost），附加到报告、AI 提示词、JSON 报告与告警命令环境变量
+	Labels   map[string]string `yaml:"labels"`
+	Telegram TelegramConfig    `yaml:"telegram"`
+	Report   ReportConfig      `yaml:"report"`
+	Storage  StorageConfig     `yaml:"storage"`
+	Collect  CollectConfig     `yaml:"collect"`
+	AI       AIConfig          `yaml:"ai"`
+	Analysis AnalysisConfig    `yaml:"analysis"`
+	Fleet    FleetConfig       `yaml:"fleet"`
+	Alert    AlertConfig       `yaml:"alert"`
+	Influx   InfluxConfig      `yaml:"influx"`
 }
 
 // TelegramConfig Telegram 通知配置
 type TelegramConfig struct {
 	BotToken string `yaml:"bot_token"`
 	ChatID   string `yaml:"chat_id"`
+	ThreadID int64  `yaml:"thread_id"` // 话题（Topic）ID，开启话题的群组中发送到指定话题；0 为默认话题
 }
 
 // ReportConfig 报告配置
@@ -31,32 +45,304 @@ type ReportConfig struct {
 	Weekly     bool   `yaml:"weekly"`
 	WeeklyDay  int    `yaml:"weekly_day"` // 0=周日, 1=周一, ...
 	Monthly    bool   `yaml:"monthly"`
-	MonthlyDay int    `yaml:"monthly_day"` // 1-28
+	MonthlyDay int    `yaml:"monthly_day"`  // 1-28
+	AsDocument bool   `yaml:"as_document"`  // 以 .txt 文件附件形式发送报告（不受 4096 字符限制）
+	Compact    bool   `yaml:"compact"`      // 精简模式：每项指标压缩为单行
+	JSONDir    string `yaml:"json_dir"`     // 每次报告额外写入机器可读的 JSON 文件到该目录（可选）
+	HTMLDir    string `yaml:"html_dir"`     // 每次报告额外写入自包含的 HTML 文件到该目录（可选）
+	AIMaxChars int    `yaml:"ai_max_chars"` // AI 分析在报告中的最大字符数，超出截断，0 表示不限制
+
+	BusinessHours string `yaml:"business_hours"` // 仅用该时段内的样本评分，格式 "09:00-18:00"（可跨午夜），为空表示全天
+
+	Sections []string `yaml:"sections"` // 报告包含的指标段（见 ReportSections），为空表示全部
+
+	Locale           string `yaml:"locale"`            // 报告语言：zh-CN（默认）或 en
+	DecimalSeparator string `yaml:"decimal_separator"` // 报告数字的小数分隔符："." 或 ","，为空表示 "."
+
+	// 仅在有问题时发送：定时报告的风险等级未达到 IssueLevel 时不发送（JSON 报告照常写入）
+	OnlyOnIssue  bool   `yaml:"only_on_issue"`
+	IssueLevel   string `yaml:"issue_level"`   // 视为有问题的最低风险等级：good / medium / severe
+	AlwaysWeekly bool   `yaml:"always_weekly"` // OnlyOnIssue 开启时周报仍照常发送
+
+	// 最早样本距今不足该时长时不发送定时报告（如 "6h"），只发一次"数据采集中"提醒；为空表示不限制
+	MinHistory string `yaml:"min_history"`
+}
+
+// ReportSections 报告中可选的指标段
+var ReportSections = []string{"cpu_steal", "iowait", "io_latency", "random_io", "disk_busy", "memory", "numa", "load", "network", "baseline"}
+
+// SectionEnabled 判断报告是否包含指定指标段
+func (c *ReportConfig) SectionEnabled(section string) bool {
+	return len(c.Sections) == 0 || slices.Contains(c.Sections, section)
+}
+
+// BusinessHoursRange 解析业务时段，返回起止时刻（距零点的分钟数）；未配置时 ok 为 false
+func (c *ReportConfig) BusinessHoursRange() (from, to int, ok bool, err error) {
+	if c.BusinessHours == "" {
+		return 0, 0, false, nil
+	}
+	startStr, endStr, found := strings.Cut(c.BusinessHours, "-")
+	if !found {
+		return 0, 0, false, fmt.Errorf("格式应为 HH:MM-HH:MM: %s", c.BusinessHours)
+	}
+	start, err := time.Parse("15:04", strings.TrimSpace(startStr))
+	if err != nil {
+		return 0, 0, false, fmt.Errorf("起始时间无效: %s", startStr)
+	}
+	end, err := time.Parse("15:04", strings.TrimSpace(endStr))
+	if err != nil {
+		return 0, 0, false, fmt.Errorf("结束时间无效: %s", endStr)
+	}
+	from = start.Hour()*60 + start.Minute()
+	to = end.Hour()*60 + end.Minute()
+	if from == to {
+		return 0, 0, false, fmt.Errorf("起止时间不能相同: %s", c.BusinessHours)
+	}
+	return from, to, true, nil
 }
 
 // StorageConfig 存储配置
 type StorageConfig struct {
 	DBPath        string `yaml:"db_path"`
 	RetentionDays int    `yaml:"retention_days"`
+	// 原始样本保留时长（如 "48h"），超过后聚合为每小时一行，聚合行保留 retention_days；为空表示不聚合
+	RawRetention string `yaml:"raw_retention"`
+	// 按指标类型去重（键为指标类型，如 cpu_steal）：与上次写入值相差不超过 epsilon 时跳过写入
+	Dedup map[string]DedupRule `yaml:"dedup"`
+	// 额外保存每次采集读取的 /proc/stat cpu 行与 /proc/loadavg 原文（gzip 压缩），供 -replay 回放
+	RawSnapshots bool `yaml:"raw_snapshots"`
+	// 写入时将各指标时间戳取整到其采集间隔的边界，同一轮采集的指标共享时间戳
+	AlignTimestamps bool `yaml:"align_timestamps"`
+	// 数据库容量上限（MB），每日清理后仍超出时删除低价值数据并逐天收紧保留期；0 表示不限制
+	MaxSizeMB int `yaml:"max_size_mb"`
+
+	// 连接与日志模式：WAL 下读写互不阻塞，适合守护进程与 -serve 面板、推送等读者并存
+	WAL                   bool   `yaml:"wal"`
+	MaxOpenConns          int    `yaml:"max_open_conns"`          // 连接池最大连接数，0 表示不限制
+	MaxIdleConns          int    `yaml:"max_idle_conns"`          // 最大空闲连接数，0 表示使用默认值（2）
+	WALCheckpointInterval string `yaml:"wal_checkpoint_interval"` // 定期执行 wal_checkpoint(TRUNCATE) 的间隔，为空时只依赖 SQLite 自动检查点
+}
+
+// DedupRule 单个指标类型的去重规则
+type DedupRule struct {
+	Epsilon   float64 `yaml:"epsilon"`   // 与上次写入值之差不超过该值视为未变化
+	Heartbeat int     `yaml:"heartbeat"` // 连续未变化时每 N 个样本仍写入一次，区分"稳定"与"缺数据"
+}
+
+// dedupUnsupported 不能去重的指标类型：累计计数器靠相邻样本差分，主值之外的字段也会丢失
+var dedupUnsupported = []string{"disk_stats", "network", "numa", "schedstat"}
+
+// 采集档位（collect.profile）
+const (
+	CollectProfileMinimal  = "minimal"  // 低开销：降低测试频率与规模，跳过内存缺页测试、随机 I/O、落盘探测与卡顿探测
+	CollectProfileStandard = "standard" // 默认
+	CollectProfileThorough = "thorough" // 高精度：更密集的采样与更大的测试规模
+)
+
+// collectProfilePreset 采集档位对应的预设值
+type collectProfilePreset struct {
+	CPUStealInterval string
+	CPUBenchInterval string
+	IOTestInterval   string
+	IOTestSizeMB     int
+	MemBenchSizeMB   int
+	RandomIOTest     bool
+	SyncProbe        bool
+	Canary           bool // 为 false 时关闭卡顿探测（显式配置的 canary_interval 仍然生效）
+}
+
+// collectProfiles 各档位的预设；配置文件中显式设置的项优先于预设
+var collectProfiles = map[string]collectProfilePreset{
+	CollectProfileMinimal:  {CPUStealInterval: "10m", CPUBenchInterval: "2h", IOTestInterval: "1h", IOTestSizeMB: 1, MemBenchSizeMB: 0},
+	CollectProfileStandard: {CPUStealInterval: "5m", CPUBenchInterval: "30m", IOTestInterval: "15m", IOTestSizeMB: 4, MemBenchSizeMB: 0, RandomIOTest: true, SyncProbe: true, Canary: true},
+	CollectProfileThorough: {CPUStealInterval: "1m", CPUBenchInterval: "10m", IOTestInterval: "5m", IOTestSizeMB: 16, MemBenchSizeMB: 128, RandomIOTest: true, SyncProbe: true, Canary: true},
+}
+
+// applyProfile 以档位预设覆盖采集间隔与测试规模，未知档位不做修改（由 Validate 报错）
+func (c *CollectConfig) applyProfile(profile string) {
+	preset, ok := collectProfiles[profile]
+	if !ok {
+		return
+	}
+	c.CPUStealInterval = preset.CPUStealInterval
+	c.CPUBenchInterval = preset.CPUBenchInterval
+	c.IOTestInterval = preset.IOTestInterval
+	c.IOTestSizeMB = preset.IOTestSizeMB
+	c.MemBenchSizeMB = preset.MemBenchSizeMB
+	c.RandomIOTest = preset.RandomIOTest
+	c.SyncProbe = preset.SyncProbe
+	if !preset.Canary {
+		c.CanaryInterval = ""
+	}
 }
 
 // CollectConfig 采集配置
 type CollectConfig struct {
+	Profile          string `yaml:"profile"` // 采集档位：minimal / standard / thorough，决定采集间隔与测试规模的默认值
 	CPUStealInterval string `yaml:"cpu_steal_interval"`
 	CPUBenchInterval string `yaml:"cpu_bench_interval"`
 	IOTestInterval   string `yaml:"io_test_interval"`
 	IOTestSizeMB     int    `yaml:"io_test_size_mb"`
+	IOSamplesPerRun  int    `yaml:"io_samples_per_run"` // 每轮 I/O 测试的重复次数，存储中位数与离散度
+	TestDir          string `yaml:"test_dir"`           // I/O 测试目录（可选，设置后原样使用，跳过 tmpfs 自动规避）
+	StartupSettle    string `yaml:"startup_settle"`     // 启动后等待系统稳定再进行首次采集
+	PreallocTestFile bool   `yaml:"prealloc_test_file"` // 预分配持久测试文件并原地覆写，不再每次创建/删除
+	NetworkInterface string `yaml:"network_interface"`  // 统计流量的网卡：auto（默认路由网卡）/ all / 网卡名
+	MemBenchSizeMB   int    `yaml:"mem_bench_size_mb"`  // 内存缺页延迟测试的缓冲区大小（MB），随 CPU 基准测试执行，0 表示关闭
+	RandomIOTest     bool   `yaml:"random_io_test"`     // 随 I/O 测试执行 4KB 随机读写测试
+	SyncProbe        bool   `yaml:"sync_probe"`         // 随 I/O 测试执行落盘探测（识别未真正落盘的 fsync）
+	// 被动模式：不运行 I/O 写入测试、CPU 基准测试、内存缺页测试与卡顿探测，只读取 /proc、/sys
+	PassiveOnly bool `yaml:"passive_only"`
+	BenchCPU    int  `yaml:"bench_cpu"` // CPU 基准测试绑定的 CPU 序号，-1 表示不绑定
+
+	// 自适应 Steal 采集间隔：近期波动大时缩短、平稳时延长，限制在 [min, max] 内
+	AdaptiveInterval    bool   `yaml:"adaptive_interval"`
+	AdaptiveMinInterval string `yaml:"adaptive_min_interval"`
+	AdaptiveMaxInterval string `yaml:"adaptive_max_interval"`
+
+	// I/O 卡顿探测：按 canary_interval 原地写入 1 字节并 fdatasync，耗时超过 canary_deadline 记为一次卡顿（为空表示关闭）
+	CanaryInterval string `yaml:"canary_interval"`
+	CanaryDeadline string `yaml:"canary_deadline"`
+
+	ExcludeDevices []string `yaml:"exclude_devices"` // 不计入磁盘统计的设备（如 sdb）
+	ExcludeMounts  []string `yaml:"exclude_mounts"`  // 自动选择 I/O 测试目录时避开的挂载点
+
+	CustomCommands []CustomCommand `yaml:"custom_commands"` // 自定义指标命令
+}
+
+// CustomCommand 自定义指标命令
+// 按间隔执行 shell 命令，将 stdout 解析为浮点数，存储为 custom:<name> 指标
+type CustomCommand struct {
+	Name     string `yaml:"name"`
+	Command  string `yaml:"command"`
+	Interval string `yaml:"interval"`
+	Timeout  string `yaml:"timeout"` // 执行超时，默认 10s
+}
+
+// GetInterval 获取自定义命令执行间隔
+func (c *CustomCommand) GetInterval() time.Duration {
+	d, _ := time.ParseDuration(c.Interval)
+	return d
+}
+
+// GetTimeout 获取自定义命令执行超时
+func (c *CustomCommand) GetTimeout() time.Duration {
+	if c.Timeout == "" {
+		return 10 * time.Second
+	}
+	d, _ := time.ParseDuration(c.Timeout)
+	return d
+}
+
+// AI 服务商
+const (
+	AIProviderOpenAI    = "openai"    // OpenAI 兼容 chat/completions 格式（默认）
+	AIProviderAnthropic = "anthropic" // Anthropic Messages API
+	AIProviderOllama    = "ollama"    // Ollama /api/chat
+)
+
+// defaultAIURLs 各服务商的默认 API 地址（未配置 api_url 时使用）
+var defaultAIURLs = map[string]string{
+	AIProviderOpenAI:    "https://api.openai.com/v1/chat/completions",
+	AIProviderAnthropic: "https://api.anthropic.com/v1/messages",
+	AIProviderOllama:    "http://127.0.0.1:11434/api/chat",
 }
 
 // AIConfig AI 分析配置
 type AIConfig struct {
-	Enabled bool   `yaml:"enabled"`
-	APIURL  string `yaml:"api_url"`
-	APIKey  string `yaml:"api_key"`
-	Model   string `yaml:"model"`
-	Daily   bool   `yaml:"daily"`
-	Weekly  bool   `yaml:"weekly"`
-	Monthly bool   `yaml:"monthly"`
+	Enabled  bool   `yaml:"enabled"`
+	Provider string `yaml:"provider"` // openai / anthropic / ollama
+	APIURL   string `yaml:"api_url"`
+	APIKey   string `yaml:"api_key"`
+	Model    string `yaml:"model"`
+	Daily    bool   `yaml:"daily"`
+	Weekly   bool   `yaml:"weekly"`
+	Monthly  bool   `yaml:"monthly"`
+
+	MaxRetries       int    `yaml:"max_retries"`       // 单次分析失败后的重试次数
+	BreakerThreshold int    `yaml:"breaker_threshold"` // 连续失败多少次后熔断
+	BreakerCooldown  string `yaml:"breaker_cooldown"`  // 熔断持续时间，期间跳过 AI 调用
+	FallbackModel    string `yaml:"fallback_model"`    // 主模型重试耗尽后（如被限流）改用该模型再尝试一次，为空表示不使用
+
+	IncludeHourly  bool `yaml:"include_hourly"`   // 在 prompt 中附加小时明细表与基线变化幅度，便于分析时段规律（显著增加 token 用量）
+	MaxPromptChars int  `yaml:"max_prompt_chars"` // prompt 最大字符数，小时明细超出部分截断，0 表示不限制
+}
+
+// AnalysisConfig 分析与评分配置
+type AnalysisConfig struct {
+	IOLatencyFloorMs float64 `yaml:"io_latency_floor_ms"` // SSD/HDD 上低于此值的 I/O 延迟视为写入命中缓存，不计入统计
+	GapFactor        float64 `yaml:"gap_factor"`          // 相邻样本间隔超过采集间隔的多少倍视为数据缺失
+	MinCoverage      float64 `yaml:"min_coverage"`        // 数据覆盖率低于该百分比时报告标注数据不足
+	CPUTenancy       string  `yaml:"cpu_tenancy"`         // CPU 独享/共享判定：auto / dedicated / shared
+	ExcludeStartup   bool    `yaml:"exclude_startup"`     // 统计时排除启动后首次采集的样本
+
+	ReferenceBenchMs    float64 `yaml:"reference_bench_ms"`    // CPU 基准测试参考耗时（开通时实测或同型号公开基准），0 表示不比较
+	ReferenceMinPercent float64 `yaml:"reference_min_percent"` // 性能持续低于参考值的该百分比时告警
+
+	// 超售可信度加成：本地负载低而 Steal/IOWait 超过阈值时加重扣分
+	BoostStealThreshold  float64 `yaml:"boost_steal_threshold"`  // Steal 触发阈值（%，扣除 steal_baseline 之后）
+	BoostIoWaitThreshold float64 `yaml:"boost_iowait_threshold"` // IOWait 触发阈值（%）
+	StealBaseline        float64 `yaml:"steal_baseline"`         // 平台固有的 Steal 底噪（%），判定加成前先扣除
+
+	// 在阈值锚点之间线性插值评分的评分项（见 ScoreItems），"all" 表示全部，为空时全部按档位评分
+	SmoothScoring []string `yaml:"smooth_scoring"`
+
+	// 周期统计的近期加权半衰期（如 "24h"）：样本权重随距周期末尾的时长指数衰减，为空时等权平均
+	RecencyHalfLife string `yaml:"recency_half_life"`
+}
+
+// ScoreItems 参与综合评分的评分项
+var ScoreItems = []string{"cpu_steal", "cpu_iowait", "cpu_stability", "io_latency", "random_io", "disk_busy", "memory", "baseline"}
+
+// SmoothScoringAll smooth_scoring 中表示全部评分项的取值
+const SmoothScoringAll = "all"
+
+// SmoothScoringEnabled 判断评分项是否使用线性插值评分
+func (c *AnalysisConfig) SmoothScoringEnabled(item string) bool {
+	return slices.Contains(c.SmoothScoring, SmoothScoringAll) || slices.Contains(c.SmoothScoring, item)
+}
+
+// CPU 核心独享类型
+const (
+	CPUTenancyAuto      = "auto"
+	CPUTenancyDedicated = "dedicated"
+	CPUTenancyShared    = "shared"
+)
+
+// FleetConfig 多机告警汇总配置
+// 多台主机共享同一个 SQLite 告警队列文件，严重告警先入队，
+// 由唯一的协调者按窗口合并为一条汇总消息发送
+type FleetConfig struct {
+	Enabled     bool   `yaml:"enabled"`
+	QueuePath   string `yaml:"queue_path"`  // 共享告警队列数据库路径（所有主机需访问同一文件）
+	Coordinator bool   `yaml:"coordinator"` // 是否为汇总协调者（同一报告目标只能有一个协调者）
+	Window      string `yaml:"window"`      // 汇总窗口
+}
+
+// AlertConfig 风险等级变化告警配置
+type AlertConfig struct {
+	ExecOnTransition string `yaml:"exec_on_transition"` // 风险等级恶化越过阈值时执行的命令（/bin/sh -c，可选）
+	Threshold        string `yaml:"threshold"`          // 触发阈值：good / medium / severe
+	ExecTimeout      string `yaml:"exec_timeout"`       // 命令超时时间
+
+	EscalateAfter int    `yaml:"escalate_after"` // 连续多少个报告周期处于 escalate_level 及以上时在报告中升级提示，0 表示关闭
+	EscalateLevel string `yaml:"escalate_level"` // 升级提示的等级阈值：good / medium / severe
+
+	IOFailureAfter int `yaml:"io_failure_after"` // I/O 写入测试连续失败多少次时立即告警（磁盘故障/只读/写满），0 表示关闭
+
+	LevelHysteresis float64 `yaml:"level_hysteresis"` // 风险等级滞回分数：越过等级边界超过该分数才改变等级，0 表示关闭
+}
+
+// InfluxConfig InfluxDB 行协议推送配置
+// 每次采集后将样本追加到文件和/或 POST 到 HTTP 写入端点（InfluxDB、VictoriaMetrics 等），
+// 供 Grafana 等外部面板使用
+type InfluxConfig struct {
+	Enabled     bool   `yaml:"enabled"`
+	File        string `yaml:"file"`        // 追加写入的文件路径（可选）
+	URL         string `yaml:"url"`         // HTTP 写入端点（可选），如 http://127.0.0.1:8086/api/v2/write?org=o&bucket=b
+	Token       string `yaml:"token"`       // 以 "Authorization: Token <token>" 发送，为空时不带认证头
+	Measurement string `yaml:"measurement"` // measurement 名称，指标类型作为 metric 标签
+	Timeout     string `yaml:"timeout"`     // HTTP 请求超时时间
 }
 
 // DefaultConfig 返回默认配置
@@ -69,38 +355,144 @@ func DefaultConfig() *Config {
 			WeeklyDay:  0,
 			Monthly:    true,
 			MonthlyDay: 1,
+			AIMaxChars: 1500,
+			Locale:     locale.ZhCN,
+			IssueLevel: "medium",
+			MinHistory: "6h",
 		},
 		Storage: StorageConfig{
 			DBPath:        "/var/lib/chaoleme/data.db",
 			RetentionDays: 30,
 		},
 		Collect: CollectConfig{
+			Profile:          CollectProfileStandard,
 			CPUStealInterval: "5m",
+			StartupSettle:    "30s",
+			NetworkInterface: "auto",
 			CPUBenchInterval: "30m",
 			IOTestInterval:   "15m",
 			IOTestSizeMB:     4,
+			MemBenchSizeMB:   0,
+			RandomIOTest:     true,
+			SyncProbe:        true,
+			IOSamplesPerRun:  1,
+			BenchCPU:         -1,
+
+			AdaptiveMinInterval: "1m",
+			AdaptiveMaxInterval: "15m",
+
+			CanaryDeadline: "500ms",
 		},
 		AI: AIConfig{
-			Enabled: false,
-			APIURL:  "https://api.openai.com/v1/chat/completions",
-			Model:   "gpt-4o-mini",
-			Daily:   true,
-			Weekly:  true,
-			Monthly: true,
+			Enabled:  false,
+			Provider: AIProviderOpenAI,
+			Model:    "gpt-4o-mini",
+			Daily:    true,
+			Weekly:   true,
+			Monthly:  true,
+
+			MaxRetries:       2,
+			BreakerThreshold: 3,
+			BreakerCooldown:  "30m",
+
+			MaxPromptChars: 4000,
+		},
+		Analysis: AnalysisConfig{
+			IOLatencyFloorMs: 0.1,
+			GapFactor:        3,
+			MinCoverage:      70,
+			CPUTenancy:       CPUTenancyAuto,
+			ExcludeStartup:   true,
+
+			ReferenceMinPercent: 85,
+
+			BoostStealThreshold:  3,
+			BoostIoWaitThreshold: 5,
+		},
+		Fleet: FleetConfig{
+			Enabled:   false,
+			QueuePath: "/var/lib/chaoleme/fleet.db",
+			Window:    "5m",
+		},
+		Alert: AlertConfig{
+			Threshold:     "severe",
+			ExecTimeout:   "60s",
+			EscalateAfter: 3,
+			EscalateLevel: "medium",
+
+			IOFailureAfter: 3,
+		},
+		Influx: InfluxConfig{
+			Measurement: "chaoleme",
+			Timeout:     "10s",
 		},
 	}
 }
 
+// ErrNotFound 配置文件不存在
+var ErrNotFound = errors.New("配置文件不存在")
+
+// yamlLinePattern 匹配 yaml 错误信息中的行号（yaml.v3 不提供列号）
+var yamlLinePattern = regexp.MustCompile(`line (\d+):`)
+
+// labelKeyPattern 标签名需可直接用作环境变量与指标标签名
+var labelKeyPattern = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// describeYAMLError 在 YAML 解析错误后附上出错行的内容，便于定位
+// Tab 缩进是最常见的错误来源，出错行含 Tab 时额外提示
+func describeYAMLError(data []byte, err error) string {
+	var b strings.Builder
+	b.WriteString(strings.TrimPrefix(err.Error(), "yaml: "))
+
+	lines := strings.Split(string(data), "\n")
+	seen := make(map[int]bool)
+	for _, m := range yamlLinePattern.FindAllStringSubmatch(err.Error(), -1) {
+		n, _ := strconv.Atoi(m[1])
+		if n < 1 || n > len(lines) || seen[n] {
+			continue
+		}
+		seen[n] = true
+		line := strings.TrimRight(lines[n-1], "\r")
+		fmt.Fprintf(&b, "\n    %4d | %s", n, strings.ReplaceAll(line, "\t", "\\t"))
+		if strings.Contains(line, "\t") {
+			b.WriteString("    ← 含 Tab，YAML 只能使用空格缩进")
+		}
+	}
+	return b.String()
+}
+
 // Load 从文件加载配置
 func Load(path string) (*Config, error) {
 	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, path)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("读取配置文件失败: %w", err)
 	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil, fmt.Errorf("配置文件 %s 为空（可能写入时被截断），可删除后运行 -init 重新生成模板", path)
+	}
 
 	cfg := DefaultConfig()
+
+	// 先读取采集档位，以其预设作为默认值，再解析完整配置：显式配置的间隔与大小仍然生效
+	var probe struct {
+		Collect struct {
+			Profile string `yaml:"profile"`
+		} `yaml:"collect"`
+	}
+	if err := yaml.Unmarshal(data, &probe); err == nil && probe.Collect.Profile != "" {
+		cfg.Collect.applyProfile(probe.Collect.Profile)
+	}
+
 	if err := yaml.Unmarshal(data, cfg); err != nil {
-		return nil, fmt.Errorf("解析配置文件失败: %w", err)
+		return nil, fmt.Errorf("解析配置文件 %s 失败: %s", path, describeYAMLError(data, err))
+	}
+
+	// 未配置 api_url 时使用服务商默认地址
+	if cfg.AI.APIURL == "" {
+		cfg.AI.APIURL = defaultAIURLs[cfg.AI.Provider]
 	}
 
 	// 如果未配置 hostname，自动获取系统主机名
@@ -119,6 +511,32 @@ func Load(path string) (*Config, error) {
 	return cfg, nil
 }
 
+// redactedSecret 脱敏后的占位符
+const redactedSecret = "***"
+
+// MarshalRedacted 将生效配置（默认值与配置文件合并后）序列化为 YAML，敏感字段脱敏
+func (c *Config) MarshalRedacted() ([]byte, error) {
+	redacted := *c
+	if redacted.Telegram.BotToken != "" {
+		redacted.Telegram.BotToken = redactedSecret
+	}
+	if redacted.AI.APIKey != "" {
+		redacted.AI.APIKey = redactedSecret
+	}
+	if redacted.Influx.Token != "" {
+		redacted.Influx.Token = redactedSecret
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2) // 与 config.yaml.example 缩进一致
+	if err := enc.Encode(&redacted); err != nil {
+		return nil, fmt.Errorf("序列化配置失败: %w", err)
+	}
+	enc.Close()
+	return buf.Bytes(), nil
+}
+
 // Validate 验证配置有效性
 func (c *Config) Validate() error {
 	if c.Telegram.BotToken == "" || c.Telegram.BotToken == "YOUR_BOT_TOKEN" {
@@ -127,6 +545,15 @@ func (c *Config) Validate() error {
 	if c.Telegram.ChatID == "" || c.Telegram.ChatID == "YOUR_CHAT_ID" {
 		return fmt.Errorf("telegram.chat_id 未配置")
 	}
+	if c.Telegram.ThreadID < 0 {
+		return fmt.Errorf("telegram.thread_id 不能为负数")
+	}
+
+	for key := range c.Labels {
+		if !labelKeyPattern.MatchString(key) {
+			return fmt.Errorf("labels 的标签名无效: %q（仅允许字母、数字和下划线，且不能以数字开头）", key)
+		}
+	}
 
 	// 验证时间间隔格式
 	intervals := map[string]string{
@@ -139,6 +566,78 @@ func (c *Config) Validate() error {
 			return fmt.Errorf("%s 格式无效: %s", name, interval)
 		}
 	}
+	if c.Collect.AdaptiveInterval {
+		minInterval, err1 := time.ParseDuration(c.Collect.AdaptiveMinInterval)
+		maxInterval, err2 := time.ParseDuration(c.Collect.AdaptiveMaxInterval)
+		if err1 != nil || err2 != nil || minInterval <= 0 {
+			return fmt.Errorf("adaptive_min_interval / adaptive_max_interval 格式无效: %s / %s", c.Collect.AdaptiveMinInterval, c.Collect.AdaptiveMaxInterval)
+		}
+		if minInterval > maxInterval {
+			return fmt.Errorf("adaptive_min_interval (%s) 不能大于 adaptive_max_interval (%s)", c.Collect.AdaptiveMinInterval, c.Collect.AdaptiveMaxInterval)
+		}
+	}
+
+	if c.Collect.CanaryInterval != "" {
+		interval, err := time.ParseDuration(c.Collect.CanaryInterval)
+		if err != nil || interval < time.Second {
+			return fmt.Errorf("collect.canary_interval 格式无效或小于 1s: %s", c.Collect.CanaryInterval)
+		}
+		deadline, err := time.ParseDuration(c.Collect.CanaryDeadline)
+		if err != nil || deadline <= 0 {
+			return fmt.Errorf("collect.canary_deadline 格式无效: %s", c.Collect.CanaryDeadline)
+		}
+	}
+
+	// 验证 I/O 测试目录
+	if c.Collect.TestDir != "" {
+		info, err := os.Stat(c.Collect.TestDir)
+		if err != nil {
+			return fmt.Errorf("test_dir 不可用: %w", err)
+		}
+		if !info.IsDir() {
+			return fmt.Errorf("test_dir 不是目录: %s", c.Collect.TestDir)
+		}
+	}
+
+	// 验证自定义指标命令
+	customNames := make(map[string]bool)
+	for i, cc := range c.Collect.CustomCommands {
+		if cc.Name == "" || strings.ContainsAny(cc.Name, " \t") {
+			return fmt.Errorf("custom_commands[%d].name 为空或包含空白字符", i)
+		}
+		if customNames[cc.Name] {
+			return fmt.Errorf("custom_commands 名称重复: %s", cc.Name)
+		}
+		customNames[cc.Name] = true
+		if cc.Command == "" {
+			return fmt.Errorf("custom_commands[%s].command 未配置", cc.Name)
+		}
+		if d, err := time.ParseDuration(cc.Interval); err != nil || d <= 0 {
+			return fmt.Errorf("custom_commands[%s].interval 格式无效: %s", cc.Name, cc.Interval)
+		}
+		if cc.Timeout != "" {
+			if d, err := time.ParseDuration(cc.Timeout); err != nil || d <= 0 {
+				return fmt.Errorf("custom_commands[%s].timeout 格式无效: %s", cc.Name, cc.Timeout)
+			}
+		}
+	}
+
+	if _, ok := collectProfiles[c.Collect.Profile]; !ok {
+		return fmt.Errorf("collect.profile 无效: %s（可选 minimal / standard / thorough）", c.Collect.Profile)
+	}
+	if c.Collect.BenchCPU < -1 {
+		return fmt.Errorf("collect.bench_cpu 必须为 CPU 序号或 -1（不绑定）: %d", c.Collect.BenchCPU)
+	}
+	if c.Collect.IOSamplesPerRun < 1 || c.Collect.IOSamplesPerRun > 15 {
+		return fmt.Errorf("collect.io_samples_per_run 必须在 1-15 之间: %d", c.Collect.IOSamplesPerRun)
+	}
+	if c.Collect.MemBenchSizeMB < 0 || c.Collect.MemBenchSizeMB > 1024 {
+		return fmt.Errorf("collect.mem_bench_size_mb 必须在 0-1024 之间: %d", c.Collect.MemBenchSizeMB)
+	}
+
+	if _, err := time.ParseDuration(c.Collect.StartupSettle); err != nil {
+		return fmt.Errorf("collect.startup_settle 格式无效: %s", c.Collect.StartupSettle)
+	}
 
 	// 验证日报时间格式
 	if c.Report.Daily {
@@ -147,11 +646,183 @@ func (c *Config) Validate() error {
 		}
 	}
 
+	for _, section := range c.Report.Sections {
+		if !slices.Contains(ReportSections, section) {
+			return fmt.Errorf("report.sections 包含未知的指标段: %s（可选: %s）", section, strings.Join(ReportSections, ", "))
+		}
+	}
+	if c.Report.Locale != "" && !slices.Contains(locale.Supported, c.Report.Locale) {
+		return fmt.Errorf("report.locale 无效: %s（可选: %s）", c.Report.Locale, strings.Join(locale.Supported, ", "))
+	}
+	switch c.Report.DecimalSeparator {
+	case "", locale.DecimalPoint, locale.DecimalComma:
+	default:
+		return fmt.Errorf("report.decimal_separator 只能为 \".\" 或 \",\": %q", c.Report.DecimalSeparator)
+	}
+	switch c.Report.IssueLevel {
+	case "good", "medium", "severe":
+	default:
+		return fmt.Errorf("report.issue_level 必须是 good、medium 或 severe")
+	}
+	for _, item := range c.Analysis.SmoothScoring {
+		if item != SmoothScoringAll && !slices.Contains(ScoreItems, item) {
+			return fmt.Errorf("analysis.smooth_scoring 包含未知的评分项: %s（可选: %s 或 %s）", item, strings.Join(ScoreItems, ", "), SmoothScoringAll)
+		}
+	}
+	if _, _, _, err := c.Report.BusinessHoursRange(); err != nil {
+		return fmt.Errorf("report.business_hours %w", err)
+	}
+	if c.Report.AIMaxChars < 0 {
+		return fmt.Errorf("report.ai_max_chars 不能为负数")
+	}
+
 	// 验证 AI 配置
+	if _, ok := defaultAIURLs[c.AI.Provider]; !ok {
+		return fmt.Errorf("ai.provider 无效: %s（可选 openai/anthropic/ollama）", c.AI.Provider)
+	}
 	if c.AI.Enabled {
-		if c.AI.APIKey == "" || c.AI.APIKey == "YOUR_API_KEY" {
+		// 本地 Ollama 无需密钥
+		if c.AI.Provider != AIProviderOllama && (c.AI.APIKey == "" || c.AI.APIKey == "YOUR_API_KEY") {
 			return fmt.Errorf("ai.api_key 未配置")
 		}
+		if c.AI.APIURL == "" {
+			return fmt.Errorf("ai.api_url 未配置")
+		}
+		if c.AI.MaxRetries < 0 {
+			return fmt.Errorf("ai.max_retries 不能为负数")
+		}
+		if _, err := time.ParseDuration(c.AI.BreakerCooldown); err != nil {
+			return fmt.Errorf("ai.breaker_cooldown 格式无效: %s", c.AI.BreakerCooldown)
+		}
+		if c.AI.MaxPromptChars < 0 {
+			return fmt.Errorf("ai.max_prompt_chars 不能为负数")
+		}
+	}
+
+	if c.Analysis.RecencyHalfLife != "" {
+		if d, err := time.ParseDuration(c.Analysis.RecencyHalfLife); err != nil || d <= 0 {
+			return fmt.Errorf("analysis.recency_half_life 格式无效: %s", c.Analysis.RecencyHalfLife)
+		}
+	}
+	if c.Analysis.IOLatencyFloorMs < 0 {
+		return fmt.Errorf("analysis.io_latency_floor_ms 不能为负数")
+	}
+	if c.Analysis.GapFactor < 1 {
+		return fmt.Errorf("analysis.gap_factor 不能小于 1")
+	}
+	if c.Analysis.MinCoverage < 0 || c.Analysis.MinCoverage > 100 {
+		return fmt.Errorf("analysis.min_coverage 应在 0-100 之间")
+	}
+	if c.Analysis.ReferenceBenchMs < 0 {
+		return fmt.Errorf("analysis.reference_bench_ms 不能为负数")
+	}
+	if c.Analysis.ReferenceMinPercent < 0 || c.Analysis.ReferenceMinPercent > 100 {
+		return fmt.Errorf("analysis.reference_min_percent 应在 0-100 之间")
+	}
+	if c.Analysis.BoostStealThreshold < 0 || c.Analysis.BoostIoWaitThreshold < 0 {
+		return fmt.Errorf("analysis.boost_steal_threshold / boost_iowait_threshold 不能为负数")
+	}
+	if c.Analysis.StealBaseline < 0 || c.Analysis.StealBaseline > 100 {
+		return fmt.Errorf("analysis.steal_baseline 应在 0-100 之间")
+	}
+	switch c.Analysis.CPUTenancy {
+	case CPUTenancyAuto, CPUTenancyDedicated, CPUTenancyShared:
+	default:
+		return fmt.Errorf("analysis.cpu_tenancy 必须是 auto、dedicated 或 shared")
+	}
+
+	// 验证机群汇总配置
+	if c.Fleet.Enabled {
+		if c.Fleet.QueuePath == "" {
+			return fmt.Errorf("fleet.queue_path 未配置")
+		}
+		if _, err := time.ParseDuration(c.Fleet.Window); err != nil {
+			return fmt.Errorf("fleet.window 格式无效: %s", c.Fleet.Window)
+		}
+	}
+
+	// 验证 InfluxDB 推送配置
+	if c.Influx.Enabled {
+		if c.Influx.File == "" && c.Influx.URL == "" {
+			return fmt.Errorf("influx.file 与 influx.url 至少需要配置一个")
+		}
+		if c.Influx.URL != "" && !strings.HasPrefix(c.Influx.URL, "http://") && !strings.HasPrefix(c.Influx.URL, "https://") {
+			return fmt.Errorf("influx.url 必须以 http:// 或 https:// 开头")
+		}
+		if c.Influx.Measurement == "" {
+			return fmt.Errorf("influx.measurement 不能为空")
+		}
+		if d, err := time.ParseDuration(c.Influx.Timeout); err != nil || d <= 0 {
+			return fmt.Errorf("influx.timeout 格式无效: %s", c.Influx.Timeout)
+		}
+	}
+
+	switch c.Alert.Threshold {
+	case "good", "medium", "severe":
+	default:
+		return fmt.Errorf("alert.threshold 必须是 good、medium 或 severe")
+	}
+	if c.Storage.MaxSizeMB < 0 {
+		return fmt.Errorf("storage.max_size_mb 不能为负数")
+	}
+	if c.Storage.MaxOpenConns < 0 || c.Storage.MaxIdleConns < 0 {
+		return fmt.Errorf("storage.max_open_conns 与 storage.max_idle_conns 不能为负数")
+	}
+	if c.Storage.MaxOpenConns > 0 && c.Storage.MaxIdleConns > c.Storage.MaxOpenConns {
+		return fmt.Errorf("storage.max_idle_conns 不能大于 storage.max_open_conns")
+	}
+	if c.Storage.WALCheckpointInterval != "" {
+		if !c.Storage.WAL {
+			return fmt.Errorf("storage.wal_checkpoint_interval 需要开启 storage.wal")
+		}
+		if d, err := time.ParseDuration(c.Storage.WALCheckpointInterval); err != nil || d < time.Minute {
+			return fmt.Errorf("storage.wal_checkpoint_interval 格式无效或小于 1m: %s", c.Storage.WALCheckpointInterval)
+		}
+	}
+	if c.Report.MinHistory != "" {
+		if d, err := time.ParseDuration(c.Report.MinHistory); err != nil || d < 0 {
+			return fmt.Errorf("report.min_history 格式无效: %s", c.Report.MinHistory)
+		}
+	}
+	if c.Storage.RawRetention != "" {
+		d, err := time.ParseDuration(c.Storage.RawRetention)
+		if err != nil {
+			return fmt.Errorf("storage.raw_retention 格式无效: %s", c.Storage.RawRetention)
+		}
+		if d < time.Hour {
+			return fmt.Errorf("storage.raw_retention 不能小于 1h")
+		}
+		if d >= time.Duration(c.Storage.RetentionDays)*24*time.Hour {
+			return fmt.Errorf("storage.raw_retention 必须小于 retention_days")
+		}
+	}
+	for metricType, rule := range c.Storage.Dedup {
+		if slices.Contains(dedupUnsupported, metricType) {
+			return fmt.Errorf("storage.dedup 不支持累计计数器类型: %s", metricType)
+		}
+		if rule.Epsilon < 0 {
+			return fmt.Errorf("storage.dedup.%s.epsilon 不能为负数", metricType)
+		}
+		if rule.Heartbeat < 2 {
+			return fmt.Errorf("storage.dedup.%s.heartbeat 必须大于等于 2", metricType)
+		}
+	}
+	if _, err := time.ParseDuration(c.Alert.ExecTimeout); err != nil {
+		return fmt.Errorf("alert.exec_timeout 格式无效: %s", c.Alert.ExecTimeout)
+	}
+	if c.Alert.EscalateAfter < 0 {
+		return fmt.Errorf("alert.escalate_after 不能为负数")
+	}
+	switch c.Alert.EscalateLevel {
+	case "good", "medium", "severe":
+	default:
+		return fmt.Errorf("alert.escalate_level 必须是 good、medium 或 severe")
+	}
+	if c.Alert.IOFailureAfter < 0 {
+		return fmt.Errorf("alert.io_failure_after 不能为负数")
+	}
+	if c.Alert.LevelHysteresis < 0 || c.Alert.LevelHysteresis >= 10 {
+		return fmt.Errorf("alert.level_hysteresis 必须在 0-10 之间（不含 10）")
 	}
 
 	return nil
@@ -163,6 +834,34 @@ func (c *Config) GetCPUStealInterval() time.Duration {
 	return d
 }
 
+// GetAdaptiveIntervalBounds 获取自适应 Steal 采集间隔的上下限
+func (c *Config) GetAdaptiveIntervalBounds() (min, max time.Duration) {
+	min, _ = time.ParseDuration(c.Collect.AdaptiveMinInterval)
+	max, _ = time.ParseDuration(c.Collect.AdaptiveMaxInterval)
+	return min, max
+}
+
+// ExpectedCPUStealInterval 相邻 Steal 样本的最大预期间隔，用于判定数据缺失
+// 开启自适应间隔时平稳期会延长到上限，按上限计算，避免被误判为缺失
+func (c *Config) ExpectedCPUStealInterval() time.Duration {
+	if c.Collect.AdaptiveInterval {
+		if _, max := c.GetAdaptiveIntervalBounds(); max > c.GetCPUStealInterval() {
+			return max
+		}
+	}
+	return c.GetCPUStealInterval()
+}
+
+// GetCanary 获取 I/O 卡顿探测的间隔与判定阈值，未开启（或处于被动模式）时 interval 为 0
+func (c *Config) GetCanary() (interval, deadline time.Duration) {
+	if c.Collect.CanaryInterval == "" || c.Collect.PassiveOnly {
+		return 0, 0
+	}
+	interval, _ = time.ParseDuration(c.Collect.CanaryInterval)
+	deadline, _ = time.ParseDuration(c.Collect.CanaryDeadline)
+	return interval, deadline
+}
+
 // GetCPUBenchInterval 获取 CPU 基准测试间隔
 func (c *Config) GetCPUBenchInterval() time.Duration {
 	d, _ := time.ParseDuration(c.Collect.CPUBenchInterval)
@@ -174,3 +873,57 @@ func (c *Config) GetIOTestInterval() time.Duration {
 	d, _ := time.ParseDuration(c.Collect.IOTestInterval)
 	return d
 }
+
+// GetRawRetention 获取原始样本保留时长，0 表示不聚合
+func (c *Config) GetRawRetention() time.Duration {
+	d, _ := time.ParseDuration(c.Storage.RawRetention)
+	return d
+}
+
+// GetWALCheckpointInterval 获取定期 WAL 检查点间隔，0 表示不定期执行
+func (c *Config) GetWALCheckpointInterval() time.Duration {
+	d, _ := time.ParseDuration(c.Storage.WALCheckpointInterval)
+	return d
+}
+
+// GetMinHistory 获取发送定时报告前需要积累的最短数据时长，0 表示不限制
+func (c *Config) GetMinHistory() time.Duration {
+	d, _ := time.ParseDuration(c.Report.MinHistory)
+	return d
+}
+
+// GetRecencyHalfLife 获取周期统计的近期加权半衰期，0 表示等权平均
+func (c *Config) GetRecencyHalfLife() time.Duration {
+	d, _ := time.ParseDuration(c.Analysis.RecencyHalfLife)
+	return d
+}
+
+// GetStartupSettle 获取启动等待时间
+func (c *Config) GetStartupSettle() time.Duration {
+	d, _ := time.ParseDuration(c.Collect.StartupSettle)
+	return d
+}
+
+// GetFleetWindow 获取机群告警汇总窗口
+func (c *Config) GetFleetWindow() time.Duration {
+	d, _ := time.ParseDuration(c.Fleet.Window)
+	return d
+}
+
+// GetAlertExecTimeout 获取告警命令超时时间
+func (c *Config) GetAlertExecTimeout() time.Duration {
+	d, _ := time.ParseDuration(c.Alert.ExecTimeout)
+	return d
+}
+
+// GetTimeout 获取 InfluxDB 推送的 HTTP 请求超时时间
+func (c *InfluxConfig) GetTimeout() time.Duration {
+	d, _ := time.ParseDuration(c.Timeout)
+	return d
+}
+
+// GetAIBreakerCooldown 获取 AI 熔断持续时间
+func (c *AIConfig) GetAIBreakerCooldown() time.Duration {
+	d, _ := time.ParseDuration(c.BreakerCooldown)
+	return d
+}
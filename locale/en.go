@@ -0,0 +1,242 @@
+package locale
+
+// enMessages 英文消息目录
+// 不含文字的格式串（如 "📅 %s\n"）无需收录
+var enMessages = map[string]string{
+	// 风险描述
+	"✅ 低":                       "✅ Low",
+	"⚠️ 中等":                     "⚠️ Medium",
+	"🔴 严重":                      "🔴 Severe",
+	"🔴 高":                       "🔴 High",
+	"✅ 稳定":                      "✅ Stable",
+	"⚠️ 轻微波动":                   "⚠️ Minor fluctuation",
+	"🔴 波动严重":                    "🔴 Severe fluctuation",
+	"✅ 低 (写:%.1fms 读:%.1fms)":   "✅ Low (write: %.1fms read: %.1fms)",
+	"⚠️ 中等 (写:%.1fms 读:%.1fms)": "⚠️ Medium (write: %.1fms read: %.1fms)",
+	"🔴 严重 (写:%.1fms 读:%.1fms)":  "🔴 Severe (write: %.1fms read: %.1fms)",
+	"✅ 低 (%.1f%%)":              "✅ Low (%.1f%%)",
+	"⚠️ 中等 (%.1f%%)":            "⚠️ Medium (%.1f%%)",
+	"🔴 高 (%.1f%%)":              "🔴 High (%.1f%%)",
+	"✅ 正常":                      "✅ Normal",
+	"⚠️ 偏低":                     "⚠️ Low",
+	"🔴 不足":                      "🔴 Insufficient",
+	"空闲":                        "idle",
+	"正常":                        "normal",
+	"较高":                        "high",
+	"过载":                        "overloaded",
+	"📊 %.2f (%s) [参考值]":         "📊 %.2f (%s) [reference only]",
+	"📈 改善中":                     "📈 Improving",
+	"🔴 明显下降":                    "🔴 Significant decline",
+	"⚠️ 轻微下降":                   "⚠️ Slight decline",
+
+	// 风险等级、续费建议、置信度、核心类型
+	"✅ 优秀":               "✅ Excellent",
+	"🟢 良好":               "🟢 Good",
+	"✅ 优秀，无超售迹象":         "✅ Excellent, no sign of overselling",
+	"🟢 良好，轻微资源竞争":        "🟢 Good, minor resource contention",
+	"⚠️ 中等，存在超售可能":       "⚠️ Medium, overselling is possible",
+	"🔴 严重超售，建议更换":        "🔴 Severely oversold, consider switching",
+	"（评分在等级边界附近，沿用上次等级）": " (score is near a level boundary, previous level kept)",
+	"✅ 建议续费":             "✅ Renew",
+	"🔴 建议更换":             "🔴 Switch provider",
+	"⚠️ 继续观察":            "⚠️ Keep watching",
+	"高":                  "high",
+	"中":                  "medium",
+	"低":                  "low",
+	"疑似独享核心":             "likely dedicated cores",
+	"疑似共享核心":             "likely shared cores",
+	"未知":                 "unknown",
+
+	// 周期与时长
+	"日报":        "Daily",
+	"周报":        "Weekly",
+	"月报":        "Monthly",
+	"%d 天":      "%d days",
+	"%d 周":      "%d weeks",
+	"%d 个月":     "%d months",
+	"%d 期":      "%d periods",
+	"%d 小时":     "%d hours",
+	"%.1f 小时":   "%.1f hours",
+	"%d 分钟":     "%d minutes",
+	"📊 超了么日报":   "📊 chaoleme Daily Report",
+	"📊 超了么周报":   "📊 chaoleme Weekly Report",
+	"📊 超了么月报":   "📊 chaoleme Monthly Report",
+	"📊 超了么报告":   "📊 chaoleme Report",
+	"📊 超了么今日实时": "📊 chaoleme Today So Far",
+	"今日实时":      "Today so far",
+	"⏱️ 统计区间 00:00-%s，当天尚未结束，评分随时间变化\n": "⏱️ Window 00:00-%s, the day is not over yet and the score will change\n",
+
+	// 报告头部
+	"📶 数据覆盖率: %.0f%% (缺失 %s)\n":            "📶 Data coverage: %.0f%% (missing %s)\n",
+	"📶 数据覆盖率: %.0f%%\n":                    "📶 Data coverage: %.0f%%\n",
+	"⚠️ 数据覆盖不足，评分仅供参考\n":                   "⚠️ Insufficient data coverage, score is for reference only\n",
+	"⚠️ %s 指标已 %s 未更新（最后更新 %s），采集器可能已失效\n": "⚠️ %s has not been updated for %s (last update %s), the collector may have stopped\n",
+	"⚠️ 采集异常（相关指标样本不完整，评分可靠性下降）:\n":        "⚠️ Collection errors (samples are incomplete, score is less reliable):\n",
+	"   • %s失败 %d 次":                       "   • %s failed %d times",
+	"（约 %.0f%%）":                           " (about %.0f%%)",
+	"⏳ 均值与分位数按近期加权（半衰期 %s）\n":              "⏳ Averages and percentiles weighted toward recent data (half-life %s)\n",
+	"   • 虚拟化: %s\n":                       "   • Virtualization: %s\n",
+	"   • ⚠️ 容器环境的 Steal 为宿主机数值或恒为 0，不代表本实例被争抢，已降低其评分权重\n": "   • ⚠️ Steal inside a container is host-wide or always 0 and does not reflect contention on this instance; its score weight was reduced\n",
+	"物理机":              "bare metal",
+	"未能识别":             "undetermined",
+	"未知虚拟机":            "unknown VM",
+	"🕘 评分基于 %s 时段数据\n": "🕘 Score based on data within %s\n",
+	"⚠️ 连续 %s评分偏低，建议尽快处理\n":        "⚠️ Low score for %s in a row, action recommended\n",
+	"📋 续费建议: %s (置信度 %s)\n":        "📋 Renewal advice: %s (confidence %s)\n",
+	"   • 日评分波动: ±%.1f (%d 天)\n\n": "   • Daily score volatility: ±%.1f (%d days)\n\n",
+	"📋 %s (置信度 %s)\n":              "📋 %s (confidence %s)\n",
+	"🔍 被动模式评估：未运行 I/O 写入与 CPU 基准测试，评分仅基于 Steal/IOWait/磁盘繁忙度/内存/基线\n": "🔍 Passive mode: no I/O write or CPU benchmark tests were run, score is based on Steal/IOWait/disk busy/memory/baseline only\n",
+
+	// 采集异常的指标名
+	"CPU 采集":          "CPU collection",
+	"Load Average 采集": "Load average collection",
+	"CPU 基准测试":        "CPU benchmark",
+	"在线 vCPU 采集":      "Online vCPU collection",
+	"CPU 温度采集":        "CPU temperature collection",
+	"I/O 测试":          "I/O test",
+	"随机 I/O 测试":       "Random I/O test",
+	"内存采集":            "Memory collection",
+	"内存缺页测试":          "Memory page fault test",
+	"磁盘统计采集":          "Disk stats collection",
+	"网络流量采集":          "Network traffic collection",
+	"自定义指标 %s ":       "Custom metric %s",
+
+	// CPU
+	"🖥️ CPU 超售风险: %s\n":            "🖥️ CPU oversell risk: %s\n",
+	"   • Steal Time 平均: %.2f%%\n": "   • Steal time avg: %.2f%%\n",
+	"   • Steal Time 峰值: %.2f%%\n": "   • Steal time peak: %.2f%%\n",
+	"   • 峰值时段: %s\n":              "   • Peak hour: %s\n",
+	"   • ⚠️ 疑似突发额度耗尽，而非超售（高负载时 Steal %.1f%%，空闲时 %.1f%%，相关系数 %.2f），建议升级规格而非更换服务商\n": "   • ⚠️ Burst credits likely exhausted rather than overselling (Steal %.1f%% when busy, %.1f%% when idle, correlation %.2f), consider upgrading the plan instead of switching provider\n",
+	"   • 检测到 %d 次疑似迁移/挂起（Steal 峰值 %.1f%%，已排除）\n":                                   "   • %d suspected migrations/suspends detected (Steal peak %.1f%%, excluded)\n",
+	"   • 性能波动系数: %.3f\n":                  "   • Performance CV: %.3f\n",
+	"   • CPU 性能: 当前为参考值的 %.0f%%\n":        "   • CPU performance: %.0f%% of reference\n",
+	"   • ⚠️ 性能持续低于参考值，疑似被调度到较慢核心或宿主机降频\n": "   • ⚠️ Performance stays below reference, likely moved to slower cores or host throttling\n",
+	"   • CPU 温度: 平均 %.0f°C / 峰值 %.0f°C\n": "   • CPU temperature: avg %.0f°C / peak %.0f°C\n",
+	"   • ⚠️ 性能波动伴随高温，疑似过热降频而非邻居争抢\n":      "   • ⚠️ Fluctuation coincides with high temperature, likely thermal throttling rather than noisy neighbours\n",
+	"   • ⚠️ 检测到 vCPU 数量变化: %s\n":          "   • ⚠️ vCPU count changed: %s\n",
+	"   • 核心类型: %s（%s）\n\n":                "   • Core type: %s (%s)\n\n",
+	"⏳ CPU IOWait 风险: %s\n":                "⏳ CPU IOWait risk: %s\n",
+	"   • IOWait 平均: %.2f%%\n":             "   • IOWait avg: %.2f%%\n",
+	"   • IOWait 峰值: %.2f%%\n":             "   • IOWait peak: %.2f%%\n",
+
+	// 磁盘
+	"💾 磁盘综合: %.0f/100\n\n":                       "💾 Disk overall: %.0f/100\n\n",
+	"💾 顺序写延迟: %s\n":                              "💾 Sequential write latency: %s\n",
+	"   • 存储类型: %s（命令行指定）\n":                     "   • Storage type: %s (set on command line)\n",
+	"   • 存储类型: %s\n":                            "   • Storage type: %s\n",
+	"   • ⚠️ %d 个样本疑似命中缓存，已排除\n":                 "   • ⚠️ %d samples likely hit the cache, excluded\n",
+	"   • ⚠️ I/O 卡顿: %d 次 (>%.0fms，最长 %.0fms)\n": "   • ⚠️ I/O stalls: %d (>%.0fms, longest %.0fms)\n",
+	"   • I/O 卡顿: 0 次 (>%.0fms)\n":               "   • I/O stalls: 0 (>%.0fms)\n",
+	"测试目录位于 %s（写时复制），fsync 伴随写放大，延迟偏高不代表超售，已按 HDD 阈值评分": "Test directory is on %s (copy-on-write); fsync causes write amplification, so high latency does not imply overselling. Scored with HDD thresholds",
+	"测试目录位于 %s（网络文件系统），延迟包含网络往返，已按 HDD 阈值评分":            "Test directory is on %s (network filesystem); latency includes network round trips. Scored with HDD thresholds",
+	"🎲 随机 I/O: %s\n":                             "🎲 Random I/O: %s\n",
+	"   • 写延迟: %.2fms\n":                         "   • Write latency: %.2fms\n",
+	"   • 读延迟: %.2fms\n":                         "   • Read latency: %.2fms\n",
+	"📀 磁盘繁忙度: %s\n":                              "📀 Disk busy: %s\n",
+	"   • 平均队列深度: %.2f (IOPS %.0f，合并率 %.0f%%)\n": "   • Avg queue depth: %.2f (IOPS %.0f, merge rate %.0f%%)\n",
+	"   • ⚠️ 低 IOPS 下队列持续较深，疑似共享存储后端拥塞\n": "   • ⚠️ Deep queue at low IOPS, shared storage backend likely congested\n",
+
+	// 内存、负载、网络、基线
+	"🧠 内存状态: %s\n":                      "🧠 Memory: %s\n",
+	"   • 可用率: %.1f%%\n":                "   • Available: %.1f%%\n",
+	"   • 缺页延迟: 平均 %.2fms，CV %.3f %s\n": "   • Page fault latency: avg %.2fms, CV %.3f %s\n",
+	"   • ⚠️ 可用率充足但缺页延迟波动大，疑似宿主机内存气球或内存超售\n": "   • ⚠️ Plenty of free memory but page fault latency fluctuates, likely host ballooning or memory overselling\n",
+	"📊 CPU 负载: %s\n":                     "📊 CPU load: %s\n",
+	"   • Load1 (归一化): %.2f\n":           "   • Load1 (normalized): %.2f\n",
+	"   • 峰值 (归一化): %.2f\n":              "   • Peak (normalized): %.2f\n",
+	"   • 每核可运行进程: 平均 %.2f / P95 %.2f\n": "   • Runnable per core: avg %.2f / P95 %.2f\n",
+	"🧩 NUMA 远程访问: %s\n":                  "🧩 NUMA remote access: %s\n",
+	"   • 节点数: %d，远程分配占比: %.1f%%\n":      "   • Nodes: %d, remote allocations: %.1f%%\n",
+	"   • numa_miss 占比: %.1f%%\n\n":      "   • numa_miss ratio: %.1f%%\n\n",
+	"   • ⚠️ fsync 可能未真正落盘（4KB fsync 中位 %.3fms，低于 %s 的物理下限），断电可能丢失已提交的数据\n": "   • ⚠️ fsync may not reach stable storage (4KB fsync median %.3fms, below the physical floor for %s); committed data may be lost on power failure\n",
+	"   • 单轮离散度: 平均 %.2fms (每轮 %d 次测量)\n":                                   "   • Within-run spread: avg %.2fms (%d measurements per run)\n",
+	"   • 调度等待: 平均 %.2f%% / P95 %.2f%% (每时间片 %.3fms)\n":                     "   • Scheduler wait: avg %.2f%% / P95 %.2f%% (%.3fms per timeslice)\n",
+	"   • ⚠️ 本地负载低但调度等待高，疑似宿主机 CPU 争抢\n":                                    "   • ⚠️ Low local load but high scheduler wait, host CPU contention suspected\n",
+	"调度统计采集":                                 "Scheduler stats collection",
+	"落盘探测":                                   "Sync durability probe",
+	"NUMA 统计采集":                              "NUMA stats collection",
+	"🌐 网络流量 (%s):\n":                         "🌐 Network traffic (%s):\n",
+	"   • 平均: ↓ %.2f Mbps / ↑ %.2f Mbps\n":   "   • Avg: ↓ %.2f Mbps / ↑ %.2f Mbps\n",
+	"   • P95 (收发合计): %.2f Mbps\n\n":         "   • P95 (rx+tx): %.2f Mbps\n\n",
+	"ℹ️ 基线数据不足，暂无对比":                         "ℹ️ Not enough history for a baseline yet",
+	" (历史较短，仅供参考)":                           " (short history, indicative only)",
+	" (历史充足)":                                " (ample history)",
+	"📈 基线对比: %s\n":                           "📈 Baseline: %s\n",
+	"   • 偏离度: %.1f%%\n":                     "   • Deviation: %.1f%%\n",
+	"🕰️ 较初始状态 (%s 起 24 小时):\n":               "🕰️ Since install (24 hours from %s):\n",
+	"🔧 自定义指标:\n":                             "🔧 Custom metrics:\n",
+	"   • %s: 平均 %.2f (最小 %.2f / 最大 %.2f)\n": "   • %s: avg %.2f (min %.2f / max %.2f)\n",
+
+	// 综合评分与时段
+	"📈 综合评分: %.0f/100\n": "📈 Overall score: %.0f/100\n",
+	"📋 风险等级: %s\n":       "📋 Risk level: %s\n",
+	"🔗 一致性: %s\n":        "🔗 Consistency: %s\n",
+	"高，%d 个劣化小时中 %d 个多项指标同步劣化（%s），超售证据充分":                    "high, %[2]d of %[1]d degraded hours show several metrics degrading together (%[3]s), strong evidence of overselling",
+	"低，%d 个劣化小时基本只有单项指标异常（%s），可能为偶发噪声，结论需谨慎":                 "low, %d degraded hours mostly show a single metric (%s), possibly noise, interpret with care",
+	"中，%d 个劣化小时中 %d 个多项指标同步劣化（%s）":                           "medium, %[2]d of %[1]d degraded hours show several metrics degrading together (%[3]s)",
+	"🕐 最差时段: %02d:00-%02d:00 · Steal %.1f%% · IOWait %.1f%%": "🕐 Worst hour: %02d:00-%02d:00 · Steal %.1f%% · IOWait %.1f%%",
+	" · 写延迟 %.0fms":                        " · write latency %.0fms",
+	"\n📊 时段分析:\n":                          "\n📊 Hourly analysis:\n",
+	"   • 高负载时段: %s\n":                     "   • Busy hours: %s\n",
+	"   • 低负载时段: %s\n":                     "   • Quiet hours: %s\n",
+	"   • 工作日 Steal %.1f%% vs 周末 %.1f%%\n": "   • Weekday Steal %.1f%% vs weekend %.1f%%\n",
+	"   • 工作日 Load %.2f vs 周末 %.2f\n":      "   • Weekday load %.2f vs weekend %.2f\n",
+	"   • ⚠️ 工作日明显更差，疑似与商业业务邻居争抢资源\n": "   • ⚠️ Weekdays are noticeably worse, likely contention with business-hours neighbours\n",
+	"\n🤖 AI 分析:\n": "\n🤖 AI analysis:\n",
+
+	// 精简模式
+	"🧠 可用 %.0f%% %s":   "🧠 Free %.0f%% %s",
+	"📈 评分 %.0f/100 %s": "📈 Score %.0f/100 %s",
+	" (数据不足)":          " (insufficient data)",
+	" (%d 项指标停止更新)":    " (%d metrics stopped updating)",
+	" (%d 项指标采集失败)":    " (%d metrics failed to collect)",
+	"⚠️ 劣化仅见于单项指标，可能为偶发噪声\n": "⚠️ Degradation seen in a single metric only, possibly noise\n",
+
+	// 机群告警汇总
+	"🚨 超了么机群告警汇总":   "🚨 chaoleme fleet alert digest",
+	"共 %d 条严重告警:\n": "%d severe alerts:\n",
+
+	// 登录提示（-motd）
+	"评分 暂无（尚未生成日报）":    "score n/a (no daily report yet)",
+	"评分 %.0f/100 %s":   "score %.0f/100 %s",
+	" · 暂无采集数据":        " · no samples yet",
+	" · ⚠️ 数据已 %s 未更新": " · ⚠️ no new data for %s",
+
+	// 评分计算过程（-explain）
+	"评分计算过程 (%s, %s ~ %s)\n": "Score breakdown (%s, %s ~ %s)\n",
+	"被动模式: CPU 稳定性、顺序写延迟、随机 I/O 不参与评分，其余项权重按比例放大\n":   "Passive mode: CPU stability, sequential write latency and random I/O are not scored; other weights are scaled up proportionally\n",
+	"%s 容器: Steal 不可信，权重减半，其余项权重按比例放大\n":              "%s container: Steal is untrusted, its weight is halved and other weights are scaled up proportionally\n",
+	"超售可信度加成: ×%.2f（%s）\n\n":                          "Oversell confidence boost: ×%.2f (%s)\n\n",
+	"  聚合值: %s，区间 %s → 档位分 %.0f\n":                    "  Value: %s, band %s → band score %.0f\n",
+	"  加成: %.0f ÷ %.2f = %.1f\n":                      "  Boost: %.0f ÷ %.2f = %.1f\n",
+	"  说明: %s\n":                                      "  Note: %s\n",
+	"  贡献: %.1f × %.0f%% = %.2f\n":                    "  Contribution: %.1f × %.0f%% = %.2f\n",
+	"\n综合评分: %.1f → %s\n":                             "\nOverall score: %.1f → %s\n",
+	"等级划分: ≥90 优秀，≥70 良好，≥50 中等，<50 严重\n":             "Levels: ≥90 excellent, ≥70 good, ≥50 medium, <50 severe\n",
+	"等级滞回: 评分越过边界未超过 alert.level_hysteresis，沿用上次等级\n": "Level hysteresis: the score crossed a boundary by less than alert.level_hysteresis, keeping the previous level\n",
+	"CPU 稳定性":      "CPU stability",
+	"顺序写延迟":        "Sequential write latency",
+	"随机 I/O":       "Random I/O",
+	"磁盘繁忙度":        "Disk busy",
+	"内存":           "Memory",
+	"基线偏离":         "Baseline deviation",
+	"；":            "; ",
+	"线性插值":         "linear interpolation",
+	"均值 %.2f%%":    "avg %.2f%%",
+	"均值 %.1f%%":    "avg %.1f%%",
+	"可用 %.1f%%":    "available %.1f%%",
+	"偏离 %.1f%%":    "deviation %.1f%%",
+	"基准测试 CV %.3f": "benchmark CV %.3f",
+	"按独享核心阈值":      "dedicated-core thresholds",
+	"按 %s 阈值":      "%s thresholds",
+	"档位分 ×0.7 + 缺页延迟 CV %.3f（%s）档位分 %.0f ×0.3":   "band score ×0.7 + page-fault latency CV %.3f (%s) band score %.0f ×0.3",
+	"疑似突发额度耗尽，不加成":                               "burst credits likely exhausted, no boost",
+	"本地负载 %.2f 偏高，不加成":                           "local load %.2f is high, no boost",
+	"本地负载 %.2f 偏低且 Steal/IOWait 超过加成阈值，加成 ×%.2f": "local load %.2f is low and Steal/IOWait exceed the boost thresholds, boost ×%.2f",
+	"Steal/IOWait 未超过加成阈值，不加成":                   "Steal/IOWait below the boost thresholds, no boost",
+	"；多项指标同步劣化，加成 ×%.2f":                         "; several metrics degraded together, boost ×%.2f",
+
+	// 无数据提醒
+	"%s 周期内没有采集数据，跳过报告":                                  "%s report: no data collected in this period, skipping",
+	"⚠️ %s | 🖥️ %s\n%s 至 %s 没有采集数据，本期未生成报告，请检查采集服务是否在运行": "⚠️ %s | 🖥️ %s\nNo data collected from %s to %s, so no report was generated for this period. Please check that the collector service is running",
+}
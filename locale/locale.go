@@ -0,0 +1,80 @@
+// Package locale 报告的本地化：消息目录与数字格式
+// 报告文案以中文原文作为消息键，其他语言在目录中查找译文，缺失时回退为中文原文
+package locale
+
+import (
+	"fmt"
+	"strings"
+)
+
+// 支持的语言（report.locale）
+const (
+	ZhCN = "zh-CN"
+	En   = "en"
+)
+
+// Supported 支持的语言列表
+var Supported = []string{ZhCN, En}
+
+// 数字的小数分隔符（report.decimal_separator）
+const (
+	DecimalPoint = "."
+	DecimalComma = ","
+)
+
+// catalogs 各语言的消息目录（中文原文 → 译文），zh-CN 无需目录
+var catalogs = map[string]map[string]string{
+	En: enMessages,
+}
+
+// Locale 报告的语言与数字格式
+type Locale struct {
+	messages map[string]string
+	decimal  string
+}
+
+// New 返回指定语言的 Locale；decimal 为空时使用小数点
+// 未知语言按 zh-CN 处理（配置校验已拒绝未知值）
+func New(tag, decimal string) *Locale {
+	if decimal == "" {
+		decimal = DecimalPoint
+	}
+	return &Locale{messages: catalogs[tag], decimal: decimal}
+}
+
+// Default 默认的 zh-CN 与小数点格式
+func Default() *Locale {
+	return New(ZhCN, DecimalPoint)
+}
+
+// T 返回消息的译文，目录中没有时返回原文
+func (l *Locale) T(msg string) string {
+	if translated, ok := l.messages[msg]; ok {
+		return translated
+	}
+	return msg
+}
+
+// Sprintf 按译文格式化，浮点参数使用配置的小数分隔符
+func (l *Locale) Sprintf(format string, args ...interface{}) string {
+	if l.decimal != DecimalPoint {
+		for i, arg := range args {
+			if f, ok := arg.(float64); ok {
+				args[i] = number{value: f, decimal: l.decimal}
+			}
+		}
+	}
+	return fmt.Sprintf(l.T(format), args...)
+}
+
+// number 按原格式动词输出浮点数，再替换小数分隔符
+type number struct {
+	value   float64
+	decimal string
+}
+
+// Format 实现 fmt.Formatter
+func (n number) Format(f fmt.State, verb rune) {
+	s := fmt.Sprintf(fmt.FormatString(f, verb), n.value)
+	fmt.Fprint(f, strings.Replace(s, DecimalPoint, n.decimal, 1))
+}
@@ -1,33 +1,64 @@
 package main
 
 import (
+	_ "embed"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
+	"math"
+	"net"
+	"net/http"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
 	"github.com/Catker/chaoleme/analyzer"
 	"github.com/Catker/chaoleme/collector"
 	"github.com/Catker/chaoleme/config"
+	"github.com/Catker/chaoleme/locale"
 	"github.com/Catker/chaoleme/reporter"
 	"github.com/Catker/chaoleme/storage"
 )
 
 var (
 	configPath   = flag.String("config", "/opt/chaoleme/config/config.yaml", "配置文件路径")
+	initConfig   = flag.Bool("init", false, "在 -config 指定的路径生成配置模板")
 	validateOnly = flag.Bool("validate", false, "仅验证配置文件")
+	printConfig  = flag.Bool("print-config", false, "打印合并默认值后的生效配置（敏感字段脱敏）")
 	testTelegram = flag.Bool("test-telegram", false, "测试 Telegram 连接")
 	collectOnce  = flag.Bool("collect-once", false, "仅采集一次数据")
-	reportType   = flag.String("report", "", "立即生成报告 (daily/weekly/monthly)")
+	reportType   = flag.String("report", "", "立即生成报告 (daily/weekly/monthly，today 为今日 0 点至今的实时报告)")
+	explain      = flag.Bool("explain", false, "与 -report 一起使用：打印评分计算过程（各项聚合值、阈值区间、加成、权重与贡献），不发送报告")
+	forceStorage = flag.String("force-storage", "", "本次运行强制按指定存储类型评分 (ssd/hdd)，跳过延迟推断")
+	measureSteal = flag.Duration("measure-steal", 0, "高精度测量指定时长内的 CPU Steal（如 60s），不写入数据库")
+	watch        = flag.Duration("watch", 0, "实时显示 CPU Steal/IOWait（指定采样间隔，如 1s），不写入数据库")
+	watchWindow  = flag.Int("watch-window", 10, "实时显示的滑动平均窗口（样本数）")
+	motd         = flag.Bool("motd", false, "输出一行当前健康状态（最近日报评分与最新样本），适合登录提示/MOTD")
+	noColor      = flag.Bool("no-color", false, "-motd 输出不使用终端颜色（也可设置 NO_COLOR 环境变量）")
+	serve        = flag.String("serve", "", "启动只读 Web 面板（如 127.0.0.1:8080，或 unix:/run/chaoleme.sock 监听仅属主可访问的 Unix socket）：GET / 为页面，GET /api/status 为 JSON")
+	reportLog    = flag.Int("report-log", 0, "显示最近 N 条报告投递记录（各渠道成功/失败及原因）")
+	benchStorage = flag.Int("bench-storage", 0, "在数据库所在目录的临时库中写入 N 行合成样本，测量逐条/批量写入速率与库大小后删除")
+	benchBatch   = flag.Int("bench-batch", 50, "-bench-storage 批量写入的每批行数")
+	replay       = flag.String("replay", "", "从原始快照重新计算指标并与已存值对比（如 6h，或 \"2006-01-02 15:04,2006-01-02 18:00\"）")
 	version      = flag.Bool("version", false, "显示版本信息")
 )
 
 var Version = "1.1.0"
 
+// configTemplate -init 生成的配置模板
+//
+//go:embed config.yaml.example
+var configTemplate []byte
+
 func main() {
 	flag.Parse()
 
@@ -36,8 +67,34 @@ func main() {
 		return
 	}
 
+	// 临时高精度 Steal 测量，无需配置和数据库
+	if *measureSteal > 0 {
+		runMeasureSteal(*measureSteal)
+		return
+	}
+
+	// 实时显示，无需配置和数据库
+	if *watch > 0 {
+		runWatch(*watch, *watchWindow)
+		return
+	}
+
+	if *initConfig {
+		if err := writeConfigTemplate(*configPath); err != nil {
+			log.Fatalf("生成配置模板失败: %v", err)
+		}
+		return
+	}
+
 	// 加载配置
 	cfg, err := config.Load(*configPath)
+	if errors.Is(err, config.ErrNotFound) {
+		fmt.Fprintf(os.Stderr, "❌ 未找到配置文件: %s\n\n", *configPath)
+		fmt.Fprintf(os.Stderr, "首次使用请先生成配置模板，填写 Telegram bot_token 与 chat_id 后再启动：\n")
+		fmt.Fprintf(os.Stderr, "  chaoleme -init -config %s\n\n", *configPath)
+		fmt.Fprintf(os.Stderr, "如配置文件在其他位置，请通过 -config 指定路径\n")
+		os.Exit(1)
+	}
 	if err != nil {
 		log.Fatalf("加载配置失败: %v", err)
 	}
@@ -47,15 +104,97 @@ func main() {
 		return
 	}
 
+	if *printConfig {
+		data, err := cfg.MarshalRedacted()
+		if err != nil {
+			log.Fatalf("打印配置失败: %v", err)
+		}
+		os.Stdout.Write(data)
+		return
+	}
+
+	// 存储基准测试使用独立的临时库，不打开正式数据库
+	if *benchStorage > 0 {
+		if err := runBenchStorage(cfg, *benchStorage, *benchBatch); err != nil {
+			log.Fatalf("存储基准测试失败: %v", err)
+		}
+		return
+	}
+
 	// 初始化存储
-	store, err := storage.New(cfg.Storage.DBPath)
+	store, err := storage.New(cfg.Storage.DBPath, storageOptions(cfg))
 	if err != nil {
 		log.Fatalf("初始化存储失败: %v", err)
 	}
 	defer store.Close()
 
+	// 读取时排除异常样本：自举样本的测量窗口与其他样本不同、迁移/挂起区间的 IOWait 是一次性伪影，始终排除
+	// 须在任何读取模式（-serve、-motd、报告）之前设置
+	excluded := []string{storage.FlagBootstrap, storage.FlagSuspend}
+	if cfg.Analysis.ExcludeStartup {
+		excluded = append(excluded, storage.FlagStartup)
+	}
+	store.ExcludeFlagged(excluded...)
+
+	// 采集写入经由守卫，数据库只读/磁盘满时降级而非刷屏报错
+	sink := storage.NewWriteGuard(store)
+	for metricType, rule := range cfg.Storage.Dedup {
+		sink.EnableDedup(storage.MetricType(metricType), rule.Epsilon, rule.Heartbeat)
+	}
+	if cfg.Storage.RawSnapshots {
+		sink.EnableSnapshots()
+	}
+	if cfg.Storage.AlignTimestamps {
+		enableAlignment(cfg, sink)
+	}
+	if cfg.Influx.Enabled {
+		exporter := reporter.NewInfluxExporter(&cfg.Influx, cfg.Hostname)
+		defer exporter.Close()
+		sink.EnableExport(exporter.Export)
+	}
+
+	if *explain && *reportType == "" {
+		log.Fatal("-explain 需要与 -report 一起使用")
+	}
+
+	if *replay != "" {
+		if err := runReplay(store, *replay); err != nil {
+			log.Fatalf("回放失败: %v", err)
+		}
+		return
+	}
+
+	if *serve != "" {
+		if err := runServe(cfg, store, *serve); err != nil {
+			log.Fatalf("Web 面板退出: %v", err)
+		}
+		return
+	}
+
+	if *motd {
+		fmt.Println(formatMOTD(cfg, store, !*noColor && os.Getenv("NO_COLOR") == ""))
+		return
+	}
+
+	if *reportLog > 0 {
+		if err := printReportLog(store, *reportLog); err != nil {
+			log.Fatalf("查询投递记录失败: %v", err)
+		}
+		return
+	}
+
 	// 初始化 Telegram 报告器
-	telegramReporter := reporter.NewTelegramReporter(&cfg.Telegram, cfg.Hostname)
+	telegramReporter := reporter.NewTelegramReporter(&cfg.Telegram, &cfg.Report, cfg.Hostname)
+
+	// 初始化机群告警队列（可选）
+	var alertQueue *storage.AlertQueue
+	if cfg.Fleet.Enabled {
+		alertQueue, err = storage.OpenAlertQueue(cfg.Fleet.QueuePath)
+		if err != nil {
+			log.Fatalf("初始化机群告警队列失败: %v", err)
+		}
+		defer alertQueue.Close()
+	}
 
 	if *testTelegram {
 		if err := telegramReporter.TestConnection(); err != nil {
@@ -66,102 +205,425 @@ func main() {
 	}
 
 	// 初始化采集器
-	cpuCollector := collector.NewCPUCollector()
-	diskCollector := collector.NewDiskCollector(cfg.Collect.IOTestSizeMB)
-	memoryCollector := collector.NewMemoryCollector()
+	cpuCollector := collector.NewCPUCollector(collector.DefaultProcPath)
+	cpuCollector.PinBenchmark(cfg.Collect.BenchCPU)
+	diskCollector := collector.NewDiskCollector(collector.DiskOptions{
+		TestSizeMB:     cfg.Collect.IOTestSizeMB,
+		TestDir:        cfg.Collect.TestDir,
+		ExcludeDevices: cfg.Collect.ExcludeDevices,
+		ExcludeMounts:  cfg.Collect.ExcludeMounts,
+		Prealloc:       cfg.Collect.PreallocTestFile,
+		Samples:        cfg.Collect.IOSamplesPerRun,
+		ProcPath:       collector.DefaultProcPath,
+	})
+	memoryCollector := collector.NewMemoryCollector(collector.DefaultProcPath, cfg.Collect.MemBenchSizeMB)
+	networkCollector := collector.NewNetworkCollector(collector.DefaultProcPath, cfg.Collect.NetworkInterface)
 
 	// 初始化分析器
-	scoreAnalyzer := analyzer.NewAnalyzer(store)
+	scoreAnalyzer := analyzer.NewAnalyzer(store, cfg)
+	if *forceStorage != "" {
+		storageType, err := parseStorageType(*forceStorage)
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+		scoreAnalyzer.ForceStorageType(storageType)
+		log.Printf("已强制按 %s 评分（-force-storage）", storageType)
+	}
 	aiAnalyzer := analyzer.NewAIAnalyzer(&cfg.AI)
 
 	// 仅采集一次
 	if *collectOnce {
-		collectAll(cpuCollector, diskCollector, memoryCollector, store)
+		resumeCPU(cfg, store, cpuCollector)
+		collectAll(cpuCollector, diskCollector, memoryCollector, networkCollector, sink, &cfg.Collect)
+		checkpointCPU(store, cpuCollector)
+		for i := range cfg.Collect.CustomCommands {
+			collectCustomMetric(&cfg.Collect.CustomCommands[i], sink)
+		}
 		fmt.Println("✅ 数据采集完成")
 		return
 	}
 
 	// 立即生成报告
 	if *reportType != "" {
-		generateReport(*reportType, scoreAnalyzer, aiAnalyzer, telegramReporter)
+		generateReport(*reportType, cfg, store, scoreAnalyzer, aiAnalyzer, telegramReporter)
 		return
 	}
 
 	// 守护进程模式
 	log.Println("超了么 (chaoleme) 启动...")
-	runDaemon(cfg, cpuCollector, diskCollector, memoryCollector, store, scoreAnalyzer, aiAnalyzer, telegramReporter)
+	runDaemon(cfg, cpuCollector, diskCollector, memoryCollector, networkCollector, store, sink, scoreAnalyzer, aiAnalyzer, telegramReporter, alertQueue)
+}
+
+// runMeasureSteal 高精度测量 CPU Steal 并打印统计结果
+func runMeasureSteal(duration time.Duration) {
+	const sampleInterval = 250 * time.Millisecond
+
+	fmt.Printf("正在以 %v 间隔采样 CPU Steal，持续 %v ...\n", sampleInterval, duration)
+	result, err := collector.NewCPUCollector(collector.DefaultProcPath).MeasureSteal(duration, sampleInterval)
+	if err != nil {
+		log.Fatalf("Steal 测量失败: %v", err)
+	}
+
+	fmt.Printf("样本数: %d\n", result.Samples)
+	fmt.Printf("Steal 最小: %.2f%%\n", result.Min)
+	fmt.Printf("Steal 平均: %.2f%%\n", result.Avg)
+	fmt.Printf("Steal 最大: %.2f%%\n", result.Max)
+	fmt.Printf("Steal P95:  %.2f%%\n", result.P95)
+}
+
+// storageOptions 数据库连接选项（日志模式与连接池）
+func storageOptions(cfg *config.Config) storage.Options {
+	return storage.Options{
+		WAL:          cfg.Storage.WAL,
+		MaxOpenConns: cfg.Storage.MaxOpenConns,
+		MaxIdleConns: cfg.Storage.MaxIdleConns,
+	}
+}
+
+// runBenchStorage 测量本机数据库写入能力，并与当前配置的写入量对比
+func runBenchStorage(cfg *config.Config, rows, batchSize int) error {
+	dir := filepath.Dir(cfg.Storage.DBPath)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("创建数据目录失败: %w", err)
+	}
+
+	fmt.Printf("正在 %s 写入 %d 行逐条样本与 %d 行批量样本（每批 %d 行）...\n", dir, rows, rows, batchSize)
+	result, err := storage.Benchmark(dir, rows, batchSize, storageOptions(cfg))
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("逐条写入: %8.0f 行/秒（%v）\n", result.SingleRate(), result.SingleDuration.Round(time.Millisecond))
+	fmt.Printf("批量写入: %8.0f 行/秒（%v，约为逐条的 %.1f 倍）\n", result.BatchRate(), result.BatchDuration.Round(time.Millisecond), result.BatchRate()/result.SingleRate())
+	fmt.Printf("数据库大小: %.1f KB（%d 行，约 %.0f 字节/行）\n", float64(result.DBSize)/1024, 2*rows, result.BytesPerRow())
+
+	// 按当前间隔粗略估算每小时写入行数：Steal 组约 6 行（Steal/IOWait/Load/运行队列/在线 vCPU/网络），
+	// 基准测试组约 3 行（基准/缺页/温度），I/O 组约 4 行（顺序/随机 I/O、内存、磁盘统计）
+	perHour := func(interval time.Duration, n int) float64 {
+		return float64(time.Hour) / float64(interval) * float64(n)
+	}
+	stealInterval := cfg.GetCPUStealInterval()
+	if cfg.Collect.AdaptiveInterval {
+		stealInterval, _ = cfg.GetAdaptiveIntervalBounds()
+	}
+	hourly := perHour(stealInterval, 6) + perHour(cfg.GetCPUBenchInterval(), 3) + perHour(cfg.GetIOTestInterval(), 4)
+	fmt.Printf("当前配置约每小时写入 %.0f 行，按逐条写入速率约占用 %.2f 秒/小时；按 %.0f 字节/行，每天约增长 %.1f MB（清理与聚合前）\n",
+		hourly, hourly/result.SingleRate(), result.BytesPerRow(), hourly*24*result.BytesPerRow()/1024/1024)
+	return nil
+}
+
+// parseStorageType 解析 -force-storage 参数
+func parseStorageType(s string) (collector.StorageType, error) {
+	switch strings.ToLower(s) {
+	case "ssd":
+		return collector.StorageTypeSSD, nil
+	case "hdd":
+		return collector.StorageTypeHDD, nil
+	default:
+		return "", fmt.Errorf("-force-storage 必须是 ssd 或 hdd: %s", s)
+	}
+}
+
+// saveSnapshot 保存原始快照（未开启 storage.raw_snapshots 时为空操作），失败仅记录日志
+func saveSnapshot(sink metricSink, now time.Time, source, content string) {
+	if err := sink.SaveSnapshot(now, source, content); err != nil {
+		log.Printf("保存原始快照失败: %v", err)
+	}
+}
+
+// parseTimeRange 解析 -replay 的时间范围：时长（如 "6h"，表示最近 6 小时）或 "开始,结束"（本地时间）
+func parseTimeRange(spec string) (start, end time.Time, err error) {
+	if d, err := time.ParseDuration(spec); err == nil {
+		end = time.Now()
+		return end.Add(-d), end, nil
+	}
+
+	from, to, ok := strings.Cut(spec, ",")
+	if !ok {
+		return start, end, fmt.Errorf("时间范围格式无效: %s（应为时长如 6h，或 \"2006-01-02 15:04,2006-01-02 18:00\"）", spec)
+	}
+	const layout = "2006-01-02 15:04"
+	if start, err = time.ParseInLocation(layout, strings.TrimSpace(from), time.Local); err != nil {
+		return start, end, fmt.Errorf("开始时间格式无效: %w", err)
+	}
+	if end, err = time.ParseInLocation(layout, strings.TrimSpace(to), time.Local); err != nil {
+		return start, end, fmt.Errorf("结束时间格式无效: %w", err)
+	}
+	if !end.After(start) {
+		return start, end, fmt.Errorf("结束时间必须晚于开始时间")
+	}
+	return start, end, nil
+}
+
+// runReplay 从原始快照重新计算 Steal/IOWait/Load1，并与数据库中已存的派生值逐行对比
+// 用于事后核对异常时段的数值、排查解析问题；快照解析失败的行单独标出
+func runReplay(store *storage.Storage, spec string) error {
+	start, end, err := parseTimeRange(spec)
+	if err != nil {
+		return err
+	}
+
+	stats, err := store.QuerySnapshots(storage.SnapshotProcStat, start, end)
+	if err != nil {
+		return err
+	}
+	loads, err := store.QuerySnapshots(storage.SnapshotLoadAvg, start, end)
+	if err != nil {
+		return err
+	}
+	if len(stats) == 0 && len(loads) == 0 {
+		return fmt.Errorf("%s ~ %s 内没有原始快照（需开启 storage.raw_snapshots）", start.Format("2006-01-02 15:04"), end.Format("2006-01-02 15:04"))
+	}
+
+	// 已存的派生值，按秒级时间戳与快照对齐
+	stored := func(metricType storage.MetricType) map[int64]float64 {
+		values := make(map[int64]float64)
+		metrics, _ := store.Query(metricType, start, end)
+		for _, m := range metrics {
+			values[m.Timestamp.Unix()] = m.Value
+		}
+		return values
+	}
+	storedSteal := stored(storage.MetricTypeCPUSteal)
+	storedIoWait := stored(storage.MetricTypeCPUIoWait)
+	loadByTime := make(map[int64]string)
+	for _, l := range loads {
+		loadByTime[l.Timestamp.Unix()] = l.Content
+	}
+
+	formatStored := func(values map[int64]float64, ts int64) string {
+		if v, ok := values[ts]; ok {
+			return fmt.Sprintf("%6.2f%%", v)
+		}
+		return "      -"
+	}
+
+	fmt.Printf("回放 %s ~ %s：%d 个 /proc/stat 快照，%d 个 /proc/loadavg 快照\n",
+		start.Format("2006-01-02 15:04"), end.Format("2006-01-02 15:04"), len(stats), len(loads))
+	fmt.Printf("%-19s  %-17s  %-17s  %s\n", "时间", "Steal 重算/已存", "IOWait 重算/已存", "Load1 (运行/总数)")
+
+	var prev *collector.CPUStats
+	for _, snap := range stats {
+		ts := snap.Timestamp.Unix()
+		cur, err := collector.ParseCPUStatLine(snap.Content)
+		if err != nil {
+			fmt.Printf("%-19s  ⚠️ 解析失败: %v\n", snap.Timestamp.Format("2006-01-02 15:04:05"), err)
+			prev = nil
+			continue
+		}
+
+		stealCol, iowaitCol := "      -", "      -"
+		if prev != nil && cur.Total() >= prev.Total() {
+			steal, iowait := collector.UsageBetween(prev, cur)
+			stealCol, iowaitCol = fmt.Sprintf("%6.2f%%", steal), fmt.Sprintf("%6.2f%%", iowait)
+		}
+		prev = cur
+
+		loadCol := "-"
+		if raw, ok := loadByTime[ts]; ok {
+			if load, err := collector.ParseLoadAvg(raw); err == nil {
+				loadCol = fmt.Sprintf("%.2f (%d/%d)", load.Load1, load.Running, load.Total)
+			} else {
+				loadCol = fmt.Sprintf("⚠️ 解析失败: %v", err)
+			}
+		}
+
+		fmt.Printf("%-19s  %s/%s  %s/%s  %s\n", snap.Timestamp.Format("2006-01-02 15:04:05"),
+			stealCol, formatStored(storedSteal, ts), iowaitCol, formatStored(storedIoWait, ts), loadCol)
+	}
+
+	fmt.Println("\n注：重算值基于相邻两次快照，而守护进程基于自身的上次采样，两者在重启或采集失败后的首个样本处可能不同")
+	return nil
+}
+
+// writeConfigTemplate 在指定路径写入配置模板，文件已存在时拒绝覆盖
+func writeConfigTemplate(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("创建配置目录失败: %w", err)
+	}
+	// 模板中包含 bot_token 等敏感字段，仅所有者可读写
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if errors.Is(err, os.ErrExist) {
+		return fmt.Errorf("%s 已存在，未覆盖（如需重新生成请先删除）", path)
+	}
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(configTemplate); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	fmt.Printf("✅ 配置模板已生成: %s\n", path)
+	fmt.Println("请编辑该文件填写 telegram.bot_token 与 telegram.chat_id，然后运行 -validate 检查配置")
+	return nil
+}
+
+// runWatch 实时显示 CPU Steal/IOWait
+// 瞬时读数跳动较大，主列显示滑动平均，同时给出瞬时值与窗口峰值；数据仅在内存中，不写入数据库
+func runWatch(interval time.Duration, window int) {
+	cpu := collector.NewCPUCollector(collector.DefaultProcPath)
+	steal := collector.NewSlidingWindow(window)
+	iowait := collector.NewSlidingWindow(window)
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	fmt.Printf("实时监控 CPU Steal/IOWait（间隔 %v，滑动窗口 %d 个样本，Ctrl+C 退出）\n", interval, window)
+	fmt.Printf("%-8s  %-28s  %-18s\n", "时间", "Steal 平均 (瞬时/峰值)", "IOWait 平均 (瞬时)")
+
+	for {
+		usage, err := cpu.Collect()
+		if err != nil {
+			log.Printf("CPU 采集失败: %v", err)
+		} else {
+			steal.Add(usage.StealPercent)
+			iowait.Add(usage.IOWaitPercent)
+			fmt.Printf("%-8s  %6.2f%% (%6.2f%% / %6.2f%%)  %6.2f%% (%6.2f%%)\n",
+				time.Now().Format("15:04:05"),
+				steal.Avg(), usage.StealPercent, steal.Max(),
+				iowait.Avg(), usage.IOWaitPercent)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-sigCh:
+			return
+		}
+	}
+}
+
+// metricSink 指标写入目标
+type metricSink interface {
+	Save(m *storage.Metric) error
+	SaveBatch(metrics []*storage.Metric) error
+	SaveSnapshot(ts time.Time, source, content string) error
+}
+
+// flaggedSink 为写入的每条指标在 extra 中打上标记
+type flaggedSink struct {
+	metricSink
+	flag string
+}
+
+func (f flaggedSink) Save(m *storage.Metric) error {
+	return f.metricSink.Save(f.mark(m))
+}
+
+func (f flaggedSink) SaveBatch(metrics []*storage.Metric) error {
+	marked := make([]*storage.Metric, len(metrics))
+	for i, m := range metrics {
+		marked[i] = f.mark(m)
+	}
+	return f.metricSink.SaveBatch(marked)
+}
+
+func (f flaggedSink) mark(m *storage.Metric) *storage.Metric {
+	tagged := *m
+	tagged.Extra = make(map[string]interface{}, len(m.Extra)+1)
+	for k, v := range m.Extra {
+		tagged.Extra[k] = v
+	}
+	tagged.Extra[f.flag] = true
+	return &tagged
 }
 
 // collectAll 执行一次完整的数据采集
-func collectAll(cpu *collector.CPUCollector, disk *collector.DiskCollector, mem *collector.MemoryCollector, store *storage.Storage) {
+func collectAll(cpu *collector.CPUCollector, disk *collector.DiskCollector, mem *collector.MemoryCollector, network *collector.NetworkCollector, sink metricSink, opts *config.CollectConfig) {
 	now := time.Now()
+	passive := opts.PassiveOnly
 
 	// CPU Usage (Steal & IOWait)
 	if cpuUsage, err := cpu.Collect(); err == nil {
-		store.Save(&storage.Metric{
-			Timestamp: now,
-			Type:      storage.MetricTypeCPUSteal,
-			Value:     cpuUsage.StealPercent,
-		})
+		saveSnapshot(sink, now, storage.SnapshotProcStat, cpuUsage.RawStat)
+		sink.Save(stealMetric(now, cpuUsage))
 		log.Printf("CPU Steal: %.2f%%", cpuUsage.StealPercent)
 
-		store.Save(&storage.Metric{
-			Timestamp: now,
-			Type:      storage.MetricTypeCPUIoWait,
-			Value:     cpuUsage.IOWaitPercent,
-		})
+		sink.Save(iowaitMetric(now, cpuUsage))
 		log.Printf("CPU IOWait: %.2f%%", cpuUsage.IOWaitPercent)
 	} else {
 		log.Printf("CPU 数据采集失败: %v", err)
+		recordCollectError(sink, storage.MetricTypeCPUSteal, err)
 	}
 
-	// CPU 基准测试
-	if result, err := cpu.RunBenchmark(); err == nil {
-		store.Save(&storage.Metric{
-			Timestamp: now,
-			Type:      storage.MetricTypeCPUBench,
-			Value:     result.DurationMs,
-		})
-		log.Printf("CPU Bench: %.2fms", result.DurationMs)
-	} else {
-		log.Printf("CPU 基准测试失败: %v", err)
+	if !passive {
+		// CPU 基准测试
+		if result, err := cpu.RunBenchmark(); err == nil {
+			sink.Save(&storage.Metric{
+				Timestamp: now,
+				Type:      storage.MetricTypeCPUBench,
+				Value:     result.DurationMs,
+			})
+			log.Printf("CPU Bench: %.2fms", result.DurationMs)
+		} else {
+			log.Printf("CPU 基准测试失败: %v", err)
+			recordCollectError(sink, storage.MetricTypeCPUBench, err)
+		}
+		collectMemoryFault(mem, sink)
 	}
 
-	// I/O 顺序延迟
-	if result, err := disk.TestWriteLatency(); err == nil {
-		store.Save(&storage.Metric{
-			Timestamp: now,
-			Type:      storage.MetricTypeIOLatency,
-			Value:     result.TotalLatencyMs,
-			Extra: map[string]interface{}{
-				"write_latency_ms": result.WriteLatencyMs,
-				"sync_latency_ms":  result.SyncLatencyMs,
-			},
-		})
-		log.Printf("I/O Latency: %.2fms", result.TotalLatencyMs)
-	} else {
-		log.Printf("I/O 延迟测试失败: %v", err)
-	}
+	// CPU 温度（无 thermal zone 时跳过）
+	collectCPUTemperature(sink)
 
-	// I/O 随机读写
-	if result, err := disk.TestRandomIO(); err == nil {
-		store.Save(&storage.Metric{
-			Timestamp: now,
-			Type:      storage.MetricTypeRandomIO,
-			Value:     result.RandomWriteLatencyMs, // 主值使用写延迟
-			Extra: map[string]interface{}{
-				"write_latency_ms": result.RandomWriteLatencyMs,
-				"read_latency_ms":  result.RandomReadLatencyMs,
-			},
-		})
-		log.Printf("Random I/O: Write=%.2fms, Read=%.2fms", result.RandomWriteLatencyMs, result.RandomReadLatencyMs)
-	} else {
-		log.Printf("随机 I/O 测试失败: %v", err)
+	if !passive {
+		// 测试窗口从下一次 IOWait 采样中扣除，避免自身 I/O 被算作磁盘争抢
+		cpu.BeginSelfTest()
+		// I/O 顺序延迟
+		if result, err := disk.TestWriteLatency(); err == nil {
+			sink.Save(&storage.Metric{
+				Timestamp: now,
+				Type:      storage.MetricTypeIOLatency,
+				Value:     result.TotalLatencyMs,
+				Extra: map[string]interface{}{
+					"write_latency_ms": result.WriteLatencyMs,
+					"sync_latency_ms":  result.SyncLatencyMs,
+					"fs_type":          disk.FilesystemType(),
+					"samples":          result.Samples,
+					"spread_ms":        result.SpreadMs,
+				},
+			})
+			log.Printf("I/O Latency: %.2fms", result.TotalLatencyMs)
+		} else {
+			log.Printf("I/O 延迟测试失败: %v", err)
+			recordCollectError(sink, storage.MetricTypeIOLatency, err)
+		}
+
+		// I/O 随机读写
+		if opts.RandomIOTest {
+			if result, err := disk.TestRandomIO(); err == nil {
+				sink.Save(&storage.Metric{
+					Timestamp: now,
+					Type:      storage.MetricTypeRandomIO,
+					Value:     result.RandomWriteLatencyMs, // 主值使用写延迟
+					Extra: map[string]interface{}{
+						"write_latency_ms": result.RandomWriteLatencyMs,
+						"read_latency_ms":  result.RandomReadLatencyMs,
+						"fs_type":          disk.FilesystemType(),
+						"samples":          result.Samples,
+						"spread_ms":        result.SpreadMs,
+					},
+				})
+				log.Printf("Random I/O: Write=%.2fms, Read=%.2fms", result.RandomWriteLatencyMs, result.RandomReadLatencyMs)
+			} else {
+				log.Printf("随机 I/O 测试失败: %v", err)
+				recordCollectError(sink, storage.MetricTypeRandomIO, err)
+			}
+		}
+		if opts.SyncProbe {
+			collectSyncProbe(disk, sink)
+		}
+		cpu.EndSelfTest()
 	}
 
 	// 内存
 	if stats, err := mem.Collect(); err == nil {
-		store.Save(&storage.Metric{
+		sink.Save(&storage.Metric{
 			Timestamp: now,
 			Type:      storage.MetricTypeMemory,
 			Value:     stats.UsagePercent(),
@@ -175,11 +637,12 @@ func collectAll(cpu *collector.CPUCollector, disk *collector.DiskCollector, mem
 		log.Printf("Memory Usage: %.1f%%, Available: %.1f%%", stats.UsagePercent(), stats.AvailablePercent())
 	} else {
 		log.Printf("内存采集失败: %v", err)
+		recordCollectError(sink, storage.MetricTypeMemory, err)
 	}
 
 	// DiskStats 磁盘统计（从 /proc/diskstats 采集，开销极低）
 	if diskStats, err := disk.CollectDiskStats(); err == nil {
-		store.Save(&storage.Metric{
+		m := &storage.Metric{
 			Timestamp: now,
 			Type:      storage.MetricTypeDiskStats,
 			Value:     float64(diskStats.IOTimeMs), // 主值使用累计 IO 耗时
@@ -190,18 +653,31 @@ func collectAll(cpu *collector.CPUCollector, disk *collector.DiskCollector, mem
 				"write_bytes":    diskStats.WriteBytes,
 				"io_time_ms":     diskStats.IOTimeMs,
 				"weighted_io_ms": diskStats.WeightedIOMs,
+				"read_merges":    diskStats.ReadMerges,
+				"write_merges":   diskStats.WriteMerges,
+				"in_flight":      diskStats.InFlight,
 			},
-		})
+		}
+		if diskStats.BusyValid {
+			m.Extra["busy_percent"] = diskStats.BusyPercent
+		}
+		sink.Save(m)
 		log.Printf("Disk Stats: ReadOps=%d, WriteOps=%d, IOTime=%dms", diskStats.ReadOps, diskStats.WriteOps, diskStats.IOTimeMs)
 	} else {
 		log.Printf("磁盘统计采集失败: %v", err)
+		recordCollectError(sink, storage.MetricTypeDiskStats, err)
 	}
 
-	// Load Average
-	if loadResult, err := collector.CollectLoadAverage(); err == nil {
-		numCPU := float64(runtime.NumCPU())
+	collectNetwork(network, sink, now)
+	collectNUMA(sink, now)
+	collectSchedStat(sink, now)
+
+	// Load Average（按实时在线 vCPU 数归一化）
+	numCPU := collectOnlineCPUs(sink, now)
+	if loadResult, err := collector.CollectLoadAverage(collector.DefaultProcPath); err == nil {
+		saveSnapshot(sink, now, storage.SnapshotLoadAvg, loadResult.Raw)
 		normalizedLoad := loadResult.Load1 / numCPU
-		store.Save(&storage.Metric{
+		sink.Save(&storage.Metric{
 			Timestamp: now,
 			Type:      storage.MetricTypeCPULoad,
 			Value:     normalizedLoad,
@@ -210,16 +686,88 @@ func collectAll(cpu *collector.CPUCollector, disk *collector.DiskCollector, mem
 				"load5":   loadResult.Load5,
 				"load15":  loadResult.Load15,
 				"num_cpu": numCPU,
+				"running": loadResult.Running,
+				"total":   loadResult.Total,
 			},
 		})
-		log.Printf("CPU Load: %.2f (normalized: %.2f)", loadResult.Load1, normalizedLoad)
+		saveRunQueue(sink, now, loadResult, numCPU)
+		log.Printf("CPU Load: %.2f (normalized: %.2f), Running: %d/%d", loadResult.Load1, normalizedLoad, loadResult.Running, loadResult.Total)
 	} else {
 		log.Printf("Load Average 采集失败: %v", err)
+		recordCollectError(sink, storage.MetricTypeCPULoad, err)
+	}
+}
+
+// collectCustomMetric 执行一次自定义指标命令并保存结果
+func collectCustomMetric(cc *config.CustomCommand, sink *storage.WriteGuard) {
+	value, err := collector.RunCustomCommand(cc.Command, cc.GetTimeout())
+	if err != nil {
+		log.Printf("自定义指标 %s 采集失败: %v", cc.Name, err)
+		recordCollectError(sink, storage.CustomMetricType(cc.Name), err)
+		return
+	}
+	sink.Save(&storage.Metric{
+		Timestamp: time.Now(),
+		Type:      storage.CustomMetricType(cc.Name),
+		Value:     value,
+	})
+	log.Printf("Custom %s: %.2f", cc.Name, value)
+}
+
+// runCustomCommand 按配置间隔循环执行自定义指标命令，直到 done 关闭
+func runCustomCommand(cc *config.CustomCommand, sink *storage.WriteGuard, done <-chan struct{}) {
+	ticker := time.NewTicker(cc.GetInterval())
+	defer ticker.Stop()
+
+	collectCustomMetric(cc, sink)
+	for {
+		select {
+		case <-ticker.C:
+			collectCustomMetric(cc, sink)
+		case <-done:
+			return
+		}
+	}
+}
+
+// enableAlignment 按各指标所属采集定时器的间隔取整时间戳（storage.align_timestamps）
+// 开启自适应 Steal 间隔时按下限取整，间隔缩短后相邻样本也不会落在同一边界
+func enableAlignment(cfg *config.Config, sink *storage.WriteGuard) {
+	for metricType, interval := range analyzer.MetricIntervals(cfg) {
+		sink.EnableAlignment(metricType, interval)
+	}
+}
+
+// runCanary 按间隔执行卡顿探测写入，仅在耗时超过 deadline 时记录一条卡顿事件
+func runCanary(disk *collector.DiskCollector, interval, deadline time.Duration, sink metricSink, done <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			latency, ok, err := disk.CanaryWrite()
+			if err != nil {
+				log.Printf("[卡顿探测] %v", err)
+				continue
+			}
+			if !ok || latency <= deadline {
+				continue
+			}
+			log.Printf("⚠️ I/O 卡顿: 探测写入耗时 %v（阈值 %v）", latency.Round(time.Millisecond), deadline)
+			sink.Save(&storage.Metric{
+				Timestamp: time.Now(),
+				Type:      storage.MetricTypeIOStall,
+				Value:     float64(latency.Microseconds()) / 1000.0,
+			})
+		case <-done:
+			return
+		}
 	}
 }
 
 // generateReport 生成并发送报告
-func generateReport(reportType string, scoreAnalyzer *analyzer.Analyzer, aiAnalyzer *analyzer.AIAnalyzer, telegramReporter *reporter.TelegramReporter) {
+func generateReport(reportType string, cfg *config.Config, store *storage.Storage, scoreAnalyzer *analyzer.Analyzer, aiAnalyzer *analyzer.AIAnalyzer, telegramReporter *reporter.TelegramReporter) {
 	var start, end time.Time
 	end = time.Now()
 
@@ -230,6 +778,10 @@ func generateReport(reportType string, scoreAnalyzer *analyzer.Analyzer, aiAnaly
 		start = end.AddDate(0, 0, -7)
 	case "monthly":
 		start = end.AddDate(0, -1, 0)
+	case "today":
+		// 本地时区今日 0 点至今，不足一天
+		y, m, d := end.Date()
+		start = time.Date(y, m, d, 0, 0, 0, 0, end.Location())
 	default:
 		log.Fatalf("无效的报告类型: %s", reportType)
 	}
@@ -239,126 +791,595 @@ func generateReport(reportType string, scoreAnalyzer *analyzer.Analyzer, aiAnaly
 		log.Fatalf("分析数据失败: %v", err)
 	}
 
+	if *explain {
+		fmt.Print(reporter.FormatExplain(locale.New(cfg.Report.Locale, cfg.Report.DecimalSeparator), stats))
+		return
+	}
+
 	// AI 分析
 	aiAnalysis, err := aiAnalyzer.Analyze(stats, reportType)
 	if err != nil {
 		log.Printf("AI 分析失败 (降级为规则评分): %v", err)
 	}
 
+	writeJSONReport(cfg, store, stats, aiAnalysis)
+	writeHTMLReport(cfg, store, telegramReporter, stats, aiAnalysis)
+
 	// 发送报告
-	if err := telegramReporter.SendReport(stats, aiAnalysis); err != nil {
+	err = telegramReporter.SendReport(stats, aiAnalysis)
+	recordDelivery(store, stats.Period, stats.EndTime, deliveryTelegram, err)
+	if err != nil {
 		log.Fatalf("发送报告失败: %v", err)
 	}
 
 	fmt.Printf("✅ %s 报告已发送\n", reportType)
 }
 
-// runDaemon 守护进程模式
-func runDaemon(cfg *config.Config, cpu *collector.CPUCollector, disk *collector.DiskCollector, mem *collector.MemoryCollector, store *storage.Storage, scoreAnalyzer *analyzer.Analyzer, aiAnalyzer *analyzer.AIAnalyzer, telegramReporter *reporter.TelegramReporter) {
-	// 获取并打印采集间隔配置
-	cpuStealInterval := cfg.GetCPUStealInterval()
-	cpuBenchInterval := cfg.GetCPUBenchInterval()
-	ioTestInterval := cfg.GetIOTestInterval()
-	log.Printf("采集间隔配置: CPU Steal=%v, CPU Bench=%v, I/O Test=%v", cpuStealInterval, cpuBenchInterval, ioTestInterval)
+// cpuCheckpointKey 保存上次 -collect-once 读取的 /proc/stat cpu 行的状态键
+const cpuCheckpointKey = "cpu_checkpoint"
 
-	// 创建定时器
-	cpuStealTicker := time.NewTicker(cpuStealInterval)
-	cpuBenchTicker := time.NewTicker(cpuBenchInterval)
-	ioTestTicker := time.NewTicker(ioTestInterval)
-	cleanupTicker := time.NewTicker(24 * time.Hour)
-	reportCheckTicker := time.NewTicker(1 * time.Minute) // 报告检查定时器
+// cpuRunGapKey 保存上一次 -collect-once 与其前一次运行的间隔（秒），用于推断 cron 周期
+const cpuRunGapKey = "cpu_run_gap"
 
-	// 解析日报时间
-	dailyTime, _ := time.Parse("15:04", cfg.Report.DailyTime)
+// cpuResumeMinAge 续接上次 CPU 起点的最小容忍时长
+const cpuResumeMinAge = 2 * time.Hour
 
-	// 信号处理
-	sigCh := make(chan os.Signal, 1)
-	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+// cpuResumeMaxAge 上次运行的 CPU 起点超过该时长视为过期，不再续接
+// （长时间停采后的区间会把数小时的 Steal 摊成一个均值，掩盖尖峰）。
+// 上限取 2 小时、两倍 cpu_steal_interval、1.5 倍上一次运行间隔中的最大者：
+// cron 周期长于 2 小时时仍能续接，否则每个样本都是被排除的自举样本，报告将没有 Steal 数据
+func cpuResumeMaxAge(cfg *config.Config, store *storage.Storage) time.Duration {
+	maxAge := cpuResumeMinAge
+	if d := 2 * cfg.GetCPUStealInterval(); d > maxAge {
+		maxAge = d
+	}
+	if raw, _, ok, err := store.GetState(cpuRunGapKey); err == nil && ok {
+		if secs, err := strconv.ParseFloat(raw, 64); err == nil {
+			if d := time.Duration(secs * 1.5 * float64(time.Second)); d > maxAge {
+				maxAge = d
+			}
+		}
+	}
+	return maxAge
+}
 
-	// 启动时先采集一次
-	collectAll(cpu, disk, mem, store)
+// resumeCPU 以上次 -collect-once 保存的 CPU 起点续接，使 cron 定时采集的 Steal/IOWait
+// 覆盖两次运行之间的完整间隔；没有可用起点时 Collect 退回 500ms 自举采样
+func resumeCPU(cfg *config.Config, store *storage.Storage, cpu *collector.CPUCollector) {
+	raw, at, ok, err := store.GetState(cpuCheckpointKey)
+	if err != nil || !ok {
+		return
+	}
+	gap := time.Since(at)
+	maxAge := cpuResumeMaxAge(cfg, store)
+	// 记录本次间隔供下次推断 cron 周期（过期的间隔同样记录，cron 周期变长后第二次运行即可续接）
+	if err := store.SetState(cpuRunGapKey, strconv.FormatFloat(gap.Seconds(), 'f', 0, 64)); err != nil {
+		log.Printf("保存运行间隔失败: %v", err)
+	}
+	if gap > maxAge {
+		log.Printf("上次 CPU 起点已过期（%v > %v），本次使用自举采样", gap.Round(time.Second), maxAge.Round(time.Second))
+		return
+	}
+	if err := cpu.Resume(raw, at); err != nil {
+		log.Printf("无法续接上次的 CPU 起点，改用自举采样: %v", err)
+	}
+}
 
-	// 上次发送报告的日期
-	var lastDailyReport, lastWeeklyReport, lastMonthlyReport time.Time
+// checkpointCPU 保存本次采样的 cpu 行，供下一次 -collect-once 续接
+func checkpointCPU(store *storage.Storage, cpu *collector.CPUCollector) {
+	raw, ok := cpu.Checkpoint()
+	if !ok {
+		return
+	}
+	if err := store.SetState(cpuCheckpointKey, raw); err != nil {
+		log.Printf("保存 CPU 起点失败: %v", err)
+	}
+}
 
-	for {
-		select {
-		case <-cpuStealTicker.C:
+// stealMetric 构造 Steal 指标
+// 采样期间检测到时钟跳变（疑似迁移/挂起）时，Steal 尖峰是一次性事件而非持续超售，
+// 单独存为 cpu_steal_suspend，不计入 Steal 平均值，报告中另行说明
+func stealMetric(now time.Time, usage *collector.CPUUsage) *storage.Metric {
+	if usage.Suspended() {
+		log.Printf("检测到时钟跳变 %v，疑似虚拟机迁移/挂起，本次 Steal %.2f%% 不计入统计", usage.ClockJump.Round(time.Second), usage.StealPercent)
+		return &storage.Metric{
+			Timestamp: now,
+			Type:      storage.MetricTypeCPUStealSuspend,
+			Value:     usage.StealPercent,
+			Extra: map[string]interface{}{
+				"clock_jump_s": usage.ClockJump.Seconds(),
+			},
+		}
+	}
+	return markBootstrap(&storage.Metric{
+		Timestamp: now,
+		Type:      storage.MetricTypeCPUSteal,
+		Value:     usage.StealPercent,
+	}, usage)
+}
+
+// iowaitMetric 构造 IOWait 指标，扣除过本工具 I/O 测试窗口时在 extra 中记录去掉的百分点
+// 检测到时钟跳变时与 Steal 一样不计入统计：挂起期间积压的 iowait 同样是一次性伪影，打上 suspend 标记保留
+func iowaitMetric(now time.Time, usage *collector.CPUUsage) *storage.Metric {
+	m := &storage.Metric{
+		Timestamp: now,
+		Type:      storage.MetricTypeCPUIoWait,
+		Value:     usage.IOWaitPercent,
+	}
+	if usage.Suspended() {
+		log.Printf("检测到时钟跳变，本次 IOWait %.2f%% 不计入统计", usage.IOWaitPercent)
+		m.Extra = map[string]interface{}{
+			storage.FlagSuspend: true,
+			"clock_jump_s":      usage.ClockJump.Seconds(),
+		}
+		return m
+	}
+	if usage.SelfTestIOWait >= 0.01 {
+		m.Extra = map[string]interface{}{storage.ExtraSelfTestIOWait: usage.SelfTestIOWait}
+		log.Printf("IOWait 已扣除自身 I/O 测试窗口（-%.2f 个百分点）", usage.SelfTestIOWait)
+	}
+	return markBootstrap(m, usage)
+}
+
+// markBootstrap 自举样本（仅覆盖 500ms）打上 bootstrap 标记并记录窗口时长，分析时排除
+// Steal/IOWait 的均值按样本等权计算，只有窗口一致（采集间隔）的样本才能放在一起平均
+func markBootstrap(m *storage.Metric, usage *collector.CPUUsage) *storage.Metric {
+	if !usage.Bootstrap {
+		return m
+	}
+	if m.Extra == nil {
+		m.Extra = make(map[string]interface{}, 2)
+	}
+	m.Extra[storage.FlagBootstrap] = true
+	m.Extra["window_ms"] = float64(usage.Window.Milliseconds())
+	return m
+}
+
+// writeJSONReport 配置了 json_dir 时写入机器可读报告，失败仅记录日志，不影响 Telegram 发送
+func writeJSONReport(cfg *config.Config, store *storage.Storage, stats *analyzer.PeriodStats, aiAnalysis string) {
+	if cfg.Report.JSONDir == "" {
+		return
+	}
+	path, err := reporter.WriteJSONReport(cfg.Report.JSONDir, cfg.Hostname, stats, aiAnalysis)
+	recordDelivery(store, stats.Period, stats.EndTime, deliveryJSON, err)
+	if err != nil {
+		log.Printf("写入 JSON 报告失败: %v", err)
+		return
+	}
+	log.Printf("JSON 报告已写入 %s", path)
+}
+
+// writeHTMLReport 配置了 html_dir 时写入自包含的 HTML 报告（内嵌 SVG 图表与 Telegram 报告全文），
+// 失败仅记录日志，不影响 Telegram 发送
+func writeHTMLReport(cfg *config.Config, store *storage.Storage, telegramReporter *reporter.TelegramReporter, stats *analyzer.PeriodStats, aiAnalysis string) {
+	if cfg.Report.HTMLDir == "" {
+		return
+	}
+	text := telegramReporter.FormatReport(stats, aiAnalysis)
+	path, err := reporter.WriteHTMLReport(cfg.Report.HTMLDir, cfg.Hostname, stats, text)
+	recordDelivery(store, stats.Period, stats.EndTime, deliveryHTML, err)
+	if err != nil {
+		log.Printf("写入 HTML 报告失败: %v", err)
+		return
+	}
+	log.Printf("HTML 报告已写入 %s", path)
+}
+
+// recordCollectError 记录一次采集失败事件，报告中按指标汇总为"采集异常"
+func recordCollectError(sink metricSink, metricType storage.MetricType, err error) {
+	msg := err.Error()
+	if r := []rune(msg); len(r) > 200 {
+		msg = string(r[:200])
+	}
+	sink.Save(&storage.Metric{
+		Timestamp: time.Now(),
+		Type:      storage.MetricTypeCollectError,
+		Value:     1,
+		Extra: map[string]interface{}{
+			"metric": string(metricType),
+			"error":  msg,
+		},
+	})
+}
+
+// collectMemoryFault 执行内存缺页延迟测试（随 CPU 基准测试执行，未启用时跳过）
+func collectMemoryFault(mem *collector.MemoryCollector, sink metricSink) {
+	if !mem.BenchEnabled() {
+		return
+	}
+	result, err := mem.RunFaultBenchmark()
+	if err != nil {
+		log.Printf("内存缺页延迟测试失败: %v", err)
+		recordCollectError(sink, storage.MetricTypeMemFault, err)
+		return
+	}
+	sink.Save(&storage.Metric{
+		Timestamp: time.Now(),
+		Type:      storage.MetricTypeMemFault,
+		Value:     result.DurationMs,
+		Extra: map[string]interface{}{
+			"size_mb": result.SizeMB,
+			"pages":   result.Pages,
+		},
+	})
+	log.Printf("Memory Fault: %.2fms (%dMB)", result.DurationMs, result.SizeMB)
+}
+
+// collectSyncProbe 运行落盘探测（4KB 覆写 + fdatasync），识别未真正落盘的 fsync
+func collectSyncProbe(disk *collector.DiskCollector, sink metricSink) {
+	result, err := disk.TestSyncDurability()
+	if err != nil {
+		log.Printf("落盘探测失败: %v", err)
+		recordCollectError(sink, storage.MetricTypeSyncProbe, err)
+		return
+	}
+	sink.Save(&storage.Metric{
+		Timestamp: time.Now(),
+		Type:      storage.MetricTypeSyncProbe,
+		Value:     result.MedianMs,
+		Extra: map[string]interface{}{
+			"min_ms":  result.MinMs,
+			"rounds":  result.Rounds,
+			"fs_type": disk.FilesystemType(),
+		},
+	})
+	log.Printf("Sync Probe: median=%.3fms, min=%.3fms", result.MedianMs, result.MinMs)
+}
+
+// collectNetwork 采集所选网卡的累计收发字节数（速率由分析器按相邻样本差分得出）
+func collectNetwork(network *collector.NetworkCollector, sink metricSink, now time.Time) {
+	stats, err := network.Collect()
+	if err != nil {
+		log.Printf("网络流量采集失败: %v", err)
+		recordCollectError(sink, storage.MetricTypeNetwork, err)
+		return
+	}
+	sink.Save(&storage.Metric{
+		Timestamp: now,
+		Type:      storage.MetricTypeNetwork,
+		Value:     float64(stats.RxBytes + stats.TxBytes),
+		Extra: map[string]interface{}{
+			"interface": stats.Interface,
+			"rx_bytes":  stats.RxBytes,
+			"tx_bytes":  stats.TxBytes,
+		},
+	})
+}
+
+// collectNUMA 采集 NUMA 节点的跨节点分配计数器（单节点机器跳过）
+func collectNUMA(sink metricSink, now time.Time) {
+	stats, err := collector.CollectNUMAStats()
+	if err != nil {
+		log.Printf("NUMA 统计采集失败: %v", err)
+		recordCollectError(sink, storage.MetricTypeNUMA, err)
+		return
+	}
+	if stats == nil {
+		return
+	}
+	sink.Save(&storage.Metric{
+		Timestamp: now,
+		Type:      storage.MetricTypeNUMA,
+		Value:     float64(stats.OtherNode),
+		Extra: map[string]interface{}{
+			"nodes":        stats.Nodes,
+			"numa_hit":     stats.Hit,
+			"numa_miss":    stats.Miss,
+			"numa_foreign": stats.Foreign,
+			"local_node":   stats.LocalNode,
+			"other_node":   stats.OtherNode,
+		},
+	})
+}
+
+// collectSchedStat 采集调度器运行队列等待时间累计值（内核未暴露 /proc/schedstat 时跳过）
+func collectSchedStat(sink metricSink, now time.Time) {
+	stat, err := collector.CollectSchedStat(collector.DefaultProcPath)
+	if err != nil {
+		log.Printf("调度统计采集失败: %v", err)
+		recordCollectError(sink, storage.MetricTypeSchedStat, err)
+		return
+	}
+	if stat == nil {
+		return
+	}
+	sink.Save(&storage.Metric{
+		Timestamp: now,
+		Type:      storage.MetricTypeSchedStat,
+		Value:     float64(stat.RunDelayNs),
+		Extra: map[string]interface{}{
+			"cpus":         stat.CPUs,
+			"running_ns":   stat.RunningNs,
+			"run_delay_ns": stat.RunDelayNs,
+			"timeslices":   stat.Timeslices,
+		},
+	})
+}
+
+// saveRunQueue 保存每 vCPU 可运行进程数，作为运行队列压力的补充指标
+func saveRunQueue(sink metricSink, now time.Time, load *collector.LoadResult, numCPU float64) {
+	if load.Total == 0 {
+		return // 未能解析 running/total 列
+	}
+	sink.Save(&storage.Metric{
+		Timestamp: now,
+		Type:      storage.MetricTypeRunQueue,
+		Value:     load.RunQueuePerCPU(numCPU),
+		Extra: map[string]interface{}{
+			"running": load.Running,
+			"total":   load.Total,
+		},
+	})
+}
+
+// collectOnlineCPUs 采集在线 vCPU 数量并返回，供 Load 归一化使用
+// 读取失败时退回进程启动时的 runtime.NumCPU()
+func collectOnlineCPUs(sink metricSink, now time.Time) float64 {
+	result, err := collector.CollectOnlineCPUs()
+	if err != nil || result.Online == 0 {
+		if err != nil {
+			log.Printf("在线 vCPU 数量采集失败: %v", err)
+			recordCollectError(sink, storage.MetricTypeCPUOnline, err)
+		}
+		return float64(runtime.NumCPU())
+	}
+	sink.Save(&storage.Metric{
+		Timestamp: now,
+		Type:      storage.MetricTypeCPUOnline,
+		Value:     float64(result.Online),
+		Extra: map[string]interface{}{
+			"configured": result.Configured,
+		},
+	})
+	return float64(result.Online)
+}
+
+// collectCPUTemperature 采集 CPU 温度，与基准测试同步以便关联性能波动与过热降频
+func collectCPUTemperature(sink metricSink) {
+	result, err := collector.CollectCPUTemperature()
+	if err != nil {
+		log.Printf("CPU 温度采集失败: %v", err)
+		recordCollectError(sink, storage.MetricTypeCPUTemp, err)
+		return
+	}
+	if result == nil {
+		return
+	}
+	sink.Save(&storage.Metric{
+		Timestamp: time.Now(),
+		Type:      storage.MetricTypeCPUTemp,
+		Value:     result.MaxCelsius,
+		Extra: map[string]interface{}{
+			"zone": result.Zone,
+		},
+	})
+	log.Printf("CPU Temp: %.1f°C (%s)", result.MaxCelsius, result.Zone)
+}
+
+// runDaemon 守护进程模式
+func runDaemon(cfg *config.Config, cpu *collector.CPUCollector, disk *collector.DiskCollector, mem *collector.MemoryCollector, network *collector.NetworkCollector, store *storage.Storage, sink *storage.WriteGuard, scoreAnalyzer *analyzer.Analyzer, aiAnalyzer *analyzer.AIAnalyzer, telegramReporter *reporter.TelegramReporter, alertQueue *storage.AlertQueue) {
+	// 获取并打印采集间隔配置
+	cpuStealInterval := cfg.GetCPUStealInterval()
+	cpuBenchInterval := cfg.GetCPUBenchInterval()
+	ioTestInterval := cfg.GetIOTestInterval()
+	log.Printf("采集间隔配置 (%s): CPU Steal=%v, CPU Bench=%v, I/O Test=%v", cfg.Collect.Profile, cpuStealInterval, cpuBenchInterval, ioTestInterval)
+	if cfg.Collect.PassiveOnly {
+		log.Println("被动模式: 不运行 I/O 写入测试、CPU 基准测试与内存缺页测试，仅采集只读指标")
+	}
+	virt, source := scoreAnalyzer.RecordVirtualization()
+	log.Printf("虚拟化平台: %s（依据 %s）", virt.DisplayName(), source)
+
+	// 创建定时器
+	cpuStealTicker := time.NewTicker(cpuStealInterval)
+	cpuBenchTicker := time.NewTicker(cpuBenchInterval)
+	ioTestTicker := time.NewTicker(ioTestInterval)
+	cleanupTicker := time.NewTicker(24 * time.Hour)
+	var cleanupRunning atomic.Bool
+	reportCheckTicker := time.NewTicker(1 * time.Minute) // 报告检查定时器
+	heartbeatTicker := time.NewTicker(heartbeatInterval)
+
+	// 机群汇总：仅协调者定期取出队列并发送汇总
+	var fleetFlushC <-chan time.Time
+	if alertQueue != nil && cfg.Fleet.Coordinator {
+		fleetFlushTicker := time.NewTicker(cfg.GetFleetWindow())
+		defer fleetFlushTicker.Stop()
+		fleetFlushC = fleetFlushTicker.C
+		log.Printf("机群告警汇总: 本机为协调者，汇总窗口 %v", cfg.GetFleetWindow())
+	}
+
+	// 定期 WAL 检查点：写入密集时避免 -wal 文件持续增长、自动检查点在写入路径上卡顿
+	var walCheckpointC <-chan time.Time
+	var checkpointRunning atomic.Bool
+	if interval := cfg.GetWALCheckpointInterval(); interval > 0 {
+		walCheckpointTicker := time.NewTicker(interval)
+		defer walCheckpointTicker.Stop()
+		walCheckpointC = walCheckpointTicker.C
+	}
+
+	// 解析日报时间
+	dailyTime, _ := time.Parse("15:04", cfg.Report.DailyTime)
+
+	// 信号处理
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+
+	// 按需报告：SIGUSR1 立即发送日报，SIGUSR2 立即发送周报（不影响定时报告的节奏）
+	// 启动时即注册，否则等待稳定与首次采集期间收到的信号会按默认行为终止进程；
+	// 这期间收到的信号留在通道中，报告 worker 就绪后由主循环处理
+	reportSigCh := make(chan os.Signal, 2)
+	signal.Notify(reportSigCh, syscall.SIGUSR1, syscall.SIGUSR2)
+	defer signal.Stop(reportSigCh)
+
+	// 启动后等待系统稳定再采集：等待期间记录 CPU 起点，使首次 Steal 覆盖整个等待窗口
+	if settle := cfg.GetStartupSettle(); settle > 0 {
+		if err := cpu.Prime(); err != nil {
+			log.Printf("记录 CPU 起点失败: %v", err)
+		}
+		log.Printf("等待 %v 让系统稳定后开始采集...", settle)
+		select {
+		case <-time.After(settle):
+		case sig := <-sigCh:
+			log.Printf("收到信号 %v，正在退出...", sig)
+			return
+		}
+	}
+
+	// 启动时先采集一次，样本标记为 startup，分析时可排除
+	collectAll(cpu, disk, mem, network, flaggedSink{metricSink: sink, flag: storage.FlagStartup}, &cfg.Collect)
+
+	// 自定义指标命令各自按间隔独立运行
+	customDone := make(chan struct{})
+	defer close(customDone)
+	for i := range cfg.Collect.CustomCommands {
+		go runCustomCommand(&cfg.Collect.CustomCommands[i], sink, customDone)
+	}
+
+	// I/O 卡顿探测独立运行：卡顿期间写入会阻塞，不能占用主循环
+	if interval, deadline := cfg.GetCanary(); interval > 0 {
+		log.Printf("I/O 卡顿探测: 每 %v 写入一次，超过 %v 记为卡顿", interval, deadline)
+		go runCanary(disk, interval, deadline, sink, customDone)
+	}
+
+	// 报告串行发送，网络缓慢时不会堆积 goroutine
+	reportDone := make(chan struct{})
+	defer close(reportDone)
+	reports := newReportWorker(func(job reportJob) {
+		if job.onDemand {
+			sendOnDemandReport(job.reportType, cfg, store, scoreAnalyzer, aiAnalyzer, telegramReporter)
+			return
+		}
+		sendScheduledReport(job.reportType, cfg, store, scoreAnalyzer, aiAnalyzer, telegramReporter, alertQueue)
+	}, reportDone)
+
+	// 上次发送报告的日期
+	var lastDailyReport, lastWeeklyReport, lastMonthlyReport time.Time
+
+	ioFailures := &ioFailureTracker{threshold: cfg.Alert.IOFailureAfter}
+
+	var stealTuner *stealIntervalTuner
+	if cfg.Collect.AdaptiveInterval {
+		minInterval, maxInterval := cfg.GetAdaptiveIntervalBounds()
+		stealTuner = newStealIntervalTuner(cpuStealInterval, minInterval, maxInterval)
+		cpuStealTicker.Reset(stealTuner.current)
+		log.Printf("自适应 Steal 采集间隔: %v ~ %v", minInterval, maxInterval)
+	}
+
+	for {
+		select {
+		case <-cpuStealTicker.C:
 			log.Println("[定时任务] 开始采集 CPU Steal/IOWait...")
 			if cpuUsage, err := cpu.Collect(); err == nil {
 				now := time.Now()
-				// 保存 Steal
-				store.Save(&storage.Metric{
-					Timestamp: now,
-					Type:      storage.MetricTypeCPUSteal,
-					Value:     cpuUsage.StealPercent,
-				})
-				// 保存 IOWait
-				store.Save(&storage.Metric{
-					Timestamp: now,
-					Type:      storage.MetricTypeCPUIoWait,
-					Value:     cpuUsage.IOWaitPercent,
+				saveSnapshot(sink, now, storage.SnapshotProcStat, cpuUsage.RawStat)
+				// Steal 与 IOWait 来自同一次采样，同一事务写入
+				err := sink.SaveBatch([]*storage.Metric{
+					stealMetric(now, cpuUsage),
+					iowaitMetric(now, cpuUsage),
 				})
+				if err != nil && !errors.Is(err, storage.ErrWriteDegraded) {
+					log.Printf("[定时任务] 保存 CPU 指标失败: %v", err)
+				}
 				log.Printf("CPU Steal: %.2f%%, IOWait: %.2f%%", cpuUsage.StealPercent, cpuUsage.IOWaitPercent)
+				if stealTuner != nil && !cpuUsage.Suspended() {
+					if interval, changed := stealTuner.observe(cpuUsage.StealPercent); changed {
+						cpuStealTicker.Reset(interval)
+					}
+				}
 			} else {
 				log.Printf("[定时任务] CPU 采集失败: %v", err)
+				recordCollectError(sink, storage.MetricTypeCPUSteal, err)
 			}
 
-			// Load Average 采集
-			if loadResult, err := collector.CollectLoadAverage(); err == nil {
-				numCPU := float64(runtime.NumCPU())
-				store.Save(&storage.Metric{
+			collectNetwork(network, sink, time.Now())
+			collectNUMA(sink, time.Now())
+			collectSchedStat(sink, time.Now())
+
+			// Load Average 采集（按实时在线 vCPU 数归一化）
+			numCPU := collectOnlineCPUs(sink, time.Now())
+			if loadResult, err := collector.CollectLoadAverage(collector.DefaultProcPath); err == nil {
+				saveSnapshot(sink, time.Now(), storage.SnapshotLoadAvg, loadResult.Raw)
+				sink.Save(&storage.Metric{
 					Timestamp: time.Now(),
 					Type:      storage.MetricTypeCPULoad,
 					Value:     loadResult.Load1 / numCPU,
 				})
+				saveRunQueue(sink, time.Now(), loadResult, numCPU)
 			} else {
 				log.Printf("[定时任务] Load Average 采集失败: %v", err)
+				recordCollectError(sink, storage.MetricTypeCPULoad, err)
 			}
 
 		case <-cpuBenchTicker.C:
-			log.Println("[定时任务] 开始 CPU 基准测试...")
-			if result, err := cpu.RunBenchmark(); err == nil {
-				store.Save(&storage.Metric{
-					Timestamp: time.Now(),
-					Type:      storage.MetricTypeCPUBench,
-					Value:     result.DurationMs,
-				})
-				log.Printf("CPU Bench: %.2fms", result.DurationMs)
-			} else {
-				log.Printf("[定时任务] CPU 基准测试失败: %v", err)
+			if !cfg.Collect.PassiveOnly {
+				log.Println("[定时任务] 开始 CPU 基准测试...")
+				if result, err := cpu.RunBenchmark(); err == nil {
+					sink.Save(&storage.Metric{
+						Timestamp: time.Now(),
+						Type:      storage.MetricTypeCPUBench,
+						Value:     result.DurationMs,
+					})
+					log.Printf("CPU Bench: %.2fms", result.DurationMs)
+				} else {
+					log.Printf("[定时任务] CPU 基准测试失败: %v", err)
+					recordCollectError(sink, storage.MetricTypeCPUBench, err)
+				}
+				collectMemoryFault(mem, sink)
 			}
+			collectCPUTemperature(sink)
 
 		case <-ioTestTicker.C:
-			log.Println("[定时任务] 开始 I/O 测试...")
-			if result, err := disk.TestWriteLatency(); err == nil {
-				store.Save(&storage.Metric{
-					Timestamp: time.Now(),
-					Type:      storage.MetricTypeIOLatency,
-					Value:     result.TotalLatencyMs,
-				})
-				log.Printf("I/O Latency: %.2fms", result.TotalLatencyMs)
-			} else {
-				log.Printf("[定时任务] I/O 延迟测试失败: %v", err)
-			}
-			// 随机 IO 测试
-			if result, err := disk.TestRandomIO(); err == nil {
-				store.Save(&storage.Metric{
-					Timestamp: time.Now(),
-					Type:      storage.MetricTypeRandomIO,
-					Value:     result.RandomWriteLatencyMs,
-					Extra: map[string]interface{}{
-						"write_latency_ms": result.RandomWriteLatencyMs,
-						"read_latency_ms":  result.RandomReadLatencyMs,
-					},
-				})
-				log.Printf("Random I/O: Write=%.2fms, Read=%.2fms", result.RandomWriteLatencyMs, result.RandomReadLatencyMs)
-			} else {
-				log.Printf("[定时任务] 随机 I/O 测试失败: %v", err)
+			if !cfg.Collect.PassiveOnly {
+				log.Println("[定时任务] 开始 I/O 测试...")
+				cpu.BeginSelfTest()
+				if result, err := disk.TestWriteLatency(); err == nil {
+					sink.Save(&storage.Metric{
+						Timestamp: time.Now(),
+						Type:      storage.MetricTypeIOLatency,
+						Value:     result.TotalLatencyMs,
+						Extra: map[string]interface{}{
+							"write_latency_ms": result.WriteLatencyMs,
+							"sync_latency_ms":  result.SyncLatencyMs,
+							"fs_type":          disk.FilesystemType(),
+							"samples":          result.Samples,
+							"spread_ms":        result.SpreadMs,
+						},
+					})
+					log.Printf("I/O Latency: %.2fms", result.TotalLatencyMs)
+					ioFailures.record(nil, cfg.Hostname, telegramReporter)
+				} else {
+					log.Printf("[定时任务] I/O 延迟测试失败: %v", err)
+					recordCollectError(sink, storage.MetricTypeIOLatency, err)
+					ioFailures.record(err, cfg.Hostname, telegramReporter)
+				}
+				// 随机 IO 测试
+				if cfg.Collect.RandomIOTest {
+					if result, err := disk.TestRandomIO(); err == nil {
+						sink.Save(&storage.Metric{
+							Timestamp: time.Now(),
+							Type:      storage.MetricTypeRandomIO,
+							Value:     result.RandomWriteLatencyMs,
+							Extra: map[string]interface{}{
+								"write_latency_ms": result.RandomWriteLatencyMs,
+								"read_latency_ms":  result.RandomReadLatencyMs,
+								"fs_type":          disk.FilesystemType(),
+								"samples":          result.Samples,
+								"spread_ms":        result.SpreadMs,
+							},
+						})
+						log.Printf("Random I/O: Write=%.2fms, Read=%.2fms", result.RandomWriteLatencyMs, result.RandomReadLatencyMs)
+					} else {
+						log.Printf("[定时任务] 随机 I/O 测试失败: %v", err)
+						recordCollectError(sink, storage.MetricTypeRandomIO, err)
+					}
+				}
+				if cfg.Collect.SyncProbe {
+					collectSyncProbe(disk, sink)
+				}
+				cpu.EndSelfTest()
 			}
 			// 同时采集内存
 			if stats, err := mem.Collect(); err == nil {
-				store.Save(&storage.Metric{
+				sink.Save(&storage.Metric{
 					Timestamp: time.Now(),
 					Type:      storage.MetricTypeMemory,
 					Value:     stats.UsagePercent(),
@@ -368,10 +1389,11 @@ func runDaemon(cfg *config.Config, cpu *collector.CPUCollector, disk *collector.
 				})
 			} else {
 				log.Printf("[定时任务] 内存采集失败: %v", err)
+				recordCollectError(sink, storage.MetricTypeMemory, err)
 			}
 			// 磁盘统计（从 /proc/diskstats 采集，开销极低）
 			if diskStats, err := disk.CollectDiskStats(); err == nil {
-				store.Save(&storage.Metric{
+				m := &storage.Metric{
 					Timestamp: time.Now(),
 					Type:      storage.MetricTypeDiskStats,
 					Value:     float64(diskStats.IOTimeMs),
@@ -382,20 +1404,53 @@ func runDaemon(cfg *config.Config, cpu *collector.CPUCollector, disk *collector.
 						"write_bytes":    diskStats.WriteBytes,
 						"io_time_ms":     diskStats.IOTimeMs,
 						"weighted_io_ms": diskStats.WeightedIOMs,
+						"read_merges":    diskStats.ReadMerges,
+						"write_merges":   diskStats.WriteMerges,
+						"in_flight":      diskStats.InFlight,
 					},
-				})
+				}
+				if diskStats.BusyValid {
+					m.Extra["busy_percent"] = diskStats.BusyPercent
+				}
+				sink.Save(m)
 				log.Printf("Disk Stats: ReadOps=%d, WriteOps=%d", diskStats.ReadOps, diskStats.WriteOps)
 			} else {
 				log.Printf("[定时任务] 磁盘统计采集失败: %v", err)
+				recordCollectError(sink, storage.MetricTypeDiskStats, err)
+			}
+
+		case <-heartbeatTicker.C:
+			logHeartbeat(sink)
+
+		case <-walCheckpointC:
+			// 检查点需要等待读写事务结束，在后台执行，上一次未结束时跳过
+			if !checkpointRunning.CompareAndSwap(false, true) {
+				log.Println("[定时任务] 上一次 WAL 检查点仍在进行，跳过")
+				continue
 			}
+			go func() {
+				defer checkpointRunning.Store(false)
+				if busy, frames, err := store.CheckpointWAL(); err != nil {
+					log.Printf("[定时任务] %v", err)
+				} else if busy {
+					log.Printf("[定时任务] WAL 检查点未完成（%d 页，有事务占用），下次重试", frames)
+				}
+			}()
 
 		case <-cleanupTicker.C:
-			deleted, err := store.Cleanup(cfg.Storage.RetentionDays)
-			if err != nil {
-				log.Printf("清理过期数据失败: %v", err)
-			} else if deleted > 0 {
-				log.Printf("已清理 %d 条过期数据", deleted)
+			if sink.Degraded() {
+				log.Println("[定时任务] 数据库处于降级模式，跳过过期数据清理")
+				continue
+			}
+			// 清理在后台执行：分批删除期间主循环继续采集，上一次清理未结束时跳过
+			if !cleanupRunning.CompareAndSwap(false, true) {
+				log.Println("[定时任务] 上一次过期数据清理仍在进行，跳过")
+				continue
 			}
+			go func() {
+				defer cleanupRunning.Store(false)
+				cleanupExpired(store, cfg.Storage.RetentionDays, cfg.GetRawRetention(), cfg.Storage.MaxSizeMB)
+			}()
 
 		case <-reportCheckTicker.C:
 			// 检查是否需要发送报告
@@ -404,7 +1459,7 @@ func runDaemon(cfg *config.Config, cpu *collector.CPUCollector, disk *collector.
 			// 日报
 			if cfg.Report.Daily && now.Hour() == dailyTime.Hour() && now.Minute() == dailyTime.Minute() {
 				if lastDailyReport.Day() != now.Day() {
-					go sendScheduledReport("daily", scoreAnalyzer, aiAnalyzer, telegramReporter)
+					reports.submit(reportJob{reportType: "daily"})
 					lastDailyReport = now
 				}
 			}
@@ -412,7 +1467,7 @@ func runDaemon(cfg *config.Config, cpu *collector.CPUCollector, disk *collector.
 			// 周报 (指定星期)
 			if cfg.Report.Weekly && int(now.Weekday()) == cfg.Report.WeeklyDay && now.Hour() == dailyTime.Hour() {
 				if lastWeeklyReport.YearDay() != now.YearDay() {
-					go sendScheduledReport("weekly", scoreAnalyzer, aiAnalyzer, telegramReporter)
+					reports.submit(reportJob{reportType: "weekly"})
 					lastWeeklyReport = now
 				}
 			}
@@ -420,11 +1475,22 @@ func runDaemon(cfg *config.Config, cpu *collector.CPUCollector, disk *collector.
 			// 月报 (指定日期)
 			if cfg.Report.Monthly && now.Day() == cfg.Report.MonthlyDay && now.Hour() == dailyTime.Hour() {
 				if lastMonthlyReport.Month() != now.Month() {
-					go sendScheduledReport("monthly", scoreAnalyzer, aiAnalyzer, telegramReporter)
+					reports.submit(reportJob{reportType: "monthly"})
 					lastMonthlyReport = now
 				}
 			}
 
+		case <-fleetFlushC:
+			flushFleetAlerts(store, alertQueue, telegramReporter)
+
+		case sig := <-reportSigCh:
+			reportType := "daily"
+			if sig == syscall.SIGUSR2 {
+				reportType = "weekly"
+			}
+			log.Printf("收到信号 %v，立即生成 %s 报告", sig, reportType)
+			reports.submit(reportJob{reportType: reportType, onDemand: true})
+
 		case sig := <-sigCh:
 			log.Printf("收到信号 %v，正在退出...", sig)
 			cpuStealTicker.Stop()
@@ -432,13 +1498,291 @@ func runDaemon(cfg *config.Config, cpu *collector.CPUCollector, disk *collector.
 			ioTestTicker.Stop()
 			cleanupTicker.Stop()
 			reportCheckTicker.Stop()
+			heartbeatTicker.Stop()
 			return
 		}
 	}
 }
 
+// heartbeatInterval 守护进程输出采集心跳日志的间隔
+const heartbeatInterval = time.Hour
+
+// logHeartbeat 输出一行采集心跳：上一个间隔内各类指标采集/写入的样本数与采集失败次数
+// 不开调试日志也能在 journald 中确认采集正常，某类指标停止流入时一眼可见
+func logHeartbeat(sink *storage.WriteGuard) {
+	counts, errs := sink.TakeCounts()
+	types := make([]string, 0, len(counts))
+	for t := range counts {
+		if t != storage.MetricTypeCollectError {
+			types = append(types, string(t))
+		}
+	}
+	sort.Strings(types)
+
+	parts := make([]string, 0, len(types))
+	for _, t := range types {
+		c := counts[storage.MetricType(t)]
+		if c.Stored == c.Collected {
+			parts = append(parts, fmt.Sprintf("%s=%d", t, c.Collected))
+		} else {
+			parts = append(parts, fmt.Sprintf("%s=%d(写入 %d)", t, c.Collected, c.Stored))
+		}
+	}
+	if len(parts) == 0 {
+		parts = append(parts, "无")
+	}
+	line := fmt.Sprintf("[心跳] 近 %.0f 小时采集样本: %s", heartbeatInterval.Hours(), strings.Join(parts, " "))
+
+	if len(errs) > 0 {
+		names := make([]string, 0, len(errs))
+		for name := range errs {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		failures := make([]string, len(names))
+		for i, name := range names {
+			failures[i] = fmt.Sprintf("%s=%d", name, errs[name])
+		}
+		line += fmt.Sprintf("；采集失败: %s", strings.Join(failures, " "))
+	}
+	if sink.Degraded() {
+		line += "；数据库处于降级模式"
+	}
+	log.Print(line)
+}
+
+// cleanupExpired 将超出 rawRetention 的原始样本聚合为小时数据（rawRetention 为 0 时跳过），
+// 再分批清理过期数据；配置了 maxSizeMB 且仍超出时继续删除低价值数据并收紧保留期。
+// 有删除时回收数据库空间
+func cleanupExpired(store *storage.Storage, retentionDays int, rawRetention time.Duration, maxSizeMB int) {
+	if rawRetention > 0 {
+		if rolled, err := store.Rollup(time.Now().Add(-rawRetention)); err != nil {
+			log.Printf("聚合历史数据失败: %v", err)
+		} else if rolled > 0 {
+			log.Printf("已将 %d 条原始样本聚合为小时数据", rolled)
+		}
+	}
+
+	deleted, err := store.Cleanup(retentionDays)
+	if err != nil {
+		log.Printf("清理过期数据失败: %v", err)
+		return
+	}
+	if deleted > 0 {
+		log.Printf("已清理 %d 条过期数据", deleted)
+	}
+
+	// 容量上限：数据库本身不能成为磁盘写满的原因
+	var dropped []string
+	if maxSizeMB > 0 {
+		dropped, err = store.EnforceMaxSize(int64(maxSizeMB)*1024*1024, retentionDays)
+		for _, d := range dropped {
+			log.Printf("⚠️ 数据库超过容量上限 %d MB，已删除 %s", maxSizeMB, d)
+		}
+		if err != nil {
+			log.Printf("数据库容量控制失败: %v", err)
+		}
+	}
+
+	if deleted == 0 && len(dropped) == 0 {
+		return
+	}
+	if reclaimed, err := store.Reclaim(); err != nil {
+		log.Printf("回收数据库空间失败: %v", err)
+	} else if reclaimed > 0 {
+		log.Printf("已回收数据库空间 %.1f MB", float64(reclaimed)/1024/1024)
+	}
+}
+
+// 自适应采集间隔参数
+const (
+	adaptiveWindow         = 6   // 每积累多少个样本评估一次波动
+	adaptiveVolatileStdDev = 2.0 // Steal 标准差（百分点）高于此值视为波动，间隔减半
+	adaptiveStableStdDev   = 0.5 // 低于此值视为平稳，间隔加倍
+	adaptiveCooldown       = 3   // 调整后至少积累多少个新样本再评估
+)
+
+// stealIntervalTuner 根据近期 Steal 波动调整采集间隔
+// 波动大的机器正处于争抢中，加密采样才能捕捉尖峰；平稳的机器拉长间隔以减少自身开销。
+// 波动按最近 adaptiveWindow 个样本的滑动窗口计算；每次调整后至少再积累 adaptiveCooldown 个
+// 新样本才重新评估，避免同一批样本连续触发多次调整。
+type stealIntervalTuner struct {
+	current, min, max time.Duration
+	window            []float64
+	sinceChange       int
+}
+
+// newStealIntervalTuner 创建间隔调节器，初始间隔限制在 [min, max] 内
+func newStealIntervalTuner(initial, min, max time.Duration) *stealIntervalTuner {
+	initial = clampDuration(initial, min, max)
+	return &stealIntervalTuner{current: initial, min: min, max: max, sinceChange: adaptiveCooldown}
+}
+
+// observe 记录一次 Steal 样本，间隔需要调整时返回新间隔与 true
+func (t *stealIntervalTuner) observe(steal float64) (time.Duration, bool) {
+	t.window = append(t.window, steal)
+	if len(t.window) > adaptiveWindow {
+		t.window = t.window[len(t.window)-adaptiveWindow:]
+	}
+	t.sinceChange++
+	if len(t.window) < adaptiveWindow || t.sinceChange < adaptiveCooldown {
+		return t.current, false
+	}
+
+	mean := 0.0
+	for _, v := range t.window {
+		mean += v
+	}
+	mean /= float64(len(t.window))
+	variance := 0.0
+	for _, v := range t.window {
+		variance += (v - mean) * (v - mean)
+	}
+	stddev := math.Sqrt(variance / float64(len(t.window)))
+
+	next := t.current
+	switch {
+	case stddev >= adaptiveVolatileStdDev:
+		next = clampDuration(t.current/2, t.min, t.max)
+	case stddev < adaptiveStableStdDev:
+		next = clampDuration(t.current*2, t.min, t.max)
+	}
+	if next == t.current {
+		return t.current, false
+	}
+	log.Printf("近期 Steal 标准差 %.2f，采集间隔 %v → %v", stddev, t.current, next)
+	t.current = next
+	t.sinceChange = 0
+	return next, true
+}
+
+// clampDuration 将时长限制在 [min, max] 内
+func clampDuration(d, min, max time.Duration) time.Duration {
+	if d < min {
+		return min
+	}
+	if d > max {
+		return max
+	}
+	return d
+}
+
+// ioFailureTracker 统计 I/O 写入测试的连续失败次数
+// 达到阈值时立即告警（持续失败期间只告警一次），之后首次成功时发送恢复通知。
+// 磁盘拒绝写入（只读重新挂载、写满）比延迟偏高更紧急，不等待定时报告。
+type ioFailureTracker struct {
+	threshold int // 0 表示关闭
+	count     int
+	alerted   bool
+}
+
+// record 记录一次写入测试结果，需要告警或恢复通知时在后台发送，不阻塞采集循环
+func (t *ioFailureTracker) record(err error, hostname string, telegramReporter *reporter.TelegramReporter) {
+	if t.threshold <= 0 {
+		return
+	}
+
+	if err == nil {
+		if t.alerted {
+			text := fmt.Sprintf("✅ 磁盘写入测试已恢复 | 🖥️ %s\n此前连续失败 %d 次", hostname, t.count)
+			go sendIOFailureAlert(telegramReporter, text)
+		}
+		t.count, t.alerted = 0, false
+		return
+	}
+
+	t.count++
+	if t.count < t.threshold || t.alerted {
+		return
+	}
+	t.alerted = true
+	log.Printf("I/O 写入测试连续失败 %d 次，发送告警", t.count)
+	text := fmt.Sprintf("🔴 磁盘写入测试连续失败，可能磁盘故障或只读 | 🖥️ %s\n连续失败: %d 次\n最近错误: %v", hostname, t.count, err)
+	go sendIOFailureAlert(telegramReporter, text)
+}
+
+// sendIOFailureAlert 发送磁盘写入告警/恢复通知
+func sendIOFailureAlert(telegramReporter *reporter.TelegramReporter, text string) {
+	if err := telegramReporter.SendText(text); err != nil {
+		log.Printf("发送磁盘写入告警失败: %v", err)
+	}
+}
+
+// reportWorkerQueueSize 报告队列容量（日报、周报、月报可能在同一分钟触发，另有按需日报/周报）
+const reportWorkerQueueSize = 5
+
+// reportJob 报告任务
+// 按需报告（SIGUSR1/SIGUSR2）只分析并发送，不记录风险等级、不受 only_on_issue 与积累期拦截，
+// 避免手动查看一次就推进连续恶化计数、评分趋势与滞回基准或触发告警命令
+type reportJob struct {
+	reportType string
+	onDemand   bool
+}
+
+func (j reportJob) String() string {
+	if j.onDemand {
+		return j.reportType + "（按需）"
+	}
+	return j.reportType
+}
+
+// reportWorker 报告发送队列
+// 所有报告由单个 goroutine 串行发送（同一时刻最多一个在途），
+// 避免 Telegram 缓慢时并发发送争用分析器和数据库；
+// 同类型报告已在排队或发送中时，新的触发直接丢弃。
+type reportWorker struct {
+	queue   chan reportJob
+	mu      sync.Mutex
+	pending map[reportJob]bool
+}
+
+// newReportWorker 创建报告队列并启动发送 goroutine，done 关闭后退出
+func newReportWorker(send func(job reportJob), done <-chan struct{}) *reportWorker {
+	w := &reportWorker{
+		queue:   make(chan reportJob, reportWorkerQueueSize),
+		pending: make(map[reportJob]bool),
+	}
+
+	go func() {
+		for {
+			select {
+			case job := <-w.queue:
+				send(job)
+				w.mu.Lock()
+				delete(w.pending, job)
+				w.mu.Unlock()
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return w
+}
+
+// submit 提交报告任务，不阻塞；被丢弃时返回 false
+func (w *reportWorker) submit(job reportJob) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.pending[job] {
+		log.Printf("%s 报告已在排队或发送中，跳过本次触发", job)
+		return false
+	}
+
+	select {
+	case w.queue <- job:
+		w.pending[job] = true
+		return true
+	default:
+		log.Printf("报告队列已满，丢弃 %s 报告", job)
+		return false
+	}
+}
+
 // sendScheduledReport 发送定时报告
-func sendScheduledReport(reportType string, scoreAnalyzer *analyzer.Analyzer, aiAnalyzer *analyzer.AIAnalyzer, telegramReporter *reporter.TelegramReporter) {
+// 启用机群汇总时，严重报告写入共享队列，由协调者合并发送
+func sendScheduledReport(reportType string, cfg *config.Config, store *storage.Storage, scoreAnalyzer *analyzer.Analyzer, aiAnalyzer *analyzer.AIAnalyzer, telegramReporter *reporter.TelegramReporter, alertQueue *storage.AlertQueue) {
 	var start, end time.Time
 	end = time.Now()
 
@@ -451,17 +1795,365 @@ func sendScheduledReport(reportType string, scoreAnalyzer *analyzer.Analyzer, ai
 		start = end.AddDate(0, -1, 0)
 	}
 
-	stats, err := scoreAnalyzer.AnalyzePeriod(reportType, start, end)
+	if warmingUp(cfg, store, telegramReporter, reportType) {
+		return
+	}
+
+	stats, err := analyzeWithRetry(scoreAnalyzer, reportType, start, end)
+	if errors.Is(err, analyzer.ErrNoData) {
+		loc := locale.New(cfg.Report.Locale, cfg.Report.DecimalSeparator)
+		period := loc.T(reporter.PeriodName(reportType))
+		log.Print(loc.Sprintf("%s 周期内没有采集数据，跳过报告", period))
+		notice := loc.Sprintf("⚠️ %s | 🖥️ %s\n%s 至 %s 没有采集数据，本期未生成报告，请检查采集服务是否在运行",
+			period, cfg.Hostname, start.Format("01-02 15:04"), end.Format("01-02 15:04"))
+		if err := telegramReporter.SendText(notice); err != nil {
+			log.Printf("发送无数据提醒失败: %v", err)
+		}
+		return
+	}
 	if err != nil {
-		log.Printf("分析 %s 数据失败: %v", reportType, err)
+		log.Printf("分析 %s 数据失败（已重试 %d 次），本期报告未发送: %v", reportType, reportAnalyzeAttempts, err)
+		recordDelivery(store, reportType, end, deliveryTelegram, fmt.Errorf("分析失败: %w", err))
+		return
+	}
+
+	checkRiskTransition(cfg, scoreAnalyzer, stats)
+
+	if reportSuppressed(cfg, reportType, stats) {
+		log.Printf("%s 报告风险等级为 %s，未达到 %s，按 only_on_issue 跳过发送", reportType, stats.RiskLevel, cfg.Report.IssueLevel)
+		writeJSONReport(cfg, store, stats, "")
+		writeHTMLReport(cfg, store, telegramReporter, stats, "")
 		return
 	}
 
 	aiAnalysis, _ := aiAnalyzer.Analyze(stats, reportType)
 
-	if err := telegramReporter.SendReport(stats, aiAnalysis); err != nil {
+	writeJSONReport(cfg, store, stats, aiAnalysis)
+	writeHTMLReport(cfg, store, telegramReporter, stats, aiAnalysis)
+
+	if alertQueue != nil && stats.RiskLevel == analyzer.RiskLevelSevere {
+		err := alertQueue.Enqueue(&storage.QueuedAlert{
+			Timestamp: time.Now(),
+			Hostname:  cfg.Hostname,
+			Target:    telegramReporter.Target(),
+			Period:    reportType,
+			Score:     stats.TotalScore,
+			Summary:   reporter.FormatAlertSummary(stats),
+			Report:    telegramReporter.FormatReport(stats, aiAnalysis),
+		})
+		recordDelivery(store, stats.Period, stats.EndTime, deliveryFleet, err)
+		if err == nil {
+			log.Printf("%s 报告评分严重，已写入机群告警队列等待汇总", reportType)
+			return
+		}
+		// 入队失败时降级为直接发送，避免告警丢失
+		log.Printf("写入机群告警队列失败，改为直接发送: %v", err)
+	}
+
+	err = telegramReporter.SendReport(stats, aiAnalysis)
+	recordDelivery(store, stats.Period, stats.EndTime, deliveryTelegram, err)
+	if err != nil {
 		log.Printf("发送 %s 报告失败: %v", reportType, err)
 	} else {
 		log.Printf("%s 报告已发送", reportType)
 	}
 }
+
+// sendOnDemandReport 发送按需报告（SIGUSR1/SIGUSR2）
+// 与定时报告不同：不记录风险等级（连续恶化计数、评分趋势、滞回基准均不变，也不触发告警命令），
+// 不受 only_on_issue 与积累期拦截，不进入机群告警队列
+func sendOnDemandReport(reportType string, cfg *config.Config, store *storage.Storage, scoreAnalyzer *analyzer.Analyzer, aiAnalyzer *analyzer.AIAnalyzer, telegramReporter *reporter.TelegramReporter) {
+	end := time.Now()
+	start := end.AddDate(0, 0, -1)
+	if reportType == "weekly" {
+		start = end.AddDate(0, 0, -7)
+	}
+
+	stats, err := analyzeWithRetry(scoreAnalyzer, reportType, start, end)
+	if errors.Is(err, analyzer.ErrNoData) {
+		log.Printf("%s 周期内没有采集数据，按需报告未发送", reportType)
+		notice := fmt.Sprintf("⚠️ %s | 🖥️ %s\n%s 至 %s 没有采集数据，无法生成报告",
+			reportType, cfg.Hostname, start.Format("01-02 15:04"), end.Format("01-02 15:04"))
+		if err := telegramReporter.SendText(notice); err != nil {
+			log.Printf("发送无数据提醒失败: %v", err)
+		}
+		return
+	}
+	if err != nil {
+		log.Printf("分析 %s 数据失败，按需报告未发送: %v", reportType, err)
+		return
+	}
+
+	aiAnalysis, _ := aiAnalyzer.Analyze(stats, reportType)
+	writeJSONReport(cfg, store, stats, aiAnalysis)
+	writeHTMLReport(cfg, store, telegramReporter, stats, aiAnalysis)
+
+	err = telegramReporter.SendReport(stats, aiAnalysis)
+	recordDelivery(store, stats.Period, stats.EndTime, deliveryTelegram, err)
+	if err != nil {
+		log.Printf("发送 %s 按需报告失败: %v", reportType, err)
+	} else {
+		log.Printf("%s 按需报告已发送", reportType)
+	}
+}
+
+// warmupNoticeKey 记录"数据采集中"提醒已发送的状态键，只提醒一次
+const warmupNoticeKey = "warmup_notice_sent"
+
+// warmingUp 判断数据是否仍处于积累期（最早样本距今不足 report.min_history）
+// 积累期内跳过定时报告，避免刚安装时几乎没有数据的评分误导用户；首次跳过时发送一次提醒。
+// 数据库为空时不拦截，交由分析阶段按"没有采集数据"处理
+func warmingUp(cfg *config.Config, store *storage.Storage, telegramReporter *reporter.TelegramReporter, reportType string) bool {
+	minHistory := cfg.GetMinHistory()
+	if minHistory <= 0 {
+		return false
+	}
+	earliest, err := store.EarliestTimestamp()
+	if err != nil {
+		log.Printf("查询最早采集时间失败，不做积累期判断: %v", err)
+		return false
+	}
+	collected := time.Since(earliest)
+	if earliest.IsZero() || collected >= minHistory {
+		return false
+	}
+
+	log.Printf("数据仅积累 %.1f 小时（不足 %s），跳过 %s 报告", collected.Hours(), cfg.Report.MinHistory, reportType)
+	if _, _, sent, err := store.GetState(warmupNoticeKey); err != nil || sent {
+		return true
+	}
+	notice := fmt.Sprintf("⏳ %s | 🖥️ %s\n数据采集中，报告将于积累足够数据后开始（已采集 %.1f 小时，需要 %s）",
+		reportType, cfg.Hostname, collected.Hours(), cfg.Report.MinHistory)
+	if err := telegramReporter.SendText(notice); err != nil {
+		log.Printf("发送数据采集中提醒失败: %v", err)
+		return true
+	}
+	if err := store.SetState(warmupNoticeKey, earliest.Format(time.RFC3339)); err != nil {
+		log.Printf("记录提醒状态失败: %v", err)
+	}
+	return true
+}
+
+// reportSuppressed 判断 report.only_on_issue 模式下是否跳过本期定时报告：
+// 风险等级未达到 issue_level 时跳过，开启 always_weekly 时周报始终发送
+func reportSuppressed(cfg *config.Config, reportType string, stats *analyzer.PeriodStats) bool {
+	if !cfg.Report.OnlyOnIssue {
+		return false
+	}
+	if reportType == "weekly" && cfg.Report.AlwaysWeekly {
+		return false
+	}
+	return stats.RiskLevel.Severity() < analyzer.RiskLevel(cfg.Report.IssueLevel).Severity()
+}
+
+// 定时报告分析的重试参数：查询错误（如数据库被长事务短暂锁定）时按指数退避重试，
+// 避免一次瞬时故障导致当期报告静默丢失
+const (
+	reportAnalyzeAttempts = 4
+	reportAnalyzeBackoff  = 30 * time.Second // 之后每次翻倍：30s、1m、2m
+)
+
+// analyzeWithRetry 分析报告周期，查询失败时重试；没有数据（analyzer.ErrNoData）不重试
+func analyzeWithRetry(scoreAnalyzer *analyzer.Analyzer, reportType string, start, end time.Time) (*analyzer.PeriodStats, error) {
+	wait := reportAnalyzeBackoff
+	for attempt := 1; ; attempt++ {
+		stats, err := scoreAnalyzer.AnalyzePeriod(reportType, start, end)
+		if err == nil || errors.Is(err, analyzer.ErrNoData) || attempt == reportAnalyzeAttempts {
+			return stats, err
+		}
+		log.Printf("分析 %s 数据失败（第 %d 次），%s 后重试: %v", reportType, attempt, wait, err)
+		time.Sleep(wait)
+		wait *= 2
+	}
+}
+
+// checkRiskTransition 风险等级恶化越过阈值时执行用户配置的命令
+// 仅在等级跨越阈值的那一次触发，持续处于该等级时不重复执行；首次运行（无历史等级）不触发
+func checkRiskTransition(cfg *config.Config, scoreAnalyzer *analyzer.Analyzer, stats *analyzer.PeriodStats) {
+	previous, ok, err := scoreAnalyzer.RecordRiskLevel(stats)
+	if err != nil {
+		log.Printf("记录风险等级失败: %v", err)
+		return
+	}
+	if cfg.Alert.ExecOnTransition == "" || !ok {
+		return
+	}
+
+	threshold := analyzer.RiskLevel(cfg.Alert.Threshold).Severity()
+	if previous.Severity() >= threshold || stats.RiskLevel.Severity() < threshold {
+		return
+	}
+
+	log.Printf("%s 风险等级由 %s 恶化为 %s，执行告警命令", stats.Period, previous, stats.RiskLevel)
+	env := map[string]string{
+		"CHAOLEME_HOSTNAME":       cfg.Hostname,
+		"CHAOLEME_PERIOD":         stats.Period,
+		"CHAOLEME_SCORE":          fmt.Sprintf("%.1f", stats.TotalScore),
+		"CHAOLEME_RISK_LEVEL":     string(stats.RiskLevel),
+		"CHAOLEME_PREVIOUS_LEVEL": string(previous),
+		"CHAOLEME_TOP_RISK":       analyzer.TopRiskFactor(stats),
+	}
+	// 标签以 CHAOLEME_LABEL_<大写标签名> 传入
+	for k, v := range cfg.Labels {
+		env["CHAOLEME_LABEL_"+strings.ToUpper(k)] = v
+	}
+	err = reporter.RunHook(cfg.Alert.ExecOnTransition, cfg.GetAlertExecTimeout(), env)
+	if err != nil {
+		log.Printf("告警命令执行失败: %v", err)
+		return
+	}
+	log.Printf("告警命令执行成功（退出码 0）")
+}
+
+// flushFleetAlerts 取出本目标的排队告警并发送汇总（仅协调者调用）
+func flushFleetAlerts(store *storage.Storage, alertQueue *storage.AlertQueue, telegramReporter *reporter.TelegramReporter) {
+	alerts, err := alertQueue.Pending(telegramReporter.Target())
+	if err != nil {
+		log.Printf("读取机群告警队列失败: %v", err)
+		return
+	}
+	if len(alerts) == 0 {
+		return
+	}
+
+	err = telegramReporter.SendDigest(alerts)
+	recordDelivery(store, deliveryPeriodDigest, time.Now(), deliveryTelegram, err)
+	if err != nil {
+		log.Printf("发送机群告警汇总失败，%d 条告警保留在队列中，下个窗口重试: %v", len(alerts), err)
+		return
+	}
+	// 发送成功后才从队列删除；删除失败时下个窗口会重复发送，重复优于丢失
+	if err := alertQueue.Ack(alerts); err != nil {
+		log.Printf("清理机群告警队列失败: %v", err)
+	}
+	log.Printf("已发送机群告警汇总（%d 条）", len(alerts))
+}
+
+// 投递渠道（report_deliveries.reporter）
+const (
+	deliveryTelegram = "telegram"
+	deliveryJSON     = "json"
+	deliveryHTML     = "html"
+	deliveryFleet    = "fleet" // 写入机群告警队列，由协调者汇总发送
+
+	deliveryPeriodDigest = "digest" // 机群告警汇总不对应单一报告周期
+)
+
+// recordDelivery 记录一次报告投递结果，写入失败仅记录日志
+func recordDelivery(store *storage.Storage, period string, reportAt time.Time, reporterName string, sendErr error) {
+	d := &storage.Delivery{
+		Timestamp: time.Now(),
+		Period:    period,
+		ReportAt:  reportAt,
+		Reporter:  reporterName,
+		Success:   sendErr == nil,
+	}
+	if sendErr != nil {
+		d.Error = sendErr.Error()
+	}
+	if err := store.SaveDelivery(d); err != nil {
+		log.Printf("记录报告投递结果失败: %v", err)
+	}
+}
+
+// formatMOTD 读取最近一次日报的评分与最新 Steal/写延迟样本，生成单行状态
+// 只做几次索引查询，不采集也不分析，适合在每次 SSH 登录时执行
+func formatMOTD(cfg *config.Config, store *storage.Storage, color bool) string {
+	status := &reporter.MOTDStatus{}
+	score, level, _, ok, err := analyzer.NewAnalyzer(store, cfg).LastReport("daily")
+	if err != nil {
+		log.Printf("读取最近评分失败: %v", err)
+	}
+	if ok {
+		status.Score, status.RiskLevel = score, level
+	}
+	status.Steal, _ = store.GetLatestMetric(storage.MetricTypeCPUSteal)
+	status.IOLatency, _ = store.GetLatestMetric(storage.MetricTypeIOLatency)
+	return reporter.FormatMOTD(locale.New(cfg.Report.Locale, cfg.Report.DecimalSeparator), status, time.Now(), color)
+}
+
+// runServe 启动只读 Web 面板，只查询数据库，可与守护进程同时运行
+func runServe(cfg *config.Config, store *storage.Storage, addr string) error {
+	scoreAnalyzer := analyzer.NewAnalyzer(store, cfg)
+	handler := reporter.NewDashboardHandler(func() (*reporter.DashboardStatus, error) {
+		return reporter.LoadDashboardStatus(store, scoreAnalyzer, cfg.Hostname, time.Now())
+	})
+
+	listener, err := listenServe(addr)
+	if err != nil {
+		return err
+	}
+	server := &http.Server{
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	if path, ok := strings.CutPrefix(addr, unixAddrPrefix); ok {
+		log.Printf("Web 面板已启动: Unix socket %s（curl --unix-socket %s http://localhost/api/status）", path, path)
+	} else {
+		log.Printf("Web 面板已启动: http://%s/", addr)
+	}
+	return server.Serve(listener)
+}
+
+// unixAddrPrefix -serve 地址使用 Unix socket 时的前缀，如 unix:/run/chaoleme.sock
+const unixAddrPrefix = "unix:"
+
+// listenServe 按 -serve 地址监听 TCP 端口或 Unix socket
+// Unix socket 仅属主可读写（0600），本机其他用户与网络均无法访问；
+// 上次异常退出遗留的 socket 文件会被移除，同名的普通文件则拒绝覆盖
+func listenServe(addr string) (net.Listener, error) {
+	path, ok := strings.CutPrefix(addr, unixAddrPrefix)
+	if !ok {
+		return net.Listen("tcp", addr)
+	}
+	if path == "" {
+		return nil, fmt.Errorf("unix socket 路径为空")
+	}
+
+	if info, err := os.Lstat(path); err == nil {
+		if info.Mode()&os.ModeSocket == 0 {
+			return nil, fmt.Errorf("%s 已存在且不是 socket 文件", path)
+		}
+		// 仍能连上说明另一个实例正在监听，不能删掉它的 socket
+		if conn, err := net.DialTimeout("unix", path, time.Second); err == nil {
+			conn.Close()
+			return nil, fmt.Errorf("%s 已有其他进程在监听", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("移除遗留的 socket 文件失败: %w", err)
+		}
+	}
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, 0600); err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("设置 socket 权限失败: %w", err)
+	}
+	return listener, nil
+}
+
+// printReportLog 打印最近 limit 条报告投递记录
+func printReportLog(store *storage.Storage, limit int) error {
+	deliveries, err := store.QueryDeliveries(limit)
+	if err != nil {
+		return err
+	}
+	if len(deliveries) == 0 {
+		fmt.Println("暂无报告投递记录")
+		return nil
+	}
+
+	fmt.Printf("%-19s  %-8s  %-16s  %-8s  %s\n", "投递时间", "报告", "周期结束", "渠道", "结果")
+	for _, d := range deliveries {
+		result := "✅ 成功"
+		if !d.Success {
+			result = "❌ " + d.Error
+		}
+		fmt.Printf("%-19s  %-8s  %-16s  %-8s  %s\n",
+			d.Timestamp.Format("2006-01-02 15:04:05"), d.Period, d.ReportAt.Format("2006-01-02 15:04"), d.Reporter, result)
+	}
+	return nil
+}
@@ -0,0 +1,237 @@
+package reporter
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Catker/chaoleme/analyzer"
+	"github.com/Catker/chaoleme/locale"
+	"github.com/Catker/chaoleme/storage"
+)
+
+// 面板趋势图参数
+const (
+	dashboardWindow = 24 * time.Hour
+	dashboardBucket = 10 * time.Minute
+)
+
+//go:embed dashboard.html
+var dashboardFS embed.FS
+
+// DashboardGauge 面板上的单项指标（取数据库中的最新样本）
+type DashboardGauge struct {
+	Key   string    `json:"key"`
+	Name  string    `json:"name"`
+	Unit  string    `json:"unit"`
+	Value float64   `json:"value"`
+	Max   float64   `json:"max"`   // 量表满刻度
+	Level string    `json:"level"` // ok / warn / bad
+	At    time.Time `json:"at"`
+}
+
+// DashboardPoint 趋势图中的一个点（桶内均值，时间为桶起点）
+type DashboardPoint struct {
+	Time  time.Time `json:"time"`
+	Value float64   `json:"value"`
+}
+
+// DashboardStatus 面板数据，页面与 /api/status 共用
+type DashboardStatus struct {
+	Hostname    string             `json:"hostname"`
+	GeneratedAt time.Time          `json:"generated_at"`
+	HasScore    bool               `json:"has_score"` // 是否已生成过日报
+	Score       float64            `json:"score"`
+	RiskLevel   analyzer.RiskLevel `json:"risk_level,omitempty"`
+	ScoredAt    time.Time          `json:"scored_at,omitempty"`
+	Gauges      []DashboardGauge   `json:"gauges"`
+	Steal       []DashboardPoint   `json:"steal_24h"`
+	Load        []DashboardPoint   `json:"load_24h"`
+}
+
+// dashboardGauges 面板展示的指标；阈值与评分满分/扣分档位一致（共享核心、SSD），仅用于着色
+var dashboardGauges = []struct {
+	key            string
+	metricType     storage.MetricType
+	name, unit     string
+	max            float64
+	warn, bad      float64
+	higherIsBetter bool
+	extra          string // 不为空时取 extra 中的字段而非主值
+}{
+	{"cpu_steal", storage.MetricTypeCPUSteal, "CPU Steal", "%", 20, 3, 8, false, ""},
+	{"cpu_iowait", storage.MetricTypeCPUIoWait, "CPU IOWait", "%", 40, 5, 15, false, ""},
+	{"cpu_load", storage.MetricTypeCPULoad, "Load (每 vCPU)", "", 2, 0.7, 1.0, false, ""},
+	{"io_latency", storage.MetricTypeIOLatency, "顺序写延迟", "ms", 200, 20, 50, false, ""},
+	{"random_io", storage.MetricTypeRandomIO, "随机写延迟", "ms", 150, 30, 80, false, ""},
+	{"memory", storage.MetricTypeMemory, "内存可用率", "%", 100, 80, 50, true, "available_percent"},
+}
+
+// LoadDashboardStatus 从数据库读取面板数据：最近一次日报评分、各项最新样本与近 24 小时 Steal/Load 趋势
+// 只读查询，不触发采集与分析
+func LoadDashboardStatus(store *storage.Storage, scoreAnalyzer *analyzer.Analyzer, hostname string, now time.Time) (*DashboardStatus, error) {
+	status := &DashboardStatus{Hostname: hostname, GeneratedAt: now}
+
+	score, level, at, ok, err := scoreAnalyzer.LastReport("daily")
+	if err != nil {
+		return nil, fmt.Errorf("读取最近评分失败: %w", err)
+	}
+	if ok {
+		status.HasScore, status.Score, status.RiskLevel, status.ScoredAt = true, score, level, at
+	}
+
+	for _, g := range dashboardGauges {
+		m, err := store.GetLatestMetric(g.metricType)
+		if err != nil {
+			return nil, err
+		}
+		if m == nil {
+			continue
+		}
+		value := m.Value
+		if g.extra != "" {
+			v, ok := m.Extra[g.extra].(float64)
+			if !ok {
+				continue
+			}
+			value = v
+		}
+
+		level := "ok"
+		if g.higherIsBetter {
+			switch {
+			case value <= g.bad:
+				level = "bad"
+			case value <= g.warn:
+				level = "warn"
+			}
+		} else {
+			switch {
+			case value >= g.bad:
+				level = "bad"
+			case value >= g.warn:
+				level = "warn"
+			}
+		}
+		status.Gauges = append(status.Gauges, DashboardGauge{
+			Key: g.key, Name: g.name, Unit: g.unit, Value: value, Max: g.max, Level: level, At: m.Timestamp,
+		})
+	}
+
+	if status.Steal, err = queryDashboardSeries(store, storage.MetricTypeCPUSteal, now); err != nil {
+		return nil, err
+	}
+	if status.Load, err = queryDashboardSeries(store, storage.MetricTypeCPULoad, now); err != nil {
+		return nil, err
+	}
+	return status, nil
+}
+
+// queryDashboardSeries 按 10 分钟分桶查询近 24 小时的序列
+func queryDashboardSeries(store *storage.Storage, metricType storage.MetricType, now time.Time) ([]DashboardPoint, error) {
+	values, times, err := store.QueryBucketed(metricType, now.Add(-dashboardWindow), now, dashboardBucket)
+	if err != nil {
+		return nil, err
+	}
+	points := make([]DashboardPoint, len(values))
+	for i := range values {
+		points[i] = DashboardPoint{Time: times[i], Value: values[i]}
+	}
+	return points, nil
+}
+
+// 趋势图画布尺寸（SVG viewBox）
+const (
+	chartWidth  = 720.0
+	chartHeight = 160.0
+)
+
+// dashboardChart 模板中渲染一条趋势折线所需的数据
+type dashboardChart struct {
+	Points string  // SVG polyline points
+	Max    float64 // 纵轴上限
+	Last   float64
+	Empty  bool
+}
+
+// buildChart 将序列映射到画布坐标；横轴固定为近 24 小时，纵轴上限取 floor 与序列最大值中较大者
+func buildChart(points []DashboardPoint, now time.Time, floor float64) dashboardChart {
+	chart := dashboardChart{Max: floor, Empty: len(points) == 0}
+	for _, p := range points {
+		if p.Value > chart.Max {
+			chart.Max = p.Value
+		}
+	}
+	if chart.Empty {
+		return chart
+	}
+	chart.Last = points[len(points)-1].Value
+
+	start := now.Add(-dashboardWindow)
+	coords := make([]string, len(points))
+	for i, p := range points {
+		x := p.Time.Sub(start).Seconds() / dashboardWindow.Seconds() * chartWidth
+		y := chartHeight - p.Value/chart.Max*chartHeight
+		coords[i] = fmt.Sprintf("%.1f,%.1f", x, y)
+	}
+	chart.Points = strings.Join(coords, " ")
+	return chart
+}
+
+var dashboardTemplate = template.Must(template.New("dashboard.html").Funcs(template.FuncMap{
+	"percentOf": func(v, max float64) float64 {
+		if max <= 0 {
+			return 0
+		}
+		return min(v/max*100, 100)
+	},
+	"riskLevel": describeRiskLevel,
+	"timeAgo": func(t time.Time) string {
+		return formatDuration(locale.Default(), time.Since(t).Round(time.Minute)) + "前"
+	},
+}).ParseFS(dashboardFS, "dashboard.html"))
+
+// NewDashboardHandler 创建 Web 面板：GET / 为 HTML 页面，GET /api/status 为同一份数据的 JSON
+// 页面不依赖外部 CDN 与脚本，隔离网络中也可使用
+func NewDashboardHandler(load func() (*DashboardStatus, error)) http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /api/status", func(w http.ResponseWriter, r *http.Request) {
+		status, err := load()
+		if err != nil {
+			log.Printf("读取面板数据失败: %v", err)
+			http.Error(w, "读取数据失败", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		json.NewEncoder(w).Encode(status)
+	})
+
+	mux.HandleFunc("GET /{$}", func(w http.ResponseWriter, r *http.Request) {
+		status, err := load()
+		if err != nil {
+			log.Printf("读取面板数据失败: %v", err)
+			http.Error(w, "读取数据失败", http.StatusInternalServerError)
+			return
+		}
+		data := struct {
+			*DashboardStatus
+			StealChart, LoadChart dashboardChart
+		}{
+			DashboardStatus: status,
+			StealChart:      buildChart(status.Steal, status.GeneratedAt, 5),
+			LoadChart:       buildChart(status.Load, status.GeneratedAt, 1),
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if err := dashboardTemplate.Execute(w, data); err != nil {
+			log.Printf("渲染面板失败: %v", err)
+		}
+	})
+
+	return mux
+}
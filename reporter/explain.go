@@ -0,0 +1,58 @@
+package reporter
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Catker/chaoleme/analyzer"
+	"github.com/Catker/chaoleme/locale"
+)
+
+// scoreItemNames 评分项显示名（键与 ScoreBreakdown 一致）
+var scoreItemNames = map[string]string{
+	"cpu_steal":     "CPU Steal",
+	"cpu_iowait":    "CPU IOWait",
+	"cpu_stability": "CPU 稳定性",
+	"io_latency":    "顺序写延迟",
+	"random_io":     "随机 I/O",
+	"disk_busy":     "磁盘繁忙度",
+	"memory":        "内存",
+	"baseline":      "基线偏离",
+}
+
+// FormatExplain 输出评分计算过程（-explain）：每项的聚合值、命中区间、档位分、加成、权重与加权贡献
+func FormatExplain(loc *locale.Locale, stats *analyzer.PeriodStats) string {
+	var b strings.Builder
+	b.WriteString(loc.Sprintf("评分计算过程 (%s, %s ~ %s)\n", loc.T(periodName(stats.Period)),
+		stats.StartTime.Format("2006-01-02 15:04"), stats.EndTime.Format("2006-01-02 15:04")))
+	if stats.PassiveOnly {
+		b.WriteString(loc.T("被动模式: CPU 稳定性、顺序写延迟、随机 I/O 不参与评分，其余项权重按比例放大\n"))
+	}
+	if stats.StealUntrusted {
+		b.WriteString(loc.Sprintf("%s 容器: Steal 不可信，权重减半，其余项权重按比例放大\n", loc.T(stats.Virtualization.DisplayName())))
+	}
+	b.WriteString(loc.Sprintf("超售可信度加成: ×%.2f（%s）\n\n", stats.ScoreBoost, stats.ScoreBoostReason))
+
+	for _, item := range stats.ScoreTrace {
+		name := scoreItemNames[item.Key]
+		if name == "" {
+			name = item.Key
+		}
+		fmt.Fprintf(&b, "%s\n", loc.T(name))
+		b.WriteString(loc.Sprintf("  聚合值: %s，区间 %s → 档位分 %.0f\n", item.Value, item.Bucket, item.SubScore))
+		if item.Boost > 1 {
+			b.WriteString(loc.Sprintf("  加成: %.0f ÷ %.2f = %.1f\n", item.SubScore, item.Boost, item.Score))
+		}
+		if item.Note != "" {
+			b.WriteString(loc.Sprintf("  说明: %s\n", item.Note))
+		}
+		b.WriteString(loc.Sprintf("  贡献: %.1f × %.0f%% = %.2f\n", item.Score, item.Weight*100, item.Contribution))
+	}
+
+	b.WriteString(loc.Sprintf("\n综合评分: %.1f → %s\n", stats.TotalScore, loc.T(describeRiskLevel(stats.RiskLevel))))
+	b.WriteString(loc.T("等级划分: ≥90 优秀，≥70 良好，≥50 中等，<50 严重\n"))
+	if stats.RiskLevelHeld {
+		b.WriteString(loc.T("等级滞回: 评分越过边界未超过 alert.level_hysteresis，沿用上次等级\n"))
+	}
+	return b.String()
+}
@@ -0,0 +1,37 @@
+package reporter
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// RunHook 执行用户配置的告警命令，env 以环境变量形式追加传入
+// 命令通过 /bin/sh -c 执行，超时后强制终止；失败时错误信息包含退出状态与 stderr
+func RunHook(command string, timeout time.Duration, env map[string]string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "/bin/sh", "-c", command)
+	cmd.Env = os.Environ()
+	for k, v := range env {
+		cmd.Env = append(cmd.Env, k+"="+v)
+	}
+	cmd.Stderr = &stderr
+	// 子进程残留持有管道时，超时后最多再等待 1s
+	cmd.WaitDelay = time.Second
+
+	if err := cmd.Run(); err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return fmt.Errorf("命令执行超时 (%v)", timeout)
+		}
+		return fmt.Errorf("命令执行失败: %w, stderr: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
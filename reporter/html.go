@@ -0,0 +1,145 @@
+package reporter
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"html/template"
+	"math"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/Catker/chaoleme/analyzer"
+)
+
+//go:embed report.html
+var htmlReportFS embed.FS
+
+// 半圆量表弧长（半径 50 的半圆，SVG stroke-dasharray 按弧长绘制进度）
+var gaugeArcLength = math.Pi * 50
+
+// htmlGauge 报告中的单个评分项量表
+type htmlGauge struct {
+	Name   string
+	Value  string  // 参与评分的聚合值
+	Score  float64 // 单项分 0-100
+	Weight float64
+}
+
+// htmlHourBar 小时分布柱状图中一个小时的两根柱子（SVG 坐标）
+type htmlHourBar struct {
+	Hour             int
+	X                float64
+	StealY, StealH   float64
+	IoWaitY, IoWaitH float64
+	StealAvg         float64
+	IoWaitAvg        float64
+}
+
+// 小时分布图画布尺寸（SVG viewBox，与面板趋势图同宽）
+const (
+	hourChartWidth  = 720.0
+	hourChartHeight = 160.0
+)
+
+// buildHourBars 将小时统计映射为柱状图坐标，纵轴上限取 floor 与各小时均值中较大者
+func buildHourBars(hours []analyzer.HourlyStats, floor float64) ([]htmlHourBar, float64) {
+	maxValue := floor
+	for _, h := range hours {
+		maxValue = max(maxValue, h.CPUStealAvg, h.CPUIoWaitAvg)
+	}
+	slot := hourChartWidth / 24
+	bars := make([]htmlHourBar, len(hours))
+	for i, h := range hours {
+		stealH := h.CPUStealAvg / maxValue * hourChartHeight
+		ioWaitH := h.CPUIoWaitAvg / maxValue * hourChartHeight
+		bars[i] = htmlHourBar{
+			Hour:      h.Hour,
+			X:         float64(h.Hour)*slot + 3,
+			StealY:    hourChartHeight - stealH,
+			StealH:    stealH,
+			IoWaitY:   hourChartHeight - ioWaitH,
+			IoWaitH:   ioWaitH,
+			StealAvg:  h.CPUStealAvg,
+			IoWaitAvg: h.CPUIoWaitAvg,
+		}
+	}
+	return bars, maxValue
+}
+
+// scoreClass 按分数返回量表着色（与风险等级分档一致）
+func scoreClass(score float64) string {
+	switch {
+	case score >= 70:
+		return "ok"
+	case score >= 50:
+		return "warn"
+	default:
+		return "bad"
+	}
+}
+
+var htmlReportTemplate = template.Must(template.New("report.html").Funcs(template.FuncMap{
+	"arc": func(score float64) string {
+		return fmt.Sprintf("%.1f %.1f", math.Max(0, math.Min(score, 100))/100*gaugeArcLength, gaugeArcLength)
+	},
+	"scoreClass": scoreClass,
+	"riskLevel":  describeRiskLevel,
+	"periodName": periodName,
+}).ParseFS(htmlReportFS, "report.html"))
+
+// FormatHTML 将周期统计渲染为自包含的 HTML 报告：评分量表与小时分布图为内嵌 SVG，
+// 不引用任何外部资源，离线可直接打开；text 为同一份数据的 Telegram 报告文本，附在页面末尾
+func FormatHTML(hostname string, stats *analyzer.PeriodStats, text string) ([]byte, error) {
+	gauges := make([]htmlGauge, 0, len(stats.ScoreTrace))
+	for _, item := range stats.ScoreTrace {
+		name := scoreItemNames[item.Key]
+		if name == "" {
+			name = item.Key
+		}
+		gauges = append(gauges, htmlGauge{Name: name, Value: item.Value, Score: item.Score, Weight: item.Weight * 100})
+	}
+	bars, barMax := buildHourBars(stats.HourlyBreakdown, 1)
+
+	data := struct {
+		Hostname    string
+		GeneratedAt time.Time
+		Stats       *analyzer.PeriodStats
+		Gauges      []htmlGauge
+		HourBars    []htmlHourBar
+		HourMax     float64
+		Text        string
+	}{hostname, time.Now(), stats, gauges, bars, barMax, text}
+
+	var buf bytes.Buffer
+	if err := htmlReportTemplate.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("渲染 HTML 报告失败: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// WriteHTMLReport 将 HTML 报告写入目录，文件名为 <period>-<结束时间>.html，返回写入路径
+// 先写临时文件再重命名，避免归档同步工具读到半截文件
+func WriteHTMLReport(dir, hostname string, stats *analyzer.PeriodStats, text string) (string, error) {
+	data, err := FormatHTML(hostname, stats, text)
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("创建 HTML 报告目录失败: %w", err)
+	}
+
+	path := filepath.Join(dir, fmt.Sprintf("%s-%s.html", stats.Period, stats.EndTime.Format("20060102-150405")))
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return "", fmt.Errorf("写入 HTML 报告失败: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return "", fmt.Errorf("写入 HTML 报告失败: %w", err)
+	}
+
+	return path, nil
+}
@@ -0,0 +1,252 @@
+package reporter
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Catker/chaoleme/config"
+	"github.com/Catker/chaoleme/storage"
+)
+
+// influxQueueSize 待推送批次队列长度，推送端持续不可用时丢弃新批次而非阻塞采集
+const influxQueueSize = 256
+
+// influxCloseTimeout 退出时等待剩余批次推送完成的最长时间
+const influxCloseTimeout = 5 * time.Second
+
+// InfluxExporter 以 InfluxDB 行协议推送采集样本
+// 每次采集写入时由 WriteGuard 回调 Export 入队，后台协程追加到文件或 POST 到 HTTP 写入端点，
+// 不经过数据库，因此数据库降级时仍可推送；推送失败只记录日志，不影响采集
+type InfluxExporter struct {
+	cfg    *config.InfluxConfig
+	host   string
+	client *http.Client
+	queue  chan []string
+	done   chan struct{}
+	mu     sync.Mutex
+	closed bool
+	failed bool // 上次推送是否失败，只在状态变化时记录日志
+}
+
+// NewInfluxExporter 创建行协议推送器并启动后台推送协程
+func NewInfluxExporter(cfg *config.InfluxConfig, hostname string) *InfluxExporter {
+	e := &InfluxExporter{
+		cfg:  cfg,
+		host: hostname,
+		client: &http.Client{
+			Timeout: cfg.GetTimeout(),
+		},
+		queue: make(chan []string, influxQueueSize),
+		done:  make(chan struct{}),
+	}
+	go e.run()
+	return e
+}
+
+// Export 将一批样本格式化为行协议后加入推送队列，队列已满时丢弃
+// 在调用方协程中完成格式化，推送协程不再访问样本本身
+func (e *InfluxExporter) Export(metrics []*storage.Metric) {
+	lines := FormatInfluxLines(metrics, e.host, e.cfg.Measurement)
+	if len(lines) == 0 {
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.closed {
+		return
+	}
+	select {
+	case e.queue <- lines:
+	default:
+		log.Printf("InfluxDB 推送队列已满，丢弃 %d 条样本", len(metrics))
+	}
+}
+
+// Close 停止接收新批次，并在超时前推送完队列中剩余的样本
+func (e *InfluxExporter) Close() {
+	e.mu.Lock()
+	e.closed = true
+	close(e.queue)
+	e.mu.Unlock()
+	select {
+	case <-e.done:
+	case <-time.After(influxCloseTimeout):
+		log.Printf("InfluxDB 推送未在 %v 内完成，剩余样本已丢弃", influxCloseTimeout)
+	}
+}
+
+// run 合并队列中已积压的批次后一次性推送
+func (e *InfluxExporter) run() {
+	defer close(e.done)
+	for lines := range e.queue {
+	drain:
+		for {
+			select {
+			case more, ok := <-e.queue:
+				if !ok {
+					break drain
+				}
+				lines = append(lines, more...)
+			default:
+				break drain
+			}
+		}
+		err := e.write([]byte(strings.Join(lines, "\n") + "\n"))
+		switch {
+		case err != nil && !e.failed:
+			log.Printf("InfluxDB 推送失败: %v", err)
+		case err == nil && e.failed:
+			log.Printf("InfluxDB 推送已恢复")
+		}
+		e.failed = err != nil
+	}
+}
+
+// write 追加到文件并/或推送到 HTTP 端点
+func (e *InfluxExporter) write(payload []byte) error {
+	if e.cfg.File != "" {
+		if err := appendFile(e.cfg.File, payload); err != nil {
+			return fmt.Errorf("写入文件失败: %w", err)
+		}
+	}
+	if e.cfg.URL != "" {
+		if err := e.post(payload); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func appendFile(path string, payload []byte) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(payload); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func (e *InfluxExporter) post(payload []byte) error {
+	req, err := http.NewRequest(http.MethodPost, e.cfg.URL, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("创建请求失败: %w", err)
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	if e.cfg.Token != "" {
+		req.Header.Set("Authorization", "Token "+e.cfg.Token)
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("发送请求失败: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+// FormatInfluxLines 将样本格式化为 InfluxDB 行协议，每条样本一行：
+// <measurement>,host=<主机>,metric=<指标类型> value=<值>[,<extra 数值字段>...] <纳秒时间戳>
+// extra 中只保留数值与布尔字段（字符串与嵌套结构不适合作为时序字段）
+func FormatInfluxLines(metrics []*storage.Metric, hostname, measurement string) []string {
+	lines := make([]string, 0, len(metrics))
+	for _, m := range metrics {
+		if math.IsNaN(m.Value) || math.IsInf(m.Value, 0) {
+			continue
+		}
+		var b strings.Builder
+		b.WriteString(influxEscape(measurement, ", "))
+		b.WriteString(",host=")
+		b.WriteString(influxEscape(hostname, ",= "))
+		b.WriteString(",metric=")
+		b.WriteString(influxEscape(string(m.Type), ",= "))
+		b.WriteString(" value=")
+		b.WriteString(strconv.FormatFloat(m.Value, 'f', -1, 64))
+
+		keys := make([]string, 0, len(m.Extra))
+		for k := range m.Extra {
+			if k != "value" {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			field, ok := influxField(m.Extra[k])
+			if !ok {
+				continue
+			}
+			b.WriteString(",")
+			b.WriteString(influxEscape(k, ",= "))
+			b.WriteString("=")
+			b.WriteString(field)
+		}
+
+		b.WriteString(" ")
+		b.WriteString(strconv.FormatInt(m.Timestamp.UnixNano(), 10))
+		lines = append(lines, b.String())
+	}
+	return lines
+}
+
+// influxField 将 extra 值格式化为行协议字段值，不支持的类型返回 false
+func influxField(v interface{}) (string, bool) {
+	switch x := v.(type) {
+	case float64:
+		if math.IsNaN(x) || math.IsInf(x, 0) {
+			return "", false
+		}
+		return strconv.FormatFloat(x, 'f', -1, 64), true
+	case float32:
+		if math.IsNaN(float64(x)) || math.IsInf(float64(x), 0) {
+			return "", false
+		}
+		return strconv.FormatFloat(float64(x), 'f', -1, 32), true
+	case int:
+		return strconv.Itoa(x) + "i", true
+	case int64:
+		return strconv.FormatInt(x, 10) + "i", true
+	case int32:
+		return strconv.FormatInt(int64(x), 10) + "i", true
+	case uint32:
+		return strconv.FormatUint(uint64(x), 10) + "i", true
+	// 64 位无符号数可能超出有符号整数范围，统一写为无符号整数（InfluxDB 1.8+/2.x），同一字段类型不随数值变化
+	case uint64:
+		return strconv.FormatUint(x, 10) + "u", true
+	case uint:
+		return strconv.FormatUint(uint64(x), 10) + "u", true
+	case bool:
+		return strconv.FormatBool(x), true
+	}
+	return "", false
+}
+
+// influxEscape 按行协议规则对 chars 中的字符与反斜杠加反斜杠转义
+func influxEscape(s, chars string) string {
+	if !strings.ContainsAny(s, chars+"\\") {
+		return s
+	}
+	var b strings.Builder
+	for _, r := range s {
+		if r == '\\' || strings.ContainsRune(chars, r) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,67 @@
+package reporter
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/Catker/chaoleme/analyzer"
+)
+
+// JSONReportVersion JSON 报告格式版本，字段发生不兼容变更时递增
+const JSONReportVersion = 1
+
+// JSONReport 机器可读的报告（供自动化工具消费，与 Telegram 文本格式解耦）
+type JSONReport struct {
+	Version     int                   `json:"version"`
+	Hostname    string                `json:"hostname"`
+	Labels      map[string]string     `json:"labels,omitempty"`
+	GeneratedAt time.Time             `json:"generated_at"`
+	Stats       *analyzer.PeriodStats `json:"stats"`
+	AIAnalysis  string                `json:"ai_analysis,omitempty"`
+}
+
+// FormatJSON 将周期统计序列化为 JSON 报告
+func FormatJSON(hostname string, stats *analyzer.PeriodStats, aiAnalysis string) ([]byte, error) {
+	report := &JSONReport{
+		Version:     JSONReportVersion,
+		Hostname:    hostname,
+		Labels:      stats.Labels,
+		GeneratedAt: time.Now(),
+		Stats:       stats,
+		AIAnalysis:  aiAnalysis,
+	}
+
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("序列化 JSON 报告失败: %w", err)
+	}
+	return data, nil
+}
+
+// WriteJSONReport 将 JSON 报告写入目录，文件名为 <period>-<结束时间>.json，返回写入路径
+// 先写临时文件再重命名，避免下游工具读到半截文件
+func WriteJSONReport(dir, hostname string, stats *analyzer.PeriodStats, aiAnalysis string) (string, error) {
+	data, err := FormatJSON(hostname, stats, aiAnalysis)
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("创建 JSON 报告目录失败: %w", err)
+	}
+
+	path := filepath.Join(dir, fmt.Sprintf("%s-%s.json", stats.Period, stats.EndTime.Format("20060102-150405")))
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return "", fmt.Errorf("写入 JSON 报告失败: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return "", fmt.Errorf("写入 JSON 报告失败: %w", err)
+	}
+
+	return path, nil
+}
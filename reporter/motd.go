@@ -0,0 +1,85 @@
+package reporter
+
+import (
+	"strings"
+	"time"
+
+	"github.com/Catker/chaoleme/analyzer"
+	"github.com/Catker/chaoleme/locale"
+	"github.com/Catker/chaoleme/storage"
+)
+
+// motdStaleAfter 最新样本早于该时长时提示数据过期（守护进程可能已停止）
+const motdStaleAfter = time.Hour
+
+// ANSI 颜色
+const (
+	ansiReset  = "\033[0m"
+	ansiGreen  = "\033[32m"
+	ansiYellow = "\033[33m"
+	ansiRed    = "\033[31m"
+)
+
+// MOTDStatus 登录提示行所需的数据，均为数据库中已有的记录，不触发采集与分析
+type MOTDStatus struct {
+	Score     float64
+	RiskLevel analyzer.RiskLevel // 为空表示尚未生成过日报
+	Steal     *storage.Metric    // 最新 Steal 样本，可为 nil
+	IOLatency *storage.Metric    // 最新顺序写延迟样本，可为 nil
+}
+
+// FormatMOTD 生成单行状态，如 "chaoleme: 评分 82/100 🟢 良好 (steal 2.1%, io 15ms)"
+// color 为 true 时按风险等级着色评分部分
+func FormatMOTD(loc *locale.Locale, s *MOTDStatus, now time.Time, color bool) string {
+	var b strings.Builder
+	b.WriteString("chaoleme: ")
+
+	if s.RiskLevel == "" {
+		b.WriteString(loc.T("评分 暂无（尚未生成日报）"))
+	} else {
+		verdict := loc.Sprintf("评分 %.0f/100 %s", s.Score, loc.T(describeRiskLevel(s.RiskLevel)))
+		if color {
+			verdict = riskColor(s.RiskLevel) + verdict + ansiReset
+		}
+		b.WriteString(verdict)
+	}
+
+	var details []string
+	var latest time.Time
+	if s.Steal != nil {
+		details = append(details, loc.Sprintf("steal %.1f%%", s.Steal.Value))
+		latest = s.Steal.Timestamp
+	}
+	if s.IOLatency != nil {
+		details = append(details, loc.Sprintf("io %.0fms", s.IOLatency.Value))
+		if s.IOLatency.Timestamp.After(latest) {
+			latest = s.IOLatency.Timestamp
+		}
+	}
+	if len(details) > 0 {
+		b.WriteString(" (" + strings.Join(details, ", ") + ")")
+	}
+
+	if latest.IsZero() {
+		b.WriteString(loc.T(" · 暂无采集数据"))
+	} else if age := now.Sub(latest); age > motdStaleAfter {
+		stale := loc.Sprintf(" · ⚠️ 数据已 %s 未更新", formatDuration(loc, age.Truncate(time.Minute)))
+		if color {
+			stale = ansiYellow + stale + ansiReset
+		}
+		b.WriteString(stale)
+	}
+	return b.String()
+}
+
+// riskColor 风险等级对应的终端颜色
+func riskColor(level analyzer.RiskLevel) string {
+	switch level {
+	case analyzer.RiskLevelExcellent, analyzer.RiskLevelGood:
+		return ansiGreen
+	case analyzer.RiskLevelMedium:
+		return ansiYellow
+	default:
+		return ansiRed
+	}
+}
@@ -3,31 +3,44 @@ package reporter
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"mime/multipart"
 	"net/http"
+	neturl "net/url"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/Catker/chaoleme/analyzer"
+	"github.com/Catker/chaoleme/collector"
 	"github.com/Catker/chaoleme/config"
+	"github.com/Catker/chaoleme/locale"
+	"github.com/Catker/chaoleme/storage"
 )
 
 // TelegramReporter Telegram 报告器
 type TelegramReporter struct {
 	botToken string
 	chatID   string
+	threadID int64
 	hostname string
+	report   *config.ReportConfig
+	loc      *locale.Locale
 	client   *http.Client
 }
 
 // NewTelegramReporter 创建 Telegram 报告器
-func NewTelegramReporter(cfg *config.TelegramConfig, hostname string) *TelegramReporter {
+func NewTelegramReporter(cfg *config.TelegramConfig, reportCfg *config.ReportConfig, hostname string) *TelegramReporter {
 	return &TelegramReporter{
 		botToken: cfg.BotToken,
 		chatID:   cfg.ChatID,
+		threadID: cfg.ThreadID,
 		hostname: hostname,
+		report:   reportCfg,
+		loc:      locale.New(reportCfg.Locale, reportCfg.DecimalSeparator),
 		client: &http.Client{
 			Timeout: 30 * time.Second,
 		},
@@ -37,129 +50,512 @@ func NewTelegramReporter(cfg *config.TelegramConfig, hostname string) *TelegramR
 // SendReport 发送报告
 func (r *TelegramReporter) SendReport(stats *analyzer.PeriodStats, aiAnalysis string) error {
 	message := r.formatReport(stats, aiAnalysis)
+	filename := fmt.Sprintf("chaoleme-%s-%s-%s.txt", r.hostname, stats.Period, stats.EndTime.Format("20060102"))
+	return r.sendLongMessage(message, filename)
+}
+
+// sendLongMessage 发送可能较长的报告类消息
+// 配置 as_document 时以文件附件形式发送（标题行作为 caption），否则作为普通消息发送
+func (r *TelegramReporter) sendLongMessage(message, filename string) error {
+	if r.report.AsDocument {
+		caption := strings.SplitN(message, "\n", 2)[0]
+		return withRetry(3, func() error {
+			return r.sendDocument(filename, message, caption)
+		})
+	}
 	return r.sendMessageWithRetry(message, 3)
 }
 
+// FormatReport 生成完整报告文本（不发送）
+func (r *TelegramReporter) FormatReport(stats *analyzer.PeriodStats, aiAnalysis string) string {
+	return r.formatReport(stats, aiAnalysis)
+}
+
+// Target 返回报告目标标识（chat_id，配置了话题时附加话题 ID），用于机群告警汇总时按目标分组
+func (r *TelegramReporter) Target() string {
+	if r.threadID > 0 {
+		return fmt.Sprintf("%s/%d", r.chatID, r.threadID)
+	}
+	return r.chatID
+}
+
+// SendText 发送任意文本消息（带重试）
+func (r *TelegramReporter) SendText(text string) error {
+	return r.sendMessageWithRetry(text, 3)
+}
+
+// SendDigest 发送机群告警汇总
+// 窗口内仅有一条告警时原样发送该主机的完整报告
+func (r *TelegramReporter) SendDigest(alerts []*storage.QueuedAlert) error {
+	if len(alerts) == 0 {
+		return nil
+	}
+	if len(alerts) == 1 && alerts[0].Report != "" {
+		a := alerts[0]
+		filename := fmt.Sprintf("chaoleme-%s-%s-%s.txt", a.Hostname, a.Period, a.Timestamp.Format("20060102"))
+		return r.sendLongMessage(a.Report, filename)
+	}
+	filename := fmt.Sprintf("chaoleme-digest-%s.txt", alerts[len(alerts)-1].Timestamp.Format("20060102"))
+	return r.sendLongMessage(formatDigest(r.loc, alerts), filename)
+}
+
+// FormatAlertSummary 生成单行告警摘要（用于机群汇总消息）
+func FormatAlertSummary(stats *analyzer.PeriodStats) string {
+	return fmt.Sprintf("Steal %.1f%% · IOWait %.1f%% · I/O P95 %.1fms",
+		stats.CPUStealAvg, stats.CPUIoWaitAvg, stats.IOLatencyP95)
+}
+
+// formatDigest 格式化机群告警汇总
+func formatDigest(loc *locale.Locale, alerts []*storage.QueuedAlert) string {
+	var buf bytes.Buffer
+
+	buf.WriteString(loc.T("🚨 超了么机群告警汇总") + "\n")
+	buf.WriteString(fmt.Sprintf("📅 %s\n\n", time.Now().Format("2006-01-02 15:04")))
+	buf.WriteString("━━━━━━━━━━━━━━━━━━\n")
+	buf.WriteString(loc.Sprintf("共 %d 条严重告警:\n", len(alerts)))
+	for _, a := range alerts {
+		buf.WriteString(loc.Sprintf("🔴 %s | %s | %.0f/100\n", a.Hostname, loc.T(periodName(a.Period)), a.Score))
+		if a.Summary != "" {
+			buf.WriteString(fmt.Sprintf("   • %s\n", a.Summary))
+		}
+	}
+	buf.WriteString("━━━━━━━━━━━━━━━━━━\n")
+
+	return buf.String()
+}
+
+// periodName 报告类型的中文名
+func periodName(period string) string {
+	switch period {
+	case "daily":
+		return "日报"
+	case "weekly":
+		return "周报"
+	case "monthly":
+		return "月报"
+	case "today":
+		return "今日实时"
+	default:
+		return period
+	}
+}
+
+// PeriodName 返回报告类型的中文名称，供包外经 locale 翻译后使用
+func PeriodName(period string) string {
+	return periodName(period)
+}
+
+// collectErrorNames 采集异常中各指标的中文名
+var collectErrorNames = map[storage.MetricType]string{
+	storage.MetricTypeCPUSteal:  "CPU 采集",
+	storage.MetricTypeCPULoad:   "Load Average 采集",
+	storage.MetricTypeCPUBench:  "CPU 基准测试",
+	storage.MetricTypeCPUOnline: "在线 vCPU 采集",
+	storage.MetricTypeCPUTemp:   "CPU 温度采集",
+	storage.MetricTypeIOLatency: "I/O 测试",
+	storage.MetricTypeRandomIO:  "随机 I/O 测试",
+	storage.MetricTypeMemory:    "内存采集",
+	storage.MetricTypeMemFault:  "内存缺页测试",
+	storage.MetricTypeDiskStats: "磁盘统计采集",
+	storage.MetricTypeNetwork:   "网络流量采集",
+	storage.MetricTypeNUMA:      "NUMA 统计采集",
+	storage.MetricTypeSyncProbe: "落盘探测",
+	storage.MetricTypeSchedStat: "调度统计采集",
+}
+
+// collectErrorName 采集失败指标的中文名，自定义指标显示为"自定义指标 <名称> "
+func collectErrorName(loc *locale.Locale, t storage.MetricType) string {
+	if name, ok := collectErrorNames[t]; ok {
+		return loc.T(name)
+	}
+	if name, ok := strings.CutPrefix(string(t), storage.CustomMetricPrefix); ok {
+		return loc.Sprintf("自定义指标 %s ", name)
+	}
+	return string(t) + " "
+}
+
+// formatOnlineChanges 格式化 vCPU 数量变化序列，如 "4→2→4"
+func formatOnlineChanges(changes []int) string {
+	parts := make([]string, len(changes))
+	for i, n := range changes {
+		parts[i] = strconv.Itoa(n)
+	}
+	return strings.Join(parts, "→")
+}
+
+// describeStreak 连续周期数的中文描述，如 "3 天"、"2 周"
+func describeStreak(loc *locale.Locale, period string, n int) string {
+	switch period {
+	case "daily":
+		return loc.Sprintf("%d 天", n)
+	case "weekly":
+		return loc.Sprintf("%d 周", n)
+	case "monthly":
+		return loc.Sprintf("%d 个月", n)
+	default:
+		return loc.Sprintf("%d 期", n)
+	}
+}
+
+// describeFilesystem 测试目录位于写时复制或网络文件系统时的说明，本地文件系统
+// 或以 -force-storage 指定了存储类型（不再按文件系统放宽阈值）时返回空串
+func describeFilesystem(loc *locale.Locale, stats *analyzer.PeriodStats) string {
+	if stats.StorageTypeForced {
+		return ""
+	}
+	switch stats.FilesystemKind {
+	case collector.FilesystemCOW:
+		return loc.Sprintf("测试目录位于 %s（写时复制），fsync 伴随写放大，延迟偏高不代表超售，已按 HDD 阈值评分", stats.FilesystemType)
+	case collector.FilesystemNetwork:
+		return loc.Sprintf("测试目录位于 %s（网络文件系统），延迟包含网络往返，已按 HDD 阈值评分", stats.FilesystemType)
+	default:
+		return ""
+	}
+}
+
+// showSection 判断报告是否包含指定指标段：需在 report.sections 中启用，且本周期有数据
+// stats.Samples 为空（如旧版本生成的统计）时不做数据检查
+func (r *TelegramReporter) showSection(stats *analyzer.PeriodStats, section string) bool {
+	if !r.report.SectionEnabled(section) {
+		return false
+	}
+	if stats.Samples == nil {
+		return true
+	}
+	n, tracked := stats.Samples[section]
+	return !tracked || n > 0
+}
+
 // formatReport 格式化报告
 func (r *TelegramReporter) formatReport(stats *analyzer.PeriodStats, aiAnalysis string) string {
+	// 部分服务商会无视 max_tokens 超量返回，硬性截断避免报告超出 Telegram 限制
+	aiAnalysis = truncateRunes(aiAnalysis, r.report.AIMaxChars)
+
+	if r.report.Compact {
+		return r.formatReportCompact(stats, aiAnalysis)
+	}
+
 	var buf bytes.Buffer
 
 	// 标题
 	var title string
 	switch stats.Period {
 	case "daily":
-		title = "📊 超了么日报"
+		title = r.loc.T("📊 超了么日报")
 	case "weekly":
-		title = "📊 超了么周报"
+		title = r.loc.T("📊 超了么周报")
 	case "monthly":
-		title = "📊 超了么月报"
+		title = r.loc.T("📊 超了么月报")
+	case "today":
+		title = r.loc.T("📊 超了么今日实时")
 	default:
-		title = "📊 超了么报告"
+		title = r.loc.T("📊 超了么报告")
 	}
 
 	// 添加主机标识
-	buf.WriteString(fmt.Sprintf("%s | 🖥️ %s\n", title, r.hostname))
-	buf.WriteString(fmt.Sprintf("📅 %s\n\n", stats.EndTime.Format("2006-01-02")))
+	buf.WriteString(r.loc.Sprintf("%s | 🖥️ %s\n", title, r.hostname))
+	if labels := stats.LabelPairs(); len(labels) > 0 {
+		buf.WriteString(r.loc.Sprintf("🏷️ %s\n", strings.Join(labels, " · ")))
+	}
+	buf.WriteString(r.loc.Sprintf("📅 %s\n", stats.EndTime.Format("2006-01-02")))
+	if stats.Period == "today" {
+		buf.WriteString(r.loc.Sprintf("⏱️ 统计区间 00:00-%s，当天尚未结束，评分随时间变化\n", stats.EndTime.Format("15:04")))
+	}
+	if stats.DataMissing > 0 {
+		buf.WriteString(r.loc.Sprintf("📶 数据覆盖率: %.0f%% (缺失 %s)\n", stats.DataCoverage, formatDuration(r.loc, stats.DataMissing)))
+	} else {
+		buf.WriteString(r.loc.Sprintf("📶 数据覆盖率: %.0f%%\n", stats.DataCoverage))
+	}
+	if !stats.DataSufficiency {
+		buf.WriteString(r.loc.T("⚠️ 数据覆盖不足，评分仅供参考\n"))
+	}
+	for _, m := range stats.StaleMetrics {
+		buf.WriteString(r.loc.Sprintf("⚠️ %s 指标已 %s 未更新（最后更新 %s），采集器可能已失效\n",
+			m.Type, formatDuration(r.loc, m.Age), m.LastUpdated.Format("01-02 15:04")))
+	}
+	if len(stats.CollectErrors) > 0 {
+		buf.WriteString(r.loc.T("⚠️ 采集异常（相关指标样本不完整，评分可靠性下降）:\n"))
+		for _, e := range stats.CollectErrors {
+			line := r.loc.Sprintf("   • %s失败 %d 次", collectErrorName(r.loc, e.Metric), e.Count)
+			if e.Rate > 0 {
+				line += r.loc.Sprintf("（约 %.0f%%）", e.Rate)
+			}
+			buf.WriteString(line + "\n")
+		}
+	}
+	if stats.BusinessHours != "" {
+		buf.WriteString(r.loc.Sprintf("🕘 评分基于 %s 时段数据\n", stats.BusinessHours))
+	}
+	if stats.RecencyHalfLife != "" {
+		buf.WriteString(r.loc.Sprintf("⏳ 均值与分位数按近期加权（半衰期 %s）\n", stats.RecencyHalfLife))
+	}
+	if stats.PassiveOnly {
+		buf.WriteString(r.loc.T("🔍 被动模式评估：未运行 I/O 写入与 CPU 基准测试，评分仅基于 Steal/IOWait/磁盘繁忙度/内存/基线\n"))
+	}
+	if stats.Escalated {
+		buf.WriteString(r.loc.Sprintf("⚠️ 连续 %s评分偏低，建议尽快处理\n", describeStreak(r.loc, stats.Period, stats.RiskStreak)))
+	}
+	buf.WriteString("\n")
+
+	// 续费建议（月报）
+	if stats.Recommendation != "" {
+		buf.WriteString(r.loc.Sprintf("📋 续费建议: %s (置信度 %s)\n", r.loc.T(describeRecommendation(stats.Recommendation)), r.loc.T(describeConfidence(stats.RecommendationConfidence))))
+		buf.WriteString(r.loc.Sprintf("   • 日评分波动: ±%.1f (%d 天)\n\n", stats.DailyScoreVolatility, stats.DailyScoreDays))
+	}
+
 	buf.WriteString("━━━━━━━━━━━━━━━━━━\n")
 
 	// CPU Steal
-	cpuRisk := stats.RiskDetails["cpu_steal"]
-	buf.WriteString(fmt.Sprintf("🖥️ CPU 超售风险: %s\n", cpuRisk))
-	buf.WriteString(fmt.Sprintf("   • Steal Time 平均: %.2f%%\n", stats.CPUStealAvg))
-	buf.WriteString(fmt.Sprintf("   • Steal Time 峰值: %.2f%%\n", stats.CPUStealMax))
-	if !stats.CPUStealMaxTime.IsZero() {
-		buf.WriteString(fmt.Sprintf("   • 峰值时段: %s\n", formatHourRange(stats.CPUStealMaxTime)))
+	if r.showSection(stats, "cpu_steal") {
+		cpuRisk := stats.RiskDetails["cpu_steal"]
+		buf.WriteString(r.loc.Sprintf("🖥️ CPU 超售风险: %s\n", cpuRisk))
+		buf.WriteString(r.loc.Sprintf("   • Steal Time 平均: %.2f%%\n", stats.CPUStealAvg))
+		buf.WriteString(r.loc.Sprintf("   • Steal Time 峰值: %.2f%%\n", stats.CPUStealMax))
+		if !stats.CPUStealMaxTime.IsZero() {
+			buf.WriteString(r.loc.Sprintf("   • 峰值时段: %s\n", formatHourRange(stats.CPUStealMaxTime)))
+		}
+		if stats.BurstCreditSuspected {
+			buf.WriteString(r.loc.Sprintf("   • ⚠️ 疑似突发额度耗尽，而非超售（高负载时 Steal %.1f%%，空闲时 %.1f%%，相关系数 %.2f），建议升级规格而非更换服务商\n",
+				stats.BurstBusySteal, stats.BurstIdleSteal, stats.StealLoadCorrelation))
+		}
+		if stats.SuspendEvents > 0 {
+			buf.WriteString(r.loc.Sprintf("   • 检测到 %d 次疑似迁移/挂起（Steal 峰值 %.1f%%，已排除）\n", stats.SuspendEvents, stats.SuspendStealMax))
+		}
+		if !stats.PassiveOnly {
+			buf.WriteString(r.loc.Sprintf("   • 性能波动系数: %.3f\n", stats.CPUBenchCV))
+		}
+		if stats.CPUPerfPercent > 0 {
+			buf.WriteString(r.loc.Sprintf("   • CPU 性能: 当前为参考值的 %.0f%%\n", stats.CPUPerfPercent))
+			if stats.CPUPerfDegraded {
+				buf.WriteString(r.loc.T("   • ⚠️ 性能持续低于参考值，疑似被调度到较慢核心或宿主机降频\n"))
+			}
+		}
+		if stats.CPUTempSamples > 0 {
+			buf.WriteString(r.loc.Sprintf("   • CPU 温度: 平均 %.0f°C / 峰值 %.0f°C\n", stats.CPUTempAvg, stats.CPUTempMax))
+			if stats.ThermalThrottling {
+				buf.WriteString(r.loc.T("   • ⚠️ 性能波动伴随高温，疑似过热降频而非邻居争抢\n"))
+			}
+		}
+		if len(stats.CPUOnlineChanges) > 0 {
+			buf.WriteString(r.loc.Sprintf("   • ⚠️ 检测到 vCPU 数量变化: %s\n", formatOnlineChanges(stats.CPUOnlineChanges)))
+		}
+		if stats.Virtualization != "" {
+			buf.WriteString(r.loc.Sprintf("   • 虚拟化: %s\n", r.loc.T(stats.Virtualization.DisplayName())))
+		}
+		if stats.StealUntrusted {
+			buf.WriteString(r.loc.T("   • ⚠️ 容器环境的 Steal 为宿主机数值或恒为 0，不代表本实例被争抢，已降低其评分权重\n"))
+		}
+		buf.WriteString(r.loc.Sprintf("   • 核心类型: %s（%s）\n\n", r.loc.T(describeCPUTenancy(stats.CPUTenancy)), stats.CPUTenancyReason))
 	}
-	buf.WriteString(fmt.Sprintf("   • 性能波动系数: %.3f\n\n", stats.CPUBenchCV))
 
 	// CPU IOWait
-	iowaitRisk := stats.RiskDetails["cpu_iowait"]
-	buf.WriteString(fmt.Sprintf("⏳ CPU IOWait 风险: %s\n", iowaitRisk))
-	buf.WriteString(fmt.Sprintf("   • IOWait 平均: %.2f%%\n", stats.CPUIoWaitAvg))
-	buf.WriteString(fmt.Sprintf("   • IOWait 峰值: %.2f%%\n", stats.CPUIoWaitMax))
-	if !stats.CPUIoWaitMaxTime.IsZero() {
-		buf.WriteString(fmt.Sprintf("   • 峰值时段: %s\n", formatHourRange(stats.CPUIoWaitMaxTime)))
+	if r.showSection(stats, "iowait") {
+		iowaitRisk := stats.RiskDetails["cpu_iowait"]
+		buf.WriteString(r.loc.Sprintf("⏳ CPU IOWait 风险: %s\n", iowaitRisk))
+		buf.WriteString(r.loc.Sprintf("   • IOWait 平均: %.2f%%\n", stats.CPUIoWaitAvg))
+		buf.WriteString(r.loc.Sprintf("   • IOWait 峰值: %.2f%%\n", stats.CPUIoWaitMax))
+		if !stats.CPUIoWaitMaxTime.IsZero() {
+			buf.WriteString(r.loc.Sprintf("   • 峰值时段: %s\n", formatHourRange(stats.CPUIoWaitMaxTime)))
+		}
+		buf.WriteString("\n")
+	}
+
+	// 磁盘综合（其下为顺序写、随机 I/O、繁忙度明细）
+	if r.showSection(stats, "io_latency") || r.showSection(stats, "random_io") || r.showSection(stats, "disk_busy") {
+		buf.WriteString(r.loc.Sprintf("💾 磁盘综合: %.0f/100\n\n", stats.DiskHealthScore))
 	}
-	buf.WriteString("\n")
 
 	// I/O 顺序写
-	ioRisk := stats.RiskDetails["io_latency"]
-	buf.WriteString(fmt.Sprintf("💾 顺序写延迟: %s\n", ioRisk))
-	buf.WriteString(fmt.Sprintf("   • P95: %.2fms\n", stats.IOLatencyP95))
-	buf.WriteString(fmt.Sprintf("   • P99: %.2fms\n", stats.IOLatencyP99))
-	if stats.StorageType != "" {
-		buf.WriteString(fmt.Sprintf("   • 存储类型: %s\n", stats.StorageType))
+	if r.showSection(stats, "io_latency") {
+		ioRisk := stats.RiskDetails["io_latency"]
+		buf.WriteString(r.loc.Sprintf("💾 顺序写延迟: %s\n", ioRisk))
+		buf.WriteString(r.loc.Sprintf("   • P95: %.2fms\n", stats.IOLatencyP95))
+		buf.WriteString(r.loc.Sprintf("   • P99: %.2fms\n", stats.IOLatencyP99))
+		if stats.StorageType != "" {
+			if stats.StorageTypeForced {
+				buf.WriteString(r.loc.Sprintf("   • 存储类型: %s（命令行指定）\n", stats.StorageType))
+			} else {
+				buf.WriteString(r.loc.Sprintf("   • 存储类型: %s\n", stats.StorageType))
+			}
+		}
+		if stats.IOLatencySpreadAvg > 0 {
+			buf.WriteString(r.loc.Sprintf("   • 单轮离散度: 平均 %.2fms (每轮 %d 次测量)\n", stats.IOLatencySpreadAvg, stats.IOSamplesPerRun))
+		}
+		if stats.IOLatencyCacheSamples > 0 {
+			buf.WriteString(r.loc.Sprintf("   • ⚠️ %d 个样本疑似命中缓存，已排除\n", stats.IOLatencyCacheSamples))
+		}
+		if stats.SyncSuspect {
+			buf.WriteString(r.loc.Sprintf("   • ⚠️ fsync 可能未真正落盘（4KB fsync 中位 %.3fms，低于 %s 的物理下限），断电可能丢失已提交的数据\n", stats.SyncProbeMs, stats.StorageType))
+		}
+		if stats.IOStallDeadlineMs > 0 {
+			if stats.IOStalls > 0 {
+				buf.WriteString(r.loc.Sprintf("   • ⚠️ I/O 卡顿: %d 次 (>%.0fms，最长 %.0fms)\n", stats.IOStalls, stats.IOStallDeadlineMs, stats.IOStallMaxMs))
+			} else {
+				buf.WriteString(r.loc.Sprintf("   • I/O 卡顿: 0 次 (>%.0fms)\n", stats.IOStallDeadlineMs))
+			}
+		}
+		if note := describeFilesystem(r.loc, stats); note != "" {
+			buf.WriteString(r.loc.Sprintf("   • ℹ️ %s\n", note))
+		}
+		buf.WriteString("\n")
 	}
-	buf.WriteString("\n")
 
 	// I/O 随机读写
-	randomIORisk := stats.RiskDetails["random_io"]
-	buf.WriteString(fmt.Sprintf("🎲 随机 I/O: %s\n", randomIORisk))
-	buf.WriteString(fmt.Sprintf("   • 写延迟: %.2fms\n", stats.RandomIOWriteAvg))
-	buf.WriteString(fmt.Sprintf("   • 读延迟: %.2fms\n", stats.RandomIOReadAvg))
-	buf.WriteString("\n")
+	if r.showSection(stats, "random_io") {
+		randomIORisk := stats.RiskDetails["random_io"]
+		buf.WriteString(r.loc.Sprintf("🎲 随机 I/O: %s\n", randomIORisk))
+		buf.WriteString(r.loc.Sprintf("   • 写延迟: %.2fms\n", stats.RandomIOWriteAvg))
+		buf.WriteString(r.loc.Sprintf("   • 读延迟: %.2fms\n", stats.RandomIOReadAvg))
+		buf.WriteString("\n")
+	}
 
 	// 磁盘繁忙度
-	diskBusyRisk := stats.RiskDetails["disk_busy"]
-	buf.WriteString(fmt.Sprintf("📀 磁盘繁忙度: %s\n", diskBusyRisk))
-	if stats.DiskBusyP95 > 0 {
-		buf.WriteString(fmt.Sprintf("   • P95: %.1f%%\n", stats.DiskBusyP95))
+	if r.showSection(stats, "disk_busy") {
+		diskBusyRisk := stats.RiskDetails["disk_busy"]
+		buf.WriteString(r.loc.Sprintf("📀 磁盘繁忙度: %s\n", diskBusyRisk))
+		if stats.DiskBusyP95 > 0 {
+			buf.WriteString(r.loc.Sprintf("   • P95: %.1f%%\n", stats.DiskBusyP95))
+		}
+		if stats.DiskQueueDepth > 0 {
+			buf.WriteString(r.loc.Sprintf("   • 平均队列深度: %.2f (IOPS %.0f，合并率 %.0f%%)\n", stats.DiskQueueDepth, stats.DiskIOPS, stats.DiskMergePercent))
+		}
+		if stats.DiskQueueCongested {
+			buf.WriteString(r.loc.T("   • ⚠️ 低 IOPS 下队列持续较深，疑似共享存储后端拥塞\n"))
+		}
+		buf.WriteString("\n")
 	}
-	buf.WriteString("\n")
 
 	// Memory
-	memRisk := stats.RiskDetails["memory"]
-	buf.WriteString(fmt.Sprintf("🧠 内存状态: %s\n", memRisk))
-	buf.WriteString(fmt.Sprintf("   • 可用率: %.1f%%\n\n", stats.MemoryAvailablePercent))
+	if r.showSection(stats, "memory") {
+		memRisk := stats.RiskDetails["memory"]
+		buf.WriteString(r.loc.Sprintf("🧠 内存状态: %s\n", memRisk))
+		buf.WriteString(r.loc.Sprintf("   • 可用率: %.1f%%\n", stats.MemoryAvailablePercent))
+		if stats.MemFaultAvg > 0 {
+			buf.WriteString(r.loc.Sprintf("   • 缺页延迟: 平均 %.2fms，CV %.3f %s\n", stats.MemFaultAvg, stats.MemFaultCV, stats.RiskDetails["mem_fault"]))
+		}
+		if stats.MemBallooningSuspected {
+			buf.WriteString(r.loc.T("   • ⚠️ 可用率充足但缺页延迟波动大，疑似宿主机内存气球或内存超售\n"))
+		}
+		buf.WriteString("\n")
+	}
+
+	// NUMA（仅多节点机器）
+	if r.showSection(stats, "numa") && stats.NUMANodes >= 2 {
+		buf.WriteString(r.loc.Sprintf("🧩 NUMA 远程访问: %s\n", stats.RiskDetails["numa"]))
+		buf.WriteString(r.loc.Sprintf("   • 节点数: %d，远程分配占比: %.1f%%\n", stats.NUMANodes, stats.NUMARemotePercent))
+		buf.WriteString(r.loc.Sprintf("   • numa_miss 占比: %.1f%%\n\n", stats.NUMAMissPercent))
+	}
 
 	// CPU Load
-	loadRisk := stats.RiskDetails["cpu_load"]
-	buf.WriteString(fmt.Sprintf("📊 CPU 负载: %s\n", loadRisk))
-	buf.WriteString(fmt.Sprintf("   • Load1 (归一化): %.2f\n", stats.CPULoadAvg))
-	buf.WriteString(fmt.Sprintf("   • 峰值 (归一化): %.2f\n\n", stats.CPULoadMax))
+	if r.showSection(stats, "load") {
+		loadRisk := stats.RiskDetails["cpu_load"]
+		buf.WriteString(r.loc.Sprintf("📊 CPU 负载: %s\n", loadRisk))
+		buf.WriteString(r.loc.Sprintf("   • Load1 (归一化): %.2f\n", stats.CPULoadAvg))
+		buf.WriteString(r.loc.Sprintf("   • 峰值 (归一化): %.2f\n", stats.CPULoadMax))
+		if stats.RunQueueP95 > 0 {
+			buf.WriteString(r.loc.Sprintf("   • 每核可运行进程: 平均 %.2f / P95 %.2f\n", stats.RunQueueAvg, stats.RunQueueP95))
+		}
+		if stats.SchedWaitAvg > 0 {
+			buf.WriteString(r.loc.Sprintf("   • 调度等待: 平均 %.2f%% / P95 %.2f%% (每时间片 %.3fms)\n", stats.SchedWaitAvg, stats.SchedWaitP95, stats.SchedWaitPerSliceMs))
+		}
+		if stats.SchedContention {
+			buf.WriteString(r.loc.T("   • ⚠️ 本地负载低但调度等待高，疑似宿主机 CPU 争抢\n"))
+		}
+		buf.WriteString("\n")
+	}
+
+	// 网络流量（仅展示，不参与评分）
+	if r.showSection(stats, "network") && stats.NetworkInterface != "" {
+		buf.WriteString(r.loc.Sprintf("🌐 网络流量 (%s):\n", stats.NetworkInterface))
+		buf.WriteString(r.loc.Sprintf("   • 平均: ↓ %.2f Mbps / ↑ %.2f Mbps\n", stats.NetworkRxMbps, stats.NetworkTxMbps))
+		buf.WriteString(r.loc.Sprintf("   • P95 (收发合计): %.2f Mbps\n\n", stats.NetworkP95Mbps))
+	}
 
 	// Baseline
-	baselineRisk := stats.RiskDetails["baseline"]
-	buf.WriteString(fmt.Sprintf("📈 基线对比: %s\n", baselineRisk))
-	if stats.BaselineDeviation > 0 {
-		buf.WriteString(fmt.Sprintf("   • 偏离度: %.1f%%\n", stats.BaselineDeviation))
+	if r.showSection(stats, "baseline") {
+		baselineRisk := stats.RiskDetails["baseline"]
+		buf.WriteString(r.loc.Sprintf("📈 基线对比: %s\n", baselineRisk))
+		if stats.BaselineDeviation > 0 {
+			buf.WriteString(r.loc.Sprintf("   • 偏离度: %.1f%%\n", stats.BaselineDeviation))
+		}
+		buf.WriteString("\n")
+	}
+
+	// 较初始状态（月报）
+	if stats.SinceInstall != nil {
+		buf.WriteString(r.loc.Sprintf("🕰️ 较初始状态 (%s 起 24 小时):\n", stats.SinceInstall.WindowStart.Format("2006-01-02")))
+		for _, c := range stats.SinceInstall.Changes {
+			buf.WriteString(r.loc.Sprintf("   • %s: %.2f%s → %.2f%s (%+.0f%%)\n", c.Name, c.Initial, c.Unit, c.Current, c.Unit, c.ChangePercent))
+		}
+		buf.WriteString("\n")
+	}
+
+	// 自定义指标
+	if len(stats.CustomMetrics) > 0 {
+		buf.WriteString(r.loc.T("🔧 自定义指标:\n"))
+		for _, cm := range stats.CustomMetrics {
+			buf.WriteString(r.loc.Sprintf("   • %s: 平均 %.2f (最小 %.2f / 最大 %.2f)\n", cm.Name, cm.Avg, cm.Min, cm.Max))
+		}
+		buf.WriteString("\n")
 	}
-	buf.WriteString("\n")
 
 	buf.WriteString("━━━━━━━━━━━━━━━━━━\n")
 
 	// 综合评分
-	buf.WriteString(fmt.Sprintf("📈 综合评分: %.0f/100\n", stats.TotalScore))
+	buf.WriteString(r.loc.Sprintf("📈 综合评分: %.0f/100\n", stats.TotalScore))
 
 	// 风险等级描述
 	var riskDesc string
 	switch stats.RiskLevel {
 	case analyzer.RiskLevelExcellent:
-		riskDesc = "✅ 优秀，无超售迹象"
+		riskDesc = r.loc.T("✅ 优秀，无超售迹象")
 	case analyzer.RiskLevelGood:
-		riskDesc = "🟢 良好，轻微资源竞争"
+		riskDesc = r.loc.T("🟢 良好，轻微资源竞争")
 	case analyzer.RiskLevelMedium:
-		riskDesc = "⚠️ 中等，存在超售可能"
+		riskDesc = r.loc.T("⚠️ 中等，存在超售可能")
 	case analyzer.RiskLevelSevere:
-		riskDesc = "🔴 严重超售，建议更换"
+		riskDesc = r.loc.T("🔴 严重超售，建议更换")
+	}
+	if stats.RiskLevelHeld {
+		riskDesc += r.loc.T("（评分在等级边界附近，沿用上次等级）")
+	}
+	buf.WriteString(r.loc.Sprintf("📋 风险等级: %s\n", riskDesc))
+	if line := describeConsistency(r.loc, stats.Consistency); line != "" {
+		buf.WriteString(r.loc.Sprintf("🔗 一致性: %s\n", line))
+	}
+
+	// 最差时段（仅日报与今日实时显示：其中每个小时桶恰好对应一个实际小时）
+	if (stats.Period == "daily" || stats.Period == "today") && stats.WorstHour != nil {
+		h := stats.WorstHour
+		line := r.loc.Sprintf("🕐 最差时段: %02d:00-%02d:00 · Steal %.1f%% · IOWait %.1f%%", h.Hour, (h.Hour+1)%24, h.CPUStealAvg, h.CPUIoWaitAvg)
+		if h.IOLatencyAvg > 0 {
+			line += r.loc.Sprintf(" · 写延迟 %.0fms", h.IOLatencyAvg)
+		}
+		buf.WriteString(line + "\n")
 	}
-	buf.WriteString(fmt.Sprintf("📋 风险等级: %s\n", riskDesc))
 
 	// 时段分析摘要（仅周报/月报显示）
 	if (stats.Period == "weekly" || stats.Period == "monthly") && len(stats.HourlyBreakdown) > 0 {
-		buf.WriteString("\n📊 时段分析:\n")
+		buf.WriteString(r.loc.T("\n📊 时段分析:\n"))
 		highHours, lowHours := findHighLowLoadHours(stats.HourlyBreakdown)
 		if len(highHours) > 0 {
-			buf.WriteString(fmt.Sprintf("   • 高负载时段: %s\n", formatHoursList(highHours)))
+			buf.WriteString(r.loc.Sprintf("   • 高负载时段: %s\n", formatHoursList(r.loc, highHours)))
 		}
 		if len(lowHours) > 0 {
-			buf.WriteString(fmt.Sprintf("   • 低负载时段: %s\n", formatHoursList(lowHours)))
+			buf.WriteString(r.loc.Sprintf("   • 低负载时段: %s\n", formatHoursList(r.loc, lowHours)))
+		}
+		if d := stats.DayTypes; d != nil {
+			buf.WriteString(r.loc.Sprintf("   • 工作日 Steal %.1f%% vs 周末 %.1f%%\n", d.WeekdayStealAvg, d.WeekendStealAvg))
+			buf.WriteString(r.loc.Sprintf("   • 工作日 Load %.2f vs 周末 %.2f\n", d.WeekdayLoadAvg, d.WeekendLoadAvg))
+			if d.WeekdayStealAvg >= 2*d.WeekendStealAvg && d.WeekdayStealAvg-d.WeekendStealAvg >= 1 {
+				buf.WriteString(r.loc.T("   • ⚠️ 工作日明显更差，疑似与商业业务邻居争抢资源\n"))
+			}
 		}
 	}
 
 	// AI 分析
 	if aiAnalysis != "" {
-		buf.WriteString("\n🤖 AI 分析:\n")
+		buf.WriteString(r.loc.T("\n🤖 AI 分析:\n"))
 		buf.WriteString(aiAnalysis)
 		buf.WriteString("\n")
 	}
@@ -169,6 +565,154 @@ func (r *TelegramReporter) formatReport(stats *analyzer.PeriodStats, aiAnalysis
 	return buf.String()
 }
 
+// formatReportCompact 精简模式：核心指标压缩为一行，适合低关注度的日常监控
+func (r *TelegramReporter) formatReportCompact(stats *analyzer.PeriodStats, aiAnalysis string) string {
+	var buf bytes.Buffer
+
+	buf.WriteString(r.loc.Sprintf("📊 %s | 🖥️ %s | %s\n", r.loc.T(periodName(stats.Period)), r.hostname, stats.EndTime.Format("2006-01-02")))
+	if labels := stats.LabelPairs(); len(labels) > 0 {
+		buf.WriteString(r.loc.Sprintf("🏷️ %s\n", strings.Join(labels, " · ")))
+	}
+
+	var parts []string
+	if r.showSection(stats, "cpu_steal") {
+		parts = append(parts, r.loc.Sprintf("🖥️ Steal %.1f%% %s", stats.CPUStealAvg, riskIcon(stats.RiskDetails["cpu_steal"])))
+	}
+	if r.showSection(stats, "iowait") {
+		parts = append(parts, r.loc.Sprintf("⏳ IOWait %.1f%% %s", stats.CPUIoWaitAvg, riskIcon(stats.RiskDetails["cpu_iowait"])))
+	}
+	if r.showSection(stats, "io_latency") {
+		parts = append(parts, r.loc.Sprintf("💾 P95 %.0fms %s", stats.IOLatencyP95, riskIcon(stats.RiskDetails["io_latency"])))
+	}
+	if r.showSection(stats, "memory") {
+		parts = append(parts, r.loc.Sprintf("🧠 可用 %.0f%% %s", stats.MemoryAvailablePercent, riskIcon(stats.RiskDetails["memory"])))
+	}
+	if len(parts) > 0 {
+		buf.WriteString(strings.Join(parts, " | "))
+		buf.WriteString("\n")
+	}
+
+	buf.WriteString(r.loc.Sprintf("📈 评分 %.0f/100 %s", stats.TotalScore, r.loc.T(describeRiskLevel(stats.RiskLevel))))
+	if !stats.DataSufficiency {
+		buf.WriteString(r.loc.T(" (数据不足)"))
+	}
+	if len(stats.StaleMetrics) > 0 {
+		buf.WriteString(r.loc.Sprintf(" (%d 项指标停止更新)", len(stats.StaleMetrics)))
+	}
+	if n := len(stats.CollectErrors); n > 0 {
+		buf.WriteString(r.loc.Sprintf(" (%d 项指标采集失败)", n))
+	}
+	if stats.BusinessHours != "" {
+		buf.WriteString(r.loc.Sprintf(" (%s)", stats.BusinessHours))
+	}
+	buf.WriteString("\n")
+	if c := stats.Consistency; c != nil && c.Level == analyzer.ConsistencyIsolated {
+		buf.WriteString(r.loc.T("⚠️ 劣化仅见于单项指标，可能为偶发噪声\n"))
+	}
+
+	if stats.Escalated {
+		buf.WriteString(r.loc.Sprintf("⚠️ 连续 %s评分偏低，建议尽快处理\n", describeStreak(r.loc, stats.Period, stats.RiskStreak)))
+	}
+
+	if stats.Recommendation != "" {
+		buf.WriteString(r.loc.Sprintf("📋 %s (置信度 %s)\n", r.loc.T(describeRecommendation(stats.Recommendation)), r.loc.T(describeConfidence(stats.RecommendationConfidence))))
+	}
+
+	if aiAnalysis != "" {
+		buf.WriteString("🤖 ")
+		buf.WriteString(aiAnalysis)
+		buf.WriteString("\n")
+	}
+
+	return buf.String()
+}
+
+// truncateRunes 按字符（而非字节）截断文本，超出时以 "…" 结尾；max <= 0 表示不限制
+func truncateRunes(text string, max int) string {
+	if max <= 0 {
+		return text
+	}
+	runes := []rune(text)
+	if len(runes) <= max {
+		return text
+	}
+	return string(runes[:max]) + "…"
+}
+
+// riskIcon 提取风险描述开头的状态图标（如 "⚠️ 中等" → "⚠️"）
+func riskIcon(detail string) string {
+	if fields := strings.Fields(detail); len(fields) > 0 {
+		return fields[0]
+	}
+	return ""
+}
+
+// describeRiskLevel 风险等级的简短描述
+func describeRiskLevel(level analyzer.RiskLevel) string {
+	switch level {
+	case analyzer.RiskLevelExcellent:
+		return "✅ 优秀"
+	case analyzer.RiskLevelGood:
+		return "🟢 良好"
+	case analyzer.RiskLevelMedium:
+		return "⚠️ 中等"
+	default:
+		return "🔴 严重"
+	}
+}
+
+// describeCPUTenancy CPU 独享判定的中文描述
+func describeCPUTenancy(t analyzer.CPUTenancy) string {
+	switch t {
+	case analyzer.CPUTenancyDedicated:
+		return "疑似独享核心"
+	case analyzer.CPUTenancyShared:
+		return "疑似共享核心"
+	default:
+		return "未知"
+	}
+}
+
+// describeRecommendation 续费建议的中文描述
+func describeRecommendation(rec analyzer.Recommendation) string {
+	switch rec {
+	case analyzer.RecommendationRenew:
+		return "✅ 建议续费"
+	case analyzer.RecommendationSwitch:
+		return "🔴 建议更换"
+	default:
+		return "⚠️ 继续观察"
+	}
+}
+
+// describeConsistency 多指标一致性的中文描述，劣化时段不足时返回空串
+func describeConsistency(loc *locale.Locale, c *analyzer.SignalConsistency) string {
+	if c == nil {
+		return ""
+	}
+	signals := strings.Join(c.SignalNames(), "/")
+	switch c.Level {
+	case analyzer.ConsistencyConsistent:
+		return loc.Sprintf("高，%d 个劣化小时中 %d 个多项指标同步劣化（%s），超售证据充分", c.BadHours, c.CoBadHours, signals)
+	case analyzer.ConsistencyIsolated:
+		return loc.Sprintf("低，%d 个劣化小时基本只有单项指标异常（%s），可能为偶发噪声，结论需谨慎", c.BadHours, signals)
+	default:
+		return loc.Sprintf("中，%d 个劣化小时中 %d 个多项指标同步劣化（%s）", c.BadHours, c.CoBadHours, signals)
+	}
+}
+
+// describeConfidence 置信度的中文描述
+func describeConfidence(c analyzer.Confidence) string {
+	switch c {
+	case analyzer.ConfidenceHigh:
+		return "高"
+	case analyzer.ConfidenceMedium:
+		return "中"
+	default:
+		return "低"
+	}
+}
+
 // escapeHTML 转义 HTML 特殊字符，避免被 Telegram 解析为 HTML 标签
 func escapeHTML(text string) string {
 	// 按顺序替换：先 &，再 < 和 >
@@ -180,6 +724,13 @@ func escapeHTML(text string) string {
 
 // sendMessageWithRetry 发送消息到 Telegram（带重试机制）
 func (r *TelegramReporter) sendMessageWithRetry(text string, maxRetries int) error {
+	return withRetry(maxRetries, func() error {
+		return r.sendMessage(text)
+	})
+}
+
+// withRetry 执行发送操作（带重试机制）
+func withRetry(maxRetries int, send func() error) error {
 	var lastErr error
 	for i := 0; i < maxRetries; i++ {
 		if i > 0 {
@@ -187,7 +738,7 @@ func (r *TelegramReporter) sendMessageWithRetry(text string, maxRetries int) err
 			wait := time.Duration(1<<uint(i-1)) * time.Second
 			time.Sleep(wait)
 		}
-		if err := r.sendMessage(text); err != nil {
+		if err := send(); err != nil {
 			lastErr = err
 			// 记录重试日志（内部不再 import log，通过返回错误传递）
 			continue
@@ -197,6 +748,15 @@ func (r *TelegramReporter) sendMessageWithRetry(text string, maxRetries int) err
 	return fmt.Errorf("发送失败（重试 %d 次）: %w", maxRetries, lastErr)
 }
 
+// redactToken 去除网络错误中 URL 包含的 bot token，避免写入日志与投递记录
+func (r *TelegramReporter) redactToken(err error) error {
+	var urlErr *neturl.Error
+	if errors.As(err, &urlErr) && r.botToken != "" {
+		urlErr.URL = strings.ReplaceAll(urlErr.URL, r.botToken, "***")
+	}
+	return err
+}
+
 // sendMessage 发送消息到 Telegram
 func (r *TelegramReporter) sendMessage(text string) error {
 	url := fmt.Sprintf("https://api.telegram.org/bot%s/sendMessage", r.botToken)
@@ -209,6 +769,9 @@ func (r *TelegramReporter) sendMessage(text string) error {
 		"text":       escapedText,
 		"parse_mode": "HTML",
 	}
+	if r.threadID > 0 {
+		payload["message_thread_id"] = r.threadID
+	}
 
 	jsonData, err := json.Marshal(payload)
 	if err != nil {
@@ -217,7 +780,7 @@ func (r *TelegramReporter) sendMessage(text string) error {
 
 	resp, err := r.client.Post(url, "application/json", bytes.NewBuffer(jsonData))
 	if err != nil {
-		return fmt.Errorf("发送消息失败: %w", err)
+		return fmt.Errorf("发送消息失败: %w", r.redactToken(err))
 	}
 	defer resp.Body.Close()
 
@@ -229,11 +792,68 @@ func (r *TelegramReporter) sendMessage(text string) error {
 	return nil
 }
 
+// sendDocument 以文件附件形式发送内容到 Telegram
+// 文件内容为纯文本，无需 HTML 转义；caption 限制 1024 字符
+func (r *TelegramReporter) sendDocument(filename, content, caption string) error {
+	url := fmt.Sprintf("https://api.telegram.org/bot%s/sendDocument", r.botToken)
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	if err := writer.WriteField("chat_id", r.chatID); err != nil {
+		return fmt.Errorf("构建请求失败: %w", err)
+	}
+	if r.threadID > 0 {
+		if err := writer.WriteField("message_thread_id", strconv.FormatInt(r.threadID, 10)); err != nil {
+			return fmt.Errorf("构建请求失败: %w", err)
+		}
+	}
+	if caption != "" {
+		if err := writer.WriteField("caption", caption); err != nil {
+			return fmt.Errorf("构建请求失败: %w", err)
+		}
+	}
+	part, err := writer.CreateFormFile("document", filename)
+	if err != nil {
+		return fmt.Errorf("构建请求失败: %w", err)
+	}
+	if _, err := io.WriteString(part, content); err != nil {
+		return fmt.Errorf("构建请求失败: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("构建请求失败: %w", err)
+	}
+
+	resp, err := r.client.Post(url, writer.FormDataContentType(), &body)
+	if err != nil {
+		return fmt.Errorf("发送文件失败: %w", r.redactToken(err))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("Telegram API 错误 (%d): %s", resp.StatusCode, string(respBody))
+	}
+
+	return nil
+}
+
 // TestConnection 测试 Telegram 连接
 func (r *TelegramReporter) TestConnection() error {
 	return r.sendMessage("✅ 超了么 (chaoleme) 已连接成功！")
 }
 
+// formatDuration 格式化时长为可读字符串（如 4 小时、35 分钟）
+func formatDuration(loc *locale.Locale, d time.Duration) string {
+	if d >= time.Hour {
+		hours := d.Hours()
+		if hours == float64(int(hours)) {
+			return loc.Sprintf("%d 小时", int(hours))
+		}
+		return loc.Sprintf("%.1f 小时", hours)
+	}
+	return loc.Sprintf("%d 分钟", int(d.Minutes()))
+}
+
 // formatHourRange 格式化单个时间点为小时范围（如 14:00-15:00）
 func formatHourRange(t time.Time) string {
 	hour := t.Hour()
@@ -275,14 +895,14 @@ func findHighLowLoadHours(hourly []analyzer.HourlyStats) (high, low []analyzer.H
 }
 
 // formatHoursList 格式化多个小时统计为可读字符串
-func formatHoursList(hours []analyzer.HourlyStats) string {
+func formatHoursList(loc *locale.Locale, hours []analyzer.HourlyStats) string {
 	if len(hours) == 0 {
 		return "-"
 	}
 
 	var parts []string
 	for _, h := range hours {
-		parts = append(parts, fmt.Sprintf("%02d:00 (S:%.1f%% W:%.1f%%)",
+		parts = append(parts, loc.Sprintf("%02d:00 (S:%.1f%% W:%.1f%%)",
 			h.Hour, h.CPUStealAvg, h.CPUIoWaitAvg))
 	}
 
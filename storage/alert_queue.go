@@ -0,0 +1,129 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// QueuedAlert 等待汇总发送的告警
+type QueuedAlert struct {
+	ID        int64
+	Timestamp time.Time
+	Hostname  string
+	Target    string  // 报告目标（如 Telegram chat_id），目标相同的告警才会合并
+	Period    string  // 报告类型
+	Score     float64 // 综合评分
+	Summary   string  // 单行摘要，用于汇总消息
+	Report    string  // 完整报告文本，窗口内仅有一条告警时原样发送
+}
+
+// AlertQueue 多机共享的告警队列
+// 多个 chaoleme 进程写入同一个 SQLite 文件，由唯一的协调者定期取出并合并发送。
+// 队列本身不做选主：同一报告目标只能配置一个协调者，否则告警会被多个协调者瓜分。
+type AlertQueue struct {
+	db *sql.DB
+}
+
+// OpenAlertQueue 打开（或创建）共享告警队列
+func OpenAlertQueue(path string) (*AlertQueue, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("创建告警队列目录失败: %w", err)
+	}
+
+	// 多进程并发写入，设置 busy_timeout 等待其他进程释放锁
+	db, err := sql.Open("sqlite", sqliteDSN(path))
+	if err != nil {
+		return nil, fmt.Errorf("打开告警队列失败: %w", err)
+	}
+
+	schema := `
+	CREATE TABLE IF NOT EXISTS alert_queue (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		timestamp INTEGER NOT NULL,
+		hostname TEXT NOT NULL,
+		target TEXT NOT NULL,
+		period TEXT NOT NULL,
+		score REAL NOT NULL,
+		summary TEXT,
+		report TEXT
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_alert_queue_target ON alert_queue(target, timestamp);
+	`
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("初始化告警队列失败: %w", err)
+	}
+
+	return &AlertQueue{db: db}, nil
+}
+
+// Close 关闭告警队列
+func (q *AlertQueue) Close() error {
+	return q.db.Close()
+}
+
+// Enqueue 将告警写入队列
+func (q *AlertQueue) Enqueue(a *QueuedAlert) error {
+	_, err := q.db.Exec(
+		"INSERT INTO alert_queue (timestamp, hostname, target, period, score, summary, report) VALUES (?, ?, ?, ?, ?, ?, ?)",
+		a.Timestamp.Unix(), a.Hostname, a.Target, a.Period, a.Score, a.Summary, a.Report,
+	)
+	if err != nil {
+		return fmt.Errorf("告警入队失败: %w", err)
+	}
+	return nil
+}
+
+// Pending 读取指定目标的全部排队告警（按时间升序），不删除
+// 发送成功后再调用 Ack 删除，发送失败时告警保留在队列中，下个窗口重试
+func (q *AlertQueue) Pending(target string) ([]*QueuedAlert, error) {
+	rows, err := q.db.Query(
+		"SELECT id, timestamp, hostname, target, period, score, summary, report FROM alert_queue WHERE target = ? ORDER BY timestamp ASC, id ASC",
+		target,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("查询告警队列失败: %w", err)
+	}
+	defer rows.Close()
+
+	var alerts []*QueuedAlert
+	for rows.Next() {
+		a := &QueuedAlert{}
+		var ts int64
+		var summary, report sql.NullString
+		if err := rows.Scan(&a.ID, &ts, &a.Hostname, &a.Target, &a.Period, &a.Score, &summary, &report); err != nil {
+			return nil, fmt.Errorf("扫描行失败: %w", err)
+		}
+		a.Timestamp = time.Unix(ts, 0)
+		a.Summary = summary.String
+		a.Report = report.String
+		alerts = append(alerts, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("读取告警队列失败: %w", err)
+	}
+	return alerts, nil
+}
+
+// Ack 删除已成功发送的告警；按 ID 删除，读取之后新入队的告警不受影响
+func (q *AlertQueue) Ack(alerts []*QueuedAlert) error {
+	tx, err := q.db.Begin()
+	if err != nil {
+		return fmt.Errorf("开启事务失败: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, a := range alerts {
+		if _, err := tx.Exec("DELETE FROM alert_queue WHERE id = ?", a.ID); err != nil {
+			return fmt.Errorf("清理告警队列失败: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("提交事务失败: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package storage
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// BenchResult 存储层写入基准测试结果
+type BenchResult struct {
+	Rows      int
+	BatchSize int
+
+	SingleDuration time.Duration // 逐条 Save 写入 Rows 行的耗时
+	BatchDuration  time.Duration // 按 BatchSize 分批 SaveBatch 写入 Rows 行的耗时
+	DBSize         int64         // 两轮写入后的数据库文件大小（字节，含 -wal 文件）
+}
+
+// SingleRate 逐条写入的速率（行/秒）
+func (r *BenchResult) SingleRate() float64 {
+	return float64(r.Rows) / r.SingleDuration.Seconds()
+}
+
+// BatchRate 批量写入的速率（行/秒）
+func (r *BenchResult) BatchRate() float64 {
+	return float64(r.Rows) / r.BatchDuration.Seconds()
+}
+
+// BytesPerRow 每行平均占用的磁盘空间
+func (r *BenchResult) BytesPerRow() float64 {
+	return float64(r.DBSize) / float64(2*r.Rows)
+}
+
+// benchMetricTypes 合成样本轮流使用的指标类型，带 extra 的类型与实际采集的 extra 大小相当
+var benchMetricTypes = []MetricType{MetricTypeCPUSteal, MetricTypeCPUIoWait, MetricTypeCPULoad, MetricTypeIOLatency, MetricTypeMemory}
+
+// benchMetric 生成第 i 条合成样本
+func benchMetric(base time.Time, i int) *Metric {
+	m := &Metric{
+		Timestamp: base.Add(time.Duration(i) * time.Second),
+		Type:      benchMetricTypes[i%len(benchMetricTypes)],
+		Value:     float64(i%1000) / 10,
+	}
+	if m.Type == MetricTypeIOLatency {
+		m.Extra = map[string]interface{}{"write_latency_ms": m.Value, "sync_latency_ms": m.Value, "fs_type": "ext4"}
+	}
+	return m
+}
+
+// Benchmark 在 dir 下创建临时数据库，先逐条 Save、再分批 SaveBatch 各写入 rows 行合成样本，
+// 测量写入速率与数据库大小，结束后删除临时数据库
+// dir 应与正式数据库位于同一磁盘、opts 与正式数据库一致，结果才能反映实际写入能力
+func Benchmark(dir string, rows, batchSize int, opts Options) (*BenchResult, error) {
+	if rows <= 0 || batchSize <= 0 {
+		return nil, fmt.Errorf("写入行数与批大小必须大于 0")
+	}
+
+	file, err := os.CreateTemp(dir, "chaoleme-bench-*.db")
+	if err != nil {
+		return nil, fmt.Errorf("创建临时数据库失败: %w", err)
+	}
+	path := file.Name()
+	file.Close()
+	defer func() {
+		for _, suffix := range []string{"", "-journal", "-wal", "-shm"} {
+			os.Remove(path + suffix)
+		}
+	}()
+
+	s, err := New(path, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer s.Close()
+
+	result := &BenchResult{Rows: rows, BatchSize: batchSize}
+	base := time.Now().Add(-time.Duration(2*rows) * time.Second)
+
+	start := time.Now()
+	for i := 0; i < rows; i++ {
+		if err := s.Save(benchMetric(base, i)); err != nil {
+			return nil, err
+		}
+	}
+	result.SingleDuration = time.Since(start)
+
+	start = time.Now()
+	batch := make([]*Metric, 0, batchSize)
+	for i := rows; i < 2*rows; i++ {
+		batch = append(batch, benchMetric(base, i))
+		if len(batch) == batchSize || i == 2*rows-1 {
+			if err := s.SaveBatch(batch); err != nil {
+				return nil, err
+			}
+			batch = batch[:0]
+		}
+	}
+	result.BatchDuration = time.Since(start)
+
+	// WAL 模式下新写入的页仍在 -wal 文件中：先检查点写回主文件，未能截断的部分计入大小
+	if opts.WAL {
+		if _, _, err := s.CheckpointWAL(); err != nil {
+			return nil, err
+		}
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取临时数据库大小失败: %w", err)
+	}
+	result.DBSize = info.Size()
+	if wal, err := os.Stat(path + "-wal"); err == nil {
+		result.DBSize += wal.Size()
+	}
+	return result, nil
+}
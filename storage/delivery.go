@@ -0,0 +1,58 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Delivery 一次报告投递记录
+// 同一份报告的各投递渠道共享 Period 与 ReportAt，可据此判断哪些渠道仍需补发
+type Delivery struct {
+	Timestamp time.Time // 投递时间
+	Period    string    // 报告类型（daily/weekly/monthly，机群汇总为 digest）
+	ReportAt  time.Time // 报告周期结束时间，标识同一份报告
+	Reporter  string    // 投递渠道（如 telegram、json、fleet）
+	Success   bool
+	Error     string // 失败原因，成功时为空
+}
+
+// SaveDelivery 记录一次报告投递结果
+func (s *Storage) SaveDelivery(d *Delivery) error {
+	s.maintMu.RLock()
+	defer s.maintMu.RUnlock()
+	if _, err := s.db.Exec(
+		"INSERT INTO report_deliveries (timestamp, period, report_at, reporter, success, error) VALUES (?, ?, ?, ?, ?, ?)",
+		d.Timestamp.Unix(), d.Period, d.ReportAt.Unix(), d.Reporter, d.Success, d.Error,
+	); err != nil {
+		return fmt.Errorf("保存投递记录失败: %w", err)
+	}
+	return nil
+}
+
+// QueryDeliveries 返回最近 limit 条投递记录（按时间降序）
+func (s *Storage) QueryDeliveries(limit int) ([]*Delivery, error) {
+	rows, err := s.db.Query(
+		"SELECT timestamp, period, report_at, reporter, success, error FROM report_deliveries ORDER BY timestamp DESC, id DESC LIMIT ?",
+		limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("查询投递记录失败: %w", err)
+	}
+	defer rows.Close()
+
+	var deliveries []*Delivery
+	for rows.Next() {
+		d := &Delivery{}
+		var ts, reportAt int64
+		var errText sql.NullString
+		if err := rows.Scan(&ts, &d.Period, &reportAt, &d.Reporter, &d.Success, &errText); err != nil {
+			return nil, fmt.Errorf("扫描行失败: %w", err)
+		}
+		d.Timestamp = time.Unix(ts, 0)
+		d.ReportAt = time.Unix(reportAt, 0)
+		d.Error = errText.String
+		deliveries = append(deliveries, d)
+	}
+	return deliveries, rows.Err()
+}
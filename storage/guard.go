@@ -0,0 +1,352 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 降级模式参数
+const (
+	guardFailureThreshold = 3                // 连续写入失败多少次后进入降级模式
+	guardInitialBackoff   = 1 * time.Minute  // 降级后首次重试间隔
+	guardMaxBackoff       = 30 * time.Minute // 重试间隔上限
+)
+
+// ErrWriteDegraded 降级模式下跳过数据库写入
+var ErrWriteDegraded = errors.New("数据库写入处于降级模式，已跳过")
+
+// WriteGuard 数据库写入守卫
+// 所有采集写入经由此处：始终更新内存中的最新值缓存；
+// 数据库持续写入失败（只读、磁盘满等）时只记录一次诊断日志并进入降级模式，
+// 按指数退避定期重试，写入恢复后自动退出降级模式。
+type WriteGuard struct {
+	store *Storage
+
+	mu        sync.Mutex
+	failures  int
+	degraded  bool
+	backoff   time.Duration
+	nextRetry time.Time
+	latest    map[MetricType]*Metric
+	dedup     map[MetricType]*dedupState
+	snapshots bool // 是否保存原始快照
+	align     map[MetricType]time.Duration
+	export    func([]*Metric) // 外部推送回调（如 InfluxDB 行协议），收到全部采集样本，不受去重与降级影响
+
+	// 自上次 TakeCounts 以来的写入计数，供守护进程定期输出心跳日志
+	counts        map[MetricType]*SaveCount
+	collectErrors map[string]int // 按 collect_error 的 metric 字段统计采集失败次数
+}
+
+// SaveCount 某类指标在统计窗口内的写入计数
+type SaveCount struct {
+	Collected int // 采集到的样本数
+	Stored    int // 成功写入数据库的样本数（去重跳过、降级跳过与写入失败不计）
+}
+
+// ExtraRepeats 去重时记录在写入行 extra 中的键：该行之前被跳过的样本数
+// 被跳过的样本与上一条写入行的值相差不超过 epsilon，分析时按上一条的值还原
+const ExtraRepeats = "repeats"
+
+// ExtraSelfTestIOWait IOWait 样本中记录的已扣除自身 I/O 测试的百分点，仅供查看
+const ExtraSelfTestIOWait = "self_test_iowait"
+
+// dedupAnnotations 仅供查看的 extra 键：不影响去重判断，样本被跳过时随之丢弃
+// 否则每轮 I/O 测试后的 IOWait 样本都会带上该字段而强制写入，去重失效
+var dedupAnnotations = map[string]bool{
+	ExtraSelfTestIOWait: true,
+}
+
+// annotationOnly extra 为空或只包含 dedupAnnotations 中的键
+func annotationOnly(extra map[string]interface{}) bool {
+	for k := range extra {
+		if !dedupAnnotations[k] {
+			return false
+		}
+	}
+	return true
+}
+
+// dedupState 单个指标类型的去重状态
+type dedupState struct {
+	epsilon   float64
+	heartbeat int
+	last      float64 // 上次写入的值
+	written   bool    // 是否已写入过（首个样本总是写入）
+	skipped   int     // 自上次写入后跳过的样本数
+}
+
+// NewWriteGuard 创建写入守卫
+func NewWriteGuard(store *Storage) *WriteGuard {
+	return &WriteGuard{
+		store:  store,
+		latest: make(map[MetricType]*Metric),
+	}
+}
+
+// EnableDedup 为指定类型开启去重：与上次写入值相差不超过 epsilon 的样本跳过写入，
+// 但连续跳过 heartbeat-1 个后仍写入一次，使"数值稳定"与"数据缺失"可以区分。
+// 写入行的 extra 中记录之前跳过的样本数（ExtraRepeats），分析时据此还原，平均值按时长加权；
+// 被跳过样本在 epsilon 内的细微波动会丢失，分位数误差不超过 epsilon
+func (g *WriteGuard) EnableDedup(metricType MetricType, epsilon float64, heartbeat int) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.dedup == nil {
+		g.dedup = make(map[MetricType]*dedupState)
+	}
+	g.dedup[metricType] = &dedupState{epsilon: epsilon, heartbeat: heartbeat}
+}
+
+// applyDedup 过滤掉可跳过的样本，并为写入的样本附加跳过计数（调用方需持有锁）
+// 带 extra 的样本（startup 等标记、时钟跳变等附加字段）总是写入，否则跳过时附加字段会丢失；
+// 它同样携带之前跳过的计数并成为后续比较的基准，分析时才能按相邻行正确还原；
+// 仅带 dedupAnnotations 字段的样本按普通样本处理
+func (g *WriteGuard) applyDedup(metrics []*Metric) []*Metric {
+	if len(g.dedup) == 0 {
+		return metrics
+	}
+
+	kept := make([]*Metric, 0, len(metrics))
+	for _, m := range metrics {
+		st := g.dedup[m.Type]
+		if st == nil {
+			kept = append(kept, m)
+			continue
+		}
+		if annotationOnly(m.Extra) && st.written && math.Abs(m.Value-st.last) <= st.epsilon && st.skipped < st.heartbeat-1 {
+			st.skipped++
+			continue
+		}
+		if st.skipped > 0 {
+			tagged := *m
+			tagged.Extra = make(map[string]interface{}, len(m.Extra)+1)
+			for k, v := range m.Extra {
+				tagged.Extra[k] = v
+			}
+			tagged.Extra[ExtraRepeats] = st.skipped
+			m = &tagged
+		}
+		st.last, st.written, st.skipped = m.Value, true, 0
+		kept = append(kept, m)
+	}
+	return kept
+}
+
+// EnableAlignment 将指定类型的样本时间戳取整到最近的 interval 边界后再写入，
+// 同一轮采集的不同指标共享相同时间戳，跨指标关联与按小时分桶无需模糊时间窗口。
+// 累计计数器类型不取整：分析时按相邻样本的差值除以时间差计算速率，取整会改变分母
+func (g *WriteGuard) EnableAlignment(metricType MetricType, interval time.Duration) {
+	if cumulativeMetricTypes[metricType] {
+		return
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.align == nil {
+		g.align = make(map[MetricType]time.Duration)
+	}
+	g.align[metricType] = interval
+}
+
+// applyAlignment 返回时间戳已取整的样本（复制后修改，不影响调用方持有的指标；调用方需持有锁）
+func (g *WriteGuard) applyAlignment(metrics []*Metric) []*Metric {
+	if len(g.align) == 0 {
+		return metrics
+	}
+
+	aligned := make([]*Metric, len(metrics))
+	for i, m := range metrics {
+		if interval := g.align[m.Type]; interval > 0 {
+			rounded := *m
+			rounded.Timestamp = m.Timestamp.Round(interval)
+			m = &rounded
+		}
+		aligned[i] = m
+	}
+	return aligned
+}
+
+// EnableSnapshots 开启原始快照保存（storage.raw_snapshots）
+func (g *WriteGuard) EnableSnapshots() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.snapshots = true
+}
+
+// EnableExport 设置外部推送回调，每次写入时以对齐后、去重前的样本调用
+// 回调在写入路径上同步执行，不应阻塞
+func (g *WriteGuard) EnableExport(fn func([]*Metric)) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.export = fn
+}
+
+// SaveSnapshot 保存原始快照；未开启或处于降级模式时直接跳过，失败不计入降级判定
+func (g *WriteGuard) SaveSnapshot(ts time.Time, source, content string) error {
+	g.mu.Lock()
+	skip := !g.snapshots || g.degraded || content == ""
+	// 快照与 Steal 同一轮采集，按相同边界取整，-replay 才能按时间戳与已存值对上
+	if interval := g.align[MetricTypeCPUSteal]; interval > 0 {
+		ts = ts.Round(interval)
+	}
+	g.mu.Unlock()
+	if skip {
+		return nil
+	}
+	return g.store.SaveSnapshot(ts, source, content)
+}
+
+// Save 保存单条指标
+func (g *WriteGuard) Save(m *Metric) error {
+	return g.SaveBatch([]*Metric{m})
+}
+
+// SaveBatch 在同一事务中保存多条指标
+// 校验失败的数据直接返回错误，不计入写入失败次数
+func (g *WriteGuard) SaveBatch(metrics []*Metric) error {
+	for _, m := range metrics {
+		if err := m.Validate(); err != nil {
+			return fmt.Errorf("保存指标失败: %w", err)
+		}
+	}
+
+	g.mu.Lock()
+	metrics = g.applyAlignment(metrics)
+	if g.export != nil {
+		g.export(metrics)
+	}
+	for _, m := range metrics {
+		g.latest[m.Type] = m
+		g.count(m.Type).Collected++
+		if m.Type == MetricTypeCollectError {
+			if g.collectErrors == nil {
+				g.collectErrors = make(map[string]int)
+			}
+			name, _ := m.Extra["metric"].(string)
+			g.collectErrors[name]++
+		}
+	}
+	if g.degraded && time.Now().Before(g.nextRetry) {
+		g.mu.Unlock()
+		return ErrWriteDegraded
+	}
+	metrics = g.applyDedup(metrics)
+	g.mu.Unlock()
+
+	if len(metrics) == 0 {
+		return nil
+	}
+
+	var err error
+	if len(metrics) == 1 {
+		err = g.store.Save(metrics[0])
+	} else {
+		err = g.store.SaveBatch(metrics)
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if err != nil {
+		g.recordFailure(err)
+		return err
+	}
+	g.recordSuccess()
+	for _, m := range metrics {
+		g.count(m.Type).Stored++
+	}
+	return nil
+}
+
+// count 返回某类指标的计数器，不存在时创建（调用方需持有锁）
+func (g *WriteGuard) count(metricType MetricType) *SaveCount {
+	if g.counts == nil {
+		g.counts = make(map[MetricType]*SaveCount)
+	}
+	c := g.counts[metricType]
+	if c == nil {
+		c = &SaveCount{}
+		g.counts[metricType] = c
+	}
+	return c
+}
+
+// TakeCounts 返回自上次调用以来各类指标的写入计数与按指标统计的采集失败次数，并清零
+func (g *WriteGuard) TakeCounts() (map[MetricType]SaveCount, map[string]int) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	counts := make(map[MetricType]SaveCount, len(g.counts))
+	for t, c := range g.counts {
+		counts[t] = *c
+	}
+	errs := g.collectErrors
+	g.counts, g.collectErrors = nil, nil
+	return counts, errs
+}
+
+// recordFailure 记录写入失败，达到阈值时进入降级模式（调用方需持有锁）
+func (g *WriteGuard) recordFailure(err error) {
+	g.failures++
+
+	if g.degraded {
+		// 重试仍失败，加大退避间隔，不重复刷日志
+		g.backoff *= 2
+		if g.backoff > guardMaxBackoff {
+			g.backoff = guardMaxBackoff
+		}
+		g.nextRetry = time.Now().Add(g.backoff)
+		return
+	}
+
+	if g.failures < guardFailureThreshold {
+		return
+	}
+
+	g.degraded = true
+	g.backoff = guardInitialBackoff
+	g.nextRetry = time.Now().Add(g.backoff)
+	log.Printf("⚠️ 数据库连续 %d 次写入失败（%s），进入降级模式：继续采集到内存，每隔 %v 起重试写入。最近错误: %v",
+		g.failures, diagnoseWriteError(err), g.backoff, err)
+}
+
+// recordSuccess 记录写入成功，退出降级模式（调用方需持有锁）
+func (g *WriteGuard) recordSuccess() {
+	if g.degraded {
+		log.Printf("✅ 数据库写入已恢复，退出降级模式")
+	}
+	g.failures = 0
+	g.degraded = false
+	g.backoff = 0
+}
+
+// Degraded 是否处于降级模式
+func (g *WriteGuard) Degraded() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.degraded
+}
+
+// Latest 获取内存中某类指标的最新值（降级模式下仍会更新），无数据时返回 nil
+func (g *WriteGuard) Latest(metricType MetricType) *Metric {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.latest[metricType]
+}
+
+// diagnoseWriteError 根据错误信息给出可能原因
+func diagnoseWriteError(err error) string {
+	msg := strings.ToLower(err.Error())
+	switch {
+	case strings.Contains(msg, "readonly") || strings.Contains(msg, "read-only"):
+		return "数据库所在文件系统可能已变为只读，请检查磁盘状态（dmesg）"
+	case strings.Contains(msg, "disk is full") || strings.Contains(msg, "no space"):
+		return "磁盘空间已满"
+	case strings.Contains(msg, "locked") || strings.Contains(msg, "busy"):
+		return "数据库被其他进程锁定"
+	default:
+		return "原因未知"
+	}
+}
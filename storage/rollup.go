@@ -0,0 +1,256 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// FlagRollup 小时聚合行的标记（extra 中的键），值为聚合信息（count/min/max/p95）
+const FlagRollup = "rollup"
+
+// rollupBucket 聚合粒度
+const rollupBucket = int64(time.Hour / time.Second)
+
+// notRollup 排除聚合行的过滤条件
+const notRollup = " AND (CASE WHEN extra IS NULL OR extra = '' THEN 1 ELSE json_extract(extra, '$." + FlagRollup + "') IS NULL END)"
+
+// cumulativeMetricTypes 累计计数器类型：聚合时保留每小时最后一个样本而非取平均，差分计算仍然成立
+var cumulativeMetricTypes = map[MetricType]bool{
+	MetricTypeDiskStats: true,
+	MetricTypeNetwork:   true,
+	MetricTypeNUMA:      true,
+	MetricTypeSchedStat: true,
+}
+
+// lastValueMetricTypes 离散计数类型：均值（如 3.5 个在线 vCPU）没有意义，聚合时同样保留最后一个样本，
+// 小时内的最小值与最大值仍记录在 rollup 中
+var lastValueMetricTypes = map[MetricType]bool{
+	MetricTypeCPUOnline: true,
+}
+
+// rawOnlyMetricTypes 不参与聚合的类型：按样本条数统计事件次数，合并会丢失次数
+var rawOnlyMetricTypes = map[MetricType]bool{
+	MetricTypeCPUStealSuspend: true,
+	MetricTypeIOStall:         true,
+	MetricTypeCollectError:    true,
+}
+
+// Rollup 将 cutoff 之前的原始样本按小时聚合为一行并删除原始样本，返回被聚合的原始行数
+// 逐小时在独立事务中处理，小时之间短暂暂停，与 Cleanup 一样避免长时间持有写锁。
+// 聚合行的 value 为小时均值，extra 中数值字段取均值、其他字段取最后一个样本；
+// 带标记（startup、bootstrap）的样本直接删除，不计入聚合。
+func (s *Storage) Rollup(cutoff time.Time) (int64, error) {
+	end := cutoff.Unix() / rollupBucket * rollupBucket // 只聚合完整的小时
+
+	var total int64
+	from := int64(0)
+	for {
+		hour, ok, err := s.nextRawHour(from, end)
+		if err != nil {
+			return total, err
+		}
+		if !ok {
+			return total, nil
+		}
+
+		n, err := s.rollupHour(hour)
+		total += n
+		if err != nil {
+			return total, fmt.Errorf("聚合 %s 的数据失败: %w", time.Unix(hour, 0).Format("2006-01-02 15:04"), err)
+		}
+		from = hour + rollupBucket
+		if n > 0 {
+			time.Sleep(cleanupBatchPause)
+		}
+	}
+}
+
+// nextRawHour 返回 [from, end) 内最早一条待聚合原始样本所在的小时
+func (s *Storage) nextRawHour(from, end int64) (int64, bool, error) {
+	query := "SELECT MIN(timestamp) FROM metrics WHERE timestamp >= ? AND timestamp < ?" + notRollup
+	args := []interface{}{from, end}
+	for t := range rawOnlyMetricTypes {
+		query += " AND metric_type != ?"
+		args = append(args, string(t))
+	}
+
+	var first sql.NullInt64
+	if err := s.db.QueryRow(query, args...).Scan(&first); err != nil {
+		return 0, false, fmt.Errorf("查询待聚合数据失败: %w", err)
+	}
+	if !first.Valid {
+		return 0, false, nil
+	}
+	return first.Int64 / rollupBucket * rollupBucket, true, nil
+}
+
+// rawRow 待聚合的原始样本
+type rawRow struct {
+	id    int64
+	ts    int64
+	value float64
+	extra map[string]interface{}
+}
+
+// rollupHour 在一个事务中聚合指定小时的原始样本
+func (s *Storage) rollupHour(hour int64) (int64, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("开启事务失败: %w", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(
+		"SELECT id, timestamp, metric_type, value, extra FROM metrics WHERE timestamp >= ? AND timestamp < ?"+notRollup+" ORDER BY timestamp ASC",
+		hour, hour+rollupBucket,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("查询原始样本失败: %w", err)
+	}
+
+	groups := make(map[MetricType][]rawRow)
+	for rows.Next() {
+		var r rawRow
+		var metricType string
+		var extra sql.NullString
+		if err := rows.Scan(&r.id, &r.ts, &metricType, &r.value, &extra); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("扫描行失败: %w", err)
+		}
+		if extra.Valid && extra.String != "" {
+			json.Unmarshal([]byte(extra.String), &r.extra)
+		}
+		groups[MetricType(metricType)] = append(groups[MetricType(metricType)], r)
+	}
+	rows.Close()
+
+	var total int64
+	for metricType, group := range groups {
+		if rawOnlyMetricTypes[metricType] {
+			continue
+		}
+
+		var kept []rawRow
+		var ids []int64
+		for _, r := range group {
+			ids = append(ids, r.id)
+			if r.extra[FlagStartup] == nil && r.extra[FlagBootstrap] == nil && r.extra[FlagSuspend] == nil {
+				kept = append(kept, r)
+			}
+		}
+
+		if len(kept) > 0 {
+			m := aggregateRows(metricType, hour, kept)
+			extra, err := encodeExtra(m)
+			if err != nil {
+				return 0, err
+			}
+			if _, err := tx.Exec(
+				"INSERT INTO metrics (timestamp, metric_type, value, extra) VALUES (?, ?, ?, ?)",
+				m.Timestamp.Unix(), string(m.Type), m.Value, extra,
+			); err != nil {
+				return 0, fmt.Errorf("写入聚合行失败: %w", err)
+			}
+		}
+
+		if err := deleteByIDs(tx, ids); err != nil {
+			return 0, err
+		}
+		total += int64(len(group))
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("提交事务失败: %w", err)
+	}
+	return total, nil
+}
+
+// aggregateRows 将同一小时、同一类型的样本合并为一行
+func aggregateRows(metricType MetricType, hour int64, rows []rawRow) *Metric {
+	values := make([]float64, len(rows))
+	for i, r := range rows {
+		values[i] = r.value
+	}
+	sort.Float64s(values)
+
+	info := map[string]interface{}{
+		"count": len(rows),
+		"min":   values[0],
+		"max":   values[len(values)-1],
+		"p95":   values[(len(values)-1)*95/100],
+	}
+
+	// 累计计数器与离散计数保留最后一个样本
+	if cumulativeMetricTypes[metricType] || lastValueMetricTypes[metricType] {
+		last := rows[len(rows)-1]
+		extra := make(map[string]interface{}, len(last.extra)+1)
+		for k, v := range last.extra {
+			extra[k] = v
+		}
+		extra[FlagRollup] = info
+		return &Metric{Timestamp: time.Unix(last.ts, 0), Type: metricType, Value: last.value, Extra: extra}
+	}
+
+	// 去重跳过的样本（记在下一行的 repeats 中）按上一行的值计入均值与样本数；
+	// 本小时第一行的 repeats 属于上一小时，忽略
+	sum, weight := 0.0, 0.0
+	for i, r := range rows {
+		sum += r.value
+		weight++
+		if n, _ := r.extra[ExtraRepeats].(float64); i > 0 && n > 0 {
+			sum += rows[i-1].value * n
+			weight += n
+		}
+	}
+	info["count"] = int(weight)
+
+	// extra：数值字段取均值，其他字段取最后一个样本
+	extra := make(map[string]interface{})
+	sums := make(map[string]float64)
+	counts := make(map[string]int)
+	for _, r := range rows {
+		for k, v := range r.extra {
+			if k == ExtraRepeats {
+				continue
+			}
+			if f, ok := v.(float64); ok {
+				sums[k] += f
+				counts[k]++
+			} else {
+				extra[k] = v
+			}
+		}
+	}
+	for k, total := range sums {
+		extra[k] = total / float64(counts[k])
+	}
+	extra[FlagRollup] = info
+
+	// 时间戳取小时中点，落在按小时分桶查询的同一桶内
+	return &Metric{
+		Timestamp: time.Unix(hour+rollupBucket/2, 0),
+		Type:      metricType,
+		Value:     sum / weight,
+		Extra:     extra,
+	}
+}
+
+// deleteByIDs 按 id 删除行
+func deleteByIDs(tx *sql.Tx, ids []int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(ids)), ",")
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	if _, err := tx.Exec("DELETE FROM metrics WHERE id IN ("+placeholders+")", args...); err != nil {
+		return fmt.Errorf("删除已聚合的原始样本失败: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package storage
+
+import (
+	"fmt"
+	"time"
+)
+
+// sizeCapKeep 收紧保留期时至少保留的天数，保证日报仍有完整数据
+const sizeCapKeep = 1
+
+// sizeCapSheddable 超出容量上限时最先删除的低价值指标（不参与评分，仅展示或诊断），
+// 只删除 sizeCapKeep 天之前的部分
+var sizeCapSheddable = []MetricType{MetricTypeNetwork, MetricTypeCPUTemp, MetricTypeCollectError}
+
+// UsedBytes 数据库中数据实际占用的字节数（不含尚未回收的空闲页）
+func (s *Storage) UsedBytes() (int64, error) {
+	var pageCount, freeCount, pageSize int64
+	if err := s.db.QueryRow("PRAGMA page_count").Scan(&pageCount); err != nil {
+		return 0, fmt.Errorf("读取 page_count 失败: %w", err)
+	}
+	if err := s.db.QueryRow("PRAGMA freelist_count").Scan(&freeCount); err != nil {
+		return 0, fmt.Errorf("读取 freelist_count 失败: %w", err)
+	}
+	if err := s.db.QueryRow("PRAGMA page_size").Scan(&pageSize); err != nil {
+		return 0, fmt.Errorf("读取 page_size 失败: %w", err)
+	}
+	return (pageCount - freeCount) * pageSize, nil
+}
+
+// EnforceMaxSize 数据库超过 maxBytes 时逐步删除数据直到低于上限，返回每一步删除内容的说明
+// 依次执行，每步之后重新检查大小：
+//  1. 删除全部原始快照（仅供 -replay 使用）
+//  2. 删除 sizeCapSheddable 中低价值指标的旧数据
+//  3. 从 retentionDays-1 天起逐天收紧保留期，最少保留 sizeCapKeep 天
+//
+// 按已用页判断大小，文件本身需随后调用 Reclaim 缩小；收紧到底仍超出上限时返回错误
+func (s *Storage) EnforceMaxSize(maxBytes int64, retentionDays int) ([]string, error) {
+	var dropped []string
+	over := func() (bool, error) {
+		size, err := s.UsedBytes()
+		return size > maxBytes, err
+	}
+
+	if ok, err := over(); err != nil || !ok {
+		return nil, err
+	}
+
+	result, err := s.db.Exec("DELETE FROM raw_snapshots")
+	if err != nil {
+		return dropped, fmt.Errorf("删除原始快照失败: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n > 0 {
+		dropped = append(dropped, fmt.Sprintf("原始快照 %d 条", n))
+	}
+
+	keepCutoff := time.Now().AddDate(0, 0, -sizeCapKeep).Unix()
+	for _, t := range sizeCapSheddable {
+		if ok, err := over(); err != nil || !ok {
+			return dropped, err
+		}
+		n, err := s.deleteMetricsBatched("metric_type = ? AND timestamp < ?", string(t), keepCutoff)
+		if err != nil {
+			return dropped, fmt.Errorf("删除 %s 旧数据失败: %w", t, err)
+		}
+		if n > 0 {
+			dropped = append(dropped, fmt.Sprintf("%s %d 天前的数据 %d 条", t, sizeCapKeep, n))
+		}
+	}
+
+	for days := retentionDays - 1; days >= sizeCapKeep; days-- {
+		if ok, err := over(); err != nil || !ok {
+			return dropped, err
+		}
+		cutoff := time.Now().AddDate(0, 0, -days).Unix()
+		n, err := s.deleteMetricsBatched("timestamp < ?", cutoff)
+		if err != nil {
+			return dropped, fmt.Errorf("收紧保留期到 %d 天失败: %w", days, err)
+		}
+		if n > 0 {
+			dropped = append(dropped, fmt.Sprintf("%d 天前的全部指标 %d 条", days, n))
+		}
+	}
+
+	if ok, err := over(); err != nil {
+		return dropped, err
+	} else if ok {
+		return dropped, fmt.Errorf("已收紧到只保留 %d 天，数据库仍超过上限", sizeCapKeep)
+	}
+	return dropped, nil
+}
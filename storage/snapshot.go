@@ -0,0 +1,85 @@
+package storage
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"time"
+)
+
+// 原始快照来源
+const (
+	SnapshotProcStat = "proc_stat" // /proc/stat 的汇总 cpu 行
+	SnapshotLoadAvg  = "loadavg"   // /proc/loadavg 的内容
+)
+
+// Snapshot 采集时读取的原始 procfs 内容，用于事后回放验证派生指标
+type Snapshot struct {
+	Timestamp time.Time
+	Source    string
+	Content   string
+}
+
+// SaveSnapshot 以 gzip 压缩保存一条原始快照
+func (s *Storage) SaveSnapshot(ts time.Time, source, content string) error {
+	s.maintMu.RLock()
+	defer s.maintMu.RUnlock()
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write([]byte(content)); err != nil {
+		return fmt.Errorf("压缩原始快照失败: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("压缩原始快照失败: %w", err)
+	}
+
+	if _, err := s.db.Exec(
+		"INSERT INTO raw_snapshots (timestamp, source, data) VALUES (?, ?, ?)",
+		ts.Unix(), source, buf.Bytes(),
+	); err != nil {
+		return fmt.Errorf("保存原始快照失败: %w", err)
+	}
+	return nil
+}
+
+// QuerySnapshots 查询时间范围内指定来源的原始快照（按时间升序）
+func (s *Storage) QuerySnapshots(source string, start, end time.Time) ([]*Snapshot, error) {
+	rows, err := s.db.Query(
+		"SELECT timestamp, data FROM raw_snapshots WHERE source = ? AND timestamp >= ? AND timestamp <= ? ORDER BY timestamp ASC, id ASC",
+		source, start.Unix(), end.Unix(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("查询原始快照失败: %w", err)
+	}
+	defer rows.Close()
+
+	var snapshots []*Snapshot
+	for rows.Next() {
+		var ts int64
+		var data []byte
+		if err := rows.Scan(&ts, &data); err != nil {
+			return nil, fmt.Errorf("扫描行失败: %w", err)
+		}
+		content, err := decompressSnapshot(data)
+		if err != nil {
+			return nil, fmt.Errorf("解压 %s 的原始快照失败: %w", time.Unix(ts, 0).Format("2006-01-02 15:04:05"), err)
+		}
+		snapshots = append(snapshots, &Snapshot{Timestamp: time.Unix(ts, 0), Source: source, Content: content})
+	}
+	return snapshots, rows.Err()
+}
+
+// decompressSnapshot 解压 gzip 压缩的快照内容
+func decompressSnapshot(data []byte) (string, error) {
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+	defer zr.Close()
+	content, err := io.ReadAll(zr)
+	if err != nil {
+		return "", err
+	}
+	return string(content), nil
+}
@@ -1,11 +1,18 @@
 package storage
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"log"
+	"math"
+	"net/url"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
 	"time"
 
 	_ "modernc.org/sqlite"
@@ -23,8 +30,30 @@ const (
 	MetricTypeRandomIO  MetricType = "random_io"  // 随机 IO 延迟
 	MetricTypeMemory    MetricType = "memory"
 	MetricTypeCPULoad   MetricType = "cpu_load"
+	MetricTypeCPUTemp   MetricType = "cpu_temp"   // CPU 温度（°C，仅暴露 thermal zone 的机器）
+	MetricTypeCPUOnline MetricType = "cpu_online" // 在线 vCPU 数量（CPU 热插拔检测）
+	MetricTypeRunQueue  MetricType = "run_queue"  // 每 vCPU 可运行进程数（/proc/loadavg 第 4 列）
+	MetricTypeNetwork   MetricType = "network"    // 所选网卡累计收发字节数（主值为收发之和）
+	MetricTypeMemFault  MetricType = "mem_fault"  // 固定大小匿名内存全部缺页的耗时（ms）
+	MetricTypeSyncProbe MetricType = "sync_probe" // 4KB 覆写后 fdatasync 的中位耗时（ms），用于识别未真正落盘的 fsync
+	MetricTypeSchedStat MetricType = "schedstat"  // /proc/schedstat 累计运行队列等待时间（ns，主值为 run_delay 之和）
+	MetricTypeNUMA      MetricType = "numa"       // 各 NUMA 节点 numastat 累计页数（主值为 other_node，仅多节点机器）
+	// 迁移/挂起期间的 Steal 样本（一次性尖峰），单独存储，不计入 Steal 统计
+	MetricTypeCPUStealSuspend MetricType = "cpu_steal_suspend"
+	// I/O 卡顿事件：卡顿探测写入超过阈值时记录一条，值为耗时（ms）
+	MetricTypeIOStall MetricType = "io_stall"
+	// 采集失败事件：每次失败记录一条，extra.metric 为失败的指标类型，extra.error 为错误信息
+	MetricTypeCollectError MetricType = "collect_error"
 )
 
+// CustomMetricPrefix 自定义指标类型前缀
+const CustomMetricPrefix = "custom:"
+
+// CustomMetricType 返回自定义指标的类型名（custom:<name>）
+func CustomMetricType(name string) MetricType {
+	return MetricType(CustomMetricPrefix + name)
+}
+
 // Metric 指标数据
 type Metric struct {
 	ID        int64
@@ -34,35 +63,109 @@ type Metric struct {
 	Extra     map[string]interface{}
 }
 
+// 指标 extra 中的标记字段
+const (
+	FlagStartup = "startup" // 启动后首次采集的样本（系统可能尚未稳定）
+	// 自举样本：进程内没有 CPU 起点时临时采样 500ms 得到的 Steal/IOWait，窗口与采集间隔不可比，分析时始终排除
+	FlagBootstrap = "bootstrap"
+	// 迁移/挂起区间的 IOWait：与 Steal 尖峰同源的一次性伪影，保留供回放，分析与聚合时始终排除
+	FlagSuspend = "suspend"
+)
+
 // Storage 数据存储
 type Storage struct {
-	db *sql.DB
+	db     *sql.DB
+	dbPath string
+
+	// 维护锁：写入持有读锁，完整 VACUUM 持有写锁。
+	// VACUUM 可能持续数分钟，超过 busy_timeout 的写入会失败并使 WriteGuard 进入降级模式，
+	// 因此期间让写入排队等待，而非与之争抢数据库锁
+	maintMu sync.RWMutex
+
+	// 范围查询附加的过滤条件，排除带指定标记的样本（见 ExcludeFlagged）
+	flagFilter string
+}
+
+// sqliteDSN 构造数据库连接串：路径按 URI 规则转义（含 ? # % 等字符的路径不会被截断），
+// 并设置 busy_timeout 等待其他连接释放锁而非立即报错
+func sqliteDSN(path string) string {
+	return "file:" + url.PathEscape(path) + "?_pragma=busy_timeout(5000)"
+}
+
+// Options 数据库连接选项
+type Options struct {
+	WAL          bool // 使用 WAL 日志模式：读写互不阻塞，需定期检查点控制 -wal 文件大小
+	MaxOpenConns int  // 连接池最大连接数，0 表示不限制
+	MaxIdleConns int  // 连接池最大空闲连接数，0 表示使用 database/sql 默认值
 }
 
 // New 创建存储实例
-func New(dbPath string) (*Storage, error) {
+func New(dbPath string, opts Options) (*Storage, error) {
 	// 确保目录存在
 	dir := filepath.Dir(dbPath)
 	if err := os.MkdirAll(dir, 0755); err != nil {
 		return nil, fmt.Errorf("创建数据目录失败: %w", err)
 	}
 
-	db, err := sql.Open("sqlite", dbPath)
+	// 后台清理与采集写入并发进行，设置 busy_timeout 等待对方释放锁而非立即报错
+	db, err := sql.Open("sqlite", sqliteDSN(dbPath))
 	if err != nil {
 		return nil, fmt.Errorf("打开数据库失败: %w", err)
 	}
+	if opts.MaxOpenConns > 0 {
+		db.SetMaxOpenConns(opts.MaxOpenConns)
+	}
+	if opts.MaxIdleConns > 0 {
+		db.SetMaxIdleConns(opts.MaxIdleConns)
+	}
 
-	s := &Storage{db: db}
+	s := &Storage{db: db, dbPath: dbPath}
 	if err := s.init(); err != nil {
 		db.Close()
 		return nil, err
 	}
+	if opts.WAL {
+		if _, err := db.Exec("PRAGMA journal_mode = WAL"); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("设置 journal_mode 失败: %w", err)
+		}
+	} else {
+		s.leaveWAL()
+	}
 
 	return s, nil
 }
 
+// leaveWAL 日志模式持久化在数据库文件中：关闭 WAL 后若数据库仍处于 WAL 模式，切回默认的 DELETE 模式
+// 切换需要独占数据库，其他进程（如守护进程）正在使用时失败，仅记录日志，下次打开时重试
+func (s *Storage) leaveWAL() {
+	var mode string
+	if err := s.db.QueryRow("PRAGMA journal_mode").Scan(&mode); err != nil || !strings.EqualFold(mode, "wal") {
+		return
+	}
+	if _, err := s.db.Exec("PRAGMA journal_mode = DELETE"); err != nil {
+		log.Printf("数据库仍处于 WAL 模式，切回 DELETE 失败（可能有其他进程在使用）: %v", err)
+	}
+}
+
+// CheckpointWAL 执行 wal_checkpoint(TRUNCATE)：将 WAL 中的页写回数据库文件并截断 -wal 文件
+// busy 为 true 表示有读写事务占用，本次未能完整检查点；frames 为检查点前 WAL 中的页数
+// 非 WAL 模式下为空操作
+func (s *Storage) CheckpointWAL() (busy bool, frames int, err error) {
+	var busyFlag, logFrames, checkpointed int
+	if err := s.db.QueryRow("PRAGMA wal_checkpoint(TRUNCATE)").Scan(&busyFlag, &logFrames, &checkpointed); err != nil {
+		return false, 0, fmt.Errorf("WAL 检查点失败: %w", err)
+	}
+	return busyFlag != 0, logFrames, nil
+}
+
 // init 初始化数据库表
 func (s *Storage) init() error {
+	// auto_vacuum 只能在建表前设置，对已存在的数据库无效（Reclaim 中通过完整 VACUUM 转换）
+	if _, err := s.db.Exec("PRAGMA auto_vacuum = INCREMENTAL"); err != nil {
+		return fmt.Errorf("设置 auto_vacuum 失败: %w", err)
+	}
+
 	schema := `
 	CREATE TABLE IF NOT EXISTS metrics (
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
@@ -74,6 +177,33 @@ func (s *Storage) init() error {
 	
 	CREATE INDEX IF NOT EXISTS idx_metrics_time ON metrics(timestamp);
 	CREATE INDEX IF NOT EXISTS idx_metrics_type ON metrics(metric_type, timestamp);
+
+	CREATE TABLE IF NOT EXISTS raw_snapshots (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		timestamp INTEGER NOT NULL,
+		source TEXT NOT NULL,
+		data BLOB NOT NULL
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_raw_snapshots ON raw_snapshots(source, timestamp);
+
+	CREATE TABLE IF NOT EXISTS report_deliveries (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		timestamp INTEGER NOT NULL,
+		period TEXT NOT NULL,
+		report_at INTEGER NOT NULL,
+		reporter TEXT NOT NULL,
+		success INTEGER NOT NULL,
+		error TEXT
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_report_deliveries ON report_deliveries(timestamp);
+
+	CREATE TABLE IF NOT EXISTS state (
+		key TEXT PRIMARY KEY,
+		value TEXT NOT NULL,
+		updated_at INTEGER NOT NULL
+	);
 	`
 
 	_, err := s.db.Exec(schema)
@@ -84,21 +214,60 @@ func (s *Storage) init() error {
 	return nil
 }
 
+// ExcludeFlagged 设置范围查询（Query、QueryValuesOnly 等）与 GetLatestMetric 排除 extra 中带有指定标记的样本
+// 标记由调用方在写入时设置（如 FlagStartup），仅影响读取，不影响写入和清理
+func (s *Storage) ExcludeFlagged(flags ...string) {
+	var filter strings.Builder
+	for _, flag := range flags {
+		// extra 为空时 json_extract 会报错，先判空
+		fmt.Fprintf(&filter, " AND (CASE WHEN extra IS NULL OR extra = '' THEN 1 ELSE json_extract(extra, '$.%s') IS NULL END)", flag)
+	}
+	s.flagFilter = filter.String()
+}
+
 // Close 关闭数据库连接
 func (s *Storage) Close() error {
 	return s.db.Close()
 }
 
+// Validate 校验指标数据
+// NaN/Inf 会在 avg、percentile 中传播，一个坏样本就会污染整个周期的统计，必须在写入前拦截
+func (m *Metric) Validate() error {
+	if m.Type == "" {
+		return fmt.Errorf("指标类型为空")
+	}
+	if m.Timestamp.IsZero() {
+		return fmt.Errorf("指标 %s 时间戳为空", m.Type)
+	}
+	if math.IsNaN(m.Value) || math.IsInf(m.Value, 0) {
+		return fmt.Errorf("指标 %s 数值无效: %v", m.Type, m.Value)
+	}
+	return nil
+}
+
+// encodeExtra 序列化 extra 字段
+func encodeExtra(m *Metric) (string, error) {
+	if m.Extra == nil {
+		return "", nil
+	}
+	extraJSON, err := json.Marshal(m.Extra)
+	if err != nil {
+		return "", fmt.Errorf("序列化 extra 失败: %w", err)
+	}
+	return string(extraJSON), nil
+}
+
 // Save 保存指标数据
 func (s *Storage) Save(m *Metric) error {
-	var extraJSON []byte
-	var err error
+	if err := m.Validate(); err != nil {
+		return fmt.Errorf("保存指标失败: %w", err)
+	}
+	s.maintMu.RLock()
+	defer s.maintMu.RUnlock()
 
-	if m.Extra != nil {
-		extraJSON, err = json.Marshal(m.Extra)
-		if err != nil {
-			return fmt.Errorf("序列化 extra 失败: %w", err)
-		}
+	extraJSON, err := encodeExtra(m)
+	if err != nil {
+		return err
 	}
 
 	_, err = s.db.Exec(
@@ -106,7 +275,7 @@ func (s *Storage) Save(m *Metric) error {
 		m.Timestamp.Unix(),
 		string(m.Type),
 		m.Value,
-		string(extraJSON),
+		extraJSON,
 	)
 
 	if err != nil {
@@ -116,13 +285,71 @@ func (s *Storage) Save(m *Metric) error {
 	return nil
 }
 
+// SaveBatch 在同一事务中保存多条指标
+// 任一指标校验失败时整批不写入，避免同一次采集只落库一半
+func (s *Storage) SaveBatch(metrics []*Metric) error {
+	for _, m := range metrics {
+		if err := m.Validate(); err != nil {
+			return fmt.Errorf("批量保存指标失败: %w", err)
+		}
+	}
+	s.maintMu.RLock()
+	defer s.maintMu.RUnlock()
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("开启事务失败: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare("INSERT INTO metrics (timestamp, metric_type, value, extra) VALUES (?, ?, ?, ?)")
+	if err != nil {
+		return fmt.Errorf("准备语句失败: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, m := range metrics {
+		extraJSON, err := encodeExtra(m)
+		if err != nil {
+			return err
+		}
+		if _, err := stmt.Exec(m.Timestamp.Unix(), string(m.Type), m.Value, extraJSON); err != nil {
+			return fmt.Errorf("批量保存指标失败: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("提交事务失败: %w", err)
+	}
+	return nil
+}
+
 // Query 查询指定时间范围和类型的指标
 func (s *Storage) Query(metricType MetricType, start, end time.Time) ([]*Metric, error) {
+	return s.QueryPage(metricType, start, end, QueryOptions{})
+}
+
+// QueryOptions 分页查询选项
+type QueryOptions struct {
+	Limit  int // 最多返回条数，0 表示不限制
+	Offset int // 跳过的条数，配合 Limit 翻页
+}
+
+// QueryPage 分页查询指定时间范围和类型的指标（按时间升序）
+// 大范围查询应配合 QueryCount 先检查规模，避免一次性加载过多数据
+func (s *Storage) QueryPage(metricType MetricType, start, end time.Time, opts QueryOptions) ([]*Metric, error) {
+	limit := -1 // SQLite 中 LIMIT -1 表示不限制
+	if opts.Limit > 0 {
+		limit = opts.Limit
+	}
+
 	rows, err := s.db.Query(
-		"SELECT id, timestamp, metric_type, value, extra FROM metrics WHERE metric_type = ? AND timestamp >= ? AND timestamp <= ? ORDER BY timestamp ASC",
+		"SELECT id, timestamp, metric_type, value, extra FROM metrics WHERE metric_type = ? AND timestamp >= ? AND timestamp <= ?"+s.flagFilter+" ORDER BY timestamp ASC, id ASC LIMIT ? OFFSET ?",
 		string(metricType),
 		start.Unix(),
 		end.Unix(),
+		limit,
+		opts.Offset,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("查询指标失败: %w", err)
@@ -156,23 +383,379 @@ func (s *Storage) Query(metricType MetricType, start, end time.Time) ([]*Metric,
 	return metrics, nil
 }
 
-// Cleanup 清理过期数据
+// QueryValuesOnly 仅查询主值和时间戳，跳过 extra 反序列化
+// 用于只需要主值的统计路径，在大范围查询时显著减少内存分配
+func (s *Storage) QueryValuesOnly(metricType MetricType, start, end time.Time) ([]float64, []time.Time, error) {
+	rows, err := s.db.Query(
+		"SELECT timestamp, value FROM metrics WHERE metric_type = ? AND timestamp >= ? AND timestamp <= ?"+s.flagFilter+" ORDER BY timestamp ASC",
+		string(metricType),
+		start.Unix(),
+		end.Unix(),
+	)
+	if err != nil {
+		return nil, nil, fmt.Errorf("查询指标失败: %w", err)
+	}
+	defer rows.Close()
+
+	var values []float64
+	var times []time.Time
+	for rows.Next() {
+		var ts int64
+		var v float64
+		if err := rows.Scan(&ts, &v); err != nil {
+			return nil, nil, fmt.Errorf("扫描行失败: %w", err)
+		}
+		values = append(values, v)
+		times = append(times, time.Unix(ts, 0))
+	}
+
+	return values, times, nil
+}
+
+// QueryValuesWithRepeats 查询主值、时间戳与去重跳过计数（ExtraRepeats），跳过完整 extra 反序列化
+// 用于开启去重的类型，repeats[i] 为第 i 行之前跳过的样本数
+func (s *Storage) QueryValuesWithRepeats(metricType MetricType, start, end time.Time) ([]float64, []time.Time, []int, error) {
+	rows, err := s.db.Query(
+		"SELECT timestamp, value, CASE WHEN extra IS NULL OR extra = '' THEN 0 ELSE COALESCE(json_extract(extra, '$."+ExtraRepeats+"'), 0) END "+
+			"FROM metrics WHERE metric_type = ? AND timestamp >= ? AND timestamp <= ?"+s.flagFilter+" ORDER BY timestamp ASC, id ASC",
+		string(metricType),
+		start.Unix(),
+		end.Unix(),
+	)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("查询指标失败: %w", err)
+	}
+	defer rows.Close()
+
+	var values []float64
+	var times []time.Time
+	var repeats []int
+	for rows.Next() {
+		var ts int64
+		var v float64
+		var n int
+		if err := rows.Scan(&ts, &v, &n); err != nil {
+			return nil, nil, nil, fmt.Errorf("扫描行失败: %w", err)
+		}
+		values = append(values, v)
+		times = append(times, time.Unix(ts, 0))
+		repeats = append(repeats, n)
+	}
+
+	return values, times, repeats, nil
+}
+
+// QueryCount 统计指定时间范围和类型的指标条数（走索引，开销很低）
+func (s *Storage) QueryCount(metricType MetricType, start, end time.Time) (int64, error) {
+	var count int64
+	err := s.db.QueryRow(
+		"SELECT COUNT(*) FROM metrics WHERE metric_type = ? AND timestamp >= ? AND timestamp <= ?"+s.flagFilter,
+		string(metricType),
+		start.Unix(),
+		end.Unix(),
+	).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("统计指标条数失败: %w", err)
+	}
+	return count, nil
+}
+
+// QueryBucketed 按固定时间桶聚合主值（桶内取平均），时间为桶起点
+// 用于样本量过大时以有界内存获取时间序列
+func (s *Storage) QueryBucketed(metricType MetricType, start, end time.Time, bucket time.Duration) ([]float64, []time.Time, error) {
+	width := int64(bucket / time.Second)
+	if width < 1 {
+		width = 1
+	}
+
+	// 去重跳过的样本记在下一行的 repeats 中，按上一行的值计入所在桶的均值（与 expandRepeats 一致）；
+	// 窗口内第一行之前跳过的样本其值在窗口外，忽略
+	rows, err := s.db.Query(
+		"SELECT bucket, SUM(value + CASE WHEN prev IS NULL THEN 0 ELSE prev * rep END) / SUM(1 + CASE WHEN prev IS NULL THEN 0 ELSE rep END) FROM ("+
+			"SELECT (timestamp / ?) * ? AS bucket, value, LAG(value) OVER (ORDER BY timestamp, id) AS prev, "+
+			"CASE WHEN extra IS NULL OR extra = '' THEN 0 ELSE COALESCE(json_extract(extra, '$."+ExtraRepeats+"'), 0) END AS rep "+
+			"FROM metrics WHERE metric_type = ? AND timestamp >= ? AND timestamp <= ?"+s.flagFilter+
+			") GROUP BY bucket ORDER BY bucket ASC",
+		width, width,
+		string(metricType),
+		start.Unix(),
+		end.Unix(),
+	)
+	if err != nil {
+		return nil, nil, fmt.Errorf("聚合查询指标失败: %w", err)
+	}
+	defer rows.Close()
+
+	var values []float64
+	var times []time.Time
+	for rows.Next() {
+		var ts int64
+		var v float64
+		if err := rows.Scan(&ts, &v); err != nil {
+			return nil, nil, fmt.Errorf("扫描行失败: %w", err)
+		}
+		values = append(values, v)
+		times = append(times, time.Unix(ts, 0))
+	}
+
+	return values, times, nil
+}
+
+// Histogram 数值直方图（等宽分桶），用于大样本下近似计算分位数
+type Histogram struct {
+	Width   float64   // 桶宽
+	Bounds  []float64 // 各桶下界（升序）
+	Counts  []int64   // 各桶样本数
+	Total   int64     // 样本总数
+	Sum     float64   // 样本总和（用于精确平均值）
+	MaxSeen float64   // 最大值
+}
+
+// Avg 精确平均值
+func (h *Histogram) Avg() float64 {
+	if h.Total == 0 {
+		return 0
+	}
+	return h.Sum / float64(h.Total)
+}
+
+// Percentile 近似分位数（误差不超过一个桶宽），取所在桶的上界并以最大值封顶
+func (h *Histogram) Percentile(p float64) float64 {
+	if h.Total == 0 {
+		return 0
+	}
+	rank := int64(math.Ceil(p / 100 * float64(h.Total)))
+	if rank < 1 {
+		rank = 1
+	}
+	var cumulative int64
+	for i, c := range h.Counts {
+		cumulative += c
+		if cumulative >= rank {
+			return math.Min(h.Bounds[i]+h.Width, h.MaxSeen)
+		}
+	}
+	return h.MaxSeen
+}
+
+// QueryHistogram 在数据库中按等宽分桶统计主值分布，仅返回桶计数而非原始样本
+func (s *Storage) QueryHistogram(metricType MetricType, start, end time.Time, width float64) (*Histogram, error) {
+	if width <= 0 {
+		return nil, fmt.Errorf("直方图桶宽必须为正数")
+	}
+
+	h := &Histogram{Width: width}
+	var maxSeen sql.NullFloat64
+	var sum sql.NullFloat64
+	err := s.db.QueryRow(
+		"SELECT COUNT(*), SUM(value), MAX(value) FROM metrics WHERE metric_type = ? AND timestamp >= ? AND timestamp <= ?"+s.flagFilter,
+		string(metricType), start.Unix(), end.Unix(),
+	).Scan(&h.Total, &sum, &maxSeen)
+	if err != nil {
+		return nil, fmt.Errorf("统计直方图失败: %w", err)
+	}
+	h.Sum = sum.Float64
+	h.MaxSeen = maxSeen.Float64
+
+	rows, err := s.db.Query(
+		"SELECT CAST(value / ? AS INTEGER) AS bucket, COUNT(*) FROM metrics WHERE metric_type = ? AND timestamp >= ? AND timestamp <= ?"+s.flagFilter+" GROUP BY bucket ORDER BY bucket ASC",
+		width,
+		string(metricType), start.Unix(), end.Unix(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("统计直方图失败: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var bucket, count int64
+		if err := rows.Scan(&bucket, &count); err != nil {
+			return nil, fmt.Errorf("扫描行失败: %w", err)
+		}
+		h.Bounds = append(h.Bounds, float64(bucket)*width)
+		h.Counts = append(h.Counts, count)
+	}
+
+	return h, nil
+}
+
+// EarliestTimestamp 获取数据库中最早的采集时间，无数据时返回零值
+func (s *Storage) EarliestTimestamp() (time.Time, error) {
+	var ts sql.NullInt64
+	if err := s.db.QueryRow("SELECT MIN(timestamp) FROM metrics").Scan(&ts); err != nil {
+		return time.Time{}, fmt.Errorf("查询最早采集时间失败: %w", err)
+	}
+	if !ts.Valid {
+		return time.Time{}, nil
+	}
+	return time.Unix(ts.Int64, 0), nil
+}
+
+// ListMetricTypes 列出时间范围内出现过的、以指定前缀开头的指标类型
+func (s *Storage) ListMetricTypes(prefix string, start, end time.Time) ([]MetricType, error) {
+	rows, err := s.db.Query(
+		"SELECT DISTINCT metric_type FROM metrics WHERE metric_type LIKE ? AND timestamp >= ? AND timestamp <= ? ORDER BY metric_type",
+		prefix+"%",
+		start.Unix(),
+		end.Unix(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("查询指标类型失败: %w", err)
+	}
+	defer rows.Close()
+
+	var types []MetricType
+	for rows.Next() {
+		var t string
+		if err := rows.Scan(&t); err != nil {
+			return nil, fmt.Errorf("扫描行失败: %w", err)
+		}
+		types = append(types, MetricType(t))
+	}
+
+	return types, nil
+}
+
+const (
+	cleanupBatchSize   = 5000                  // 每批删除的行数
+	cleanupBatchPause  = 50 * time.Millisecond // 批次间暂停，让出写锁给采集写入
+	cleanupLogInterval = 20                    // 每删除多少批记录一次进度
+)
+
+// Cleanup 清理过期数据，返回删除的总行数
+// 分批删除（每批 cleanupBatchSize 行，批次间短暂暂停），避免单条大 DELETE
+// 长时间持有写锁阻塞并发的采集写入，反过来干扰测量
 func (s *Storage) Cleanup(retentionDays int) (int64, error) {
 	cutoff := time.Now().AddDate(0, 0, -retentionDays).Unix()
 
-	result, err := s.db.Exec("DELETE FROM metrics WHERE timestamp < ?", cutoff)
+	total, err := s.deleteMetricsBatched("timestamp < ?", cutoff)
 	if err != nil {
-		return 0, fmt.Errorf("清理过期数据失败: %w", err)
+		return total, fmt.Errorf("清理过期数据失败（已删除 %d 条）: %w", total, err)
 	}
 
+	// 原始快照与投递记录同样按 retention_days 清理（行数很少，单条 DELETE 即可）
+	result, err := s.db.Exec("DELETE FROM raw_snapshots WHERE timestamp < ?", cutoff)
+	if err != nil {
+		return total, fmt.Errorf("清理过期原始快照失败: %w", err)
+	}
 	deleted, _ := result.RowsAffected()
-	return deleted, nil
+	total += deleted
+
+	result, err = s.db.Exec("DELETE FROM report_deliveries WHERE timestamp < ?", cutoff)
+	if err != nil {
+		return total, fmt.Errorf("清理过期投递记录失败: %w", err)
+	}
+	deleted, _ = result.RowsAffected()
+	return total + deleted, nil
+}
+
+// deleteMetricsBatched 分批删除 metrics 中满足 cond 的行，返回删除的行数
+func (s *Storage) deleteMetricsBatched(cond string, args ...interface{}) (int64, error) {
+	query := "DELETE FROM metrics WHERE id IN (SELECT id FROM metrics WHERE " + cond + " LIMIT ?)"
+	args = append(args, cleanupBatchSize)
+
+	var total int64
+	for batch := 1; ; batch++ {
+		result, err := s.db.Exec(query, args...)
+		if err != nil {
+			return total, err
+		}
+
+		deleted, _ := result.RowsAffected()
+		total += deleted
+		if deleted < cleanupBatchSize {
+			return total, nil
+		}
+
+		if batch%cleanupLogInterval == 0 {
+			log.Printf("删除数据中，已删除 %d 条...", total)
+		}
+		time.Sleep(cleanupBatchPause)
+	}
+}
+
+// reclaimFreeRatio 空闲页占比超过该值时才执行完整 VACUUM
+const reclaimFreeRatio = 0.2
+
+// Reclaim 回收已删除数据占用的磁盘空间，返回回收的字节数
+// SQLite 删除行后不会缩小文件：
+//   - auto_vacuum=INCREMENTAL 的数据库执行 incremental_vacuum，开销小，无需独占
+//   - 旧数据库（auto_vacuum=NONE）仅在空闲页占比较高时执行完整 VACUUM，
+//     完整 VACUUM 需要独占访问和约等于数据库大小的临时空间，空间不足时跳过；
+//     VACUUM 同时会把数据库转换为 INCREMENTAL 模式，之后只需增量回收。
+//     VACUUM 期间持有维护锁，本进程的写入排队等待其完成（见 maintMu）
+func (s *Storage) Reclaim() (int64, error) {
+	var autoVacuum, pageCount, freeCount, pageSize int64
+	if err := s.db.QueryRow("PRAGMA auto_vacuum").Scan(&autoVacuum); err != nil {
+		return 0, fmt.Errorf("读取 auto_vacuum 失败: %w", err)
+	}
+	if err := s.db.QueryRow("PRAGMA page_count").Scan(&pageCount); err != nil {
+		return 0, fmt.Errorf("读取 page_count 失败: %w", err)
+	}
+	if err := s.db.QueryRow("PRAGMA freelist_count").Scan(&freeCount); err != nil {
+		return 0, fmt.Errorf("读取 freelist_count 失败: %w", err)
+	}
+	if err := s.db.QueryRow("PRAGMA page_size").Scan(&pageSize); err != nil {
+		return 0, fmt.Errorf("读取 page_size 失败: %w", err)
+	}
+
+	if freeCount == 0 || pageCount == 0 {
+		return 0, nil
+	}
+
+	const autoVacuumIncremental = 2
+	if autoVacuum == autoVacuumIncremental {
+		// incremental_vacuum 每步释放一页，需要遍历完全部结果行才会回收所有空闲页
+		rows, err := s.db.Query("PRAGMA incremental_vacuum")
+		if err != nil {
+			return 0, fmt.Errorf("增量回收失败: %w", err)
+		}
+		for rows.Next() {
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return 0, fmt.Errorf("增量回收失败: %w", err)
+		}
+	} else {
+		if float64(freeCount)/float64(pageCount) < reclaimFreeRatio {
+			return 0, nil
+		}
+		// VACUUM 需要约等于数据库大小的临时空间
+		dbSize := uint64(pageCount * pageSize)
+		var fs syscall.Statfs_t
+		if err := syscall.Statfs(filepath.Dir(s.dbPath), &fs); err == nil {
+			if uint64(fs.Bavail)*uint64(fs.Bsize) < dbSize {
+				return 0, fmt.Errorf("磁盘剩余空间不足以执行 VACUUM（需要约 %d 字节）", dbSize)
+			}
+		}
+		s.maintMu.Lock()
+		defer s.maintMu.Unlock()
+		// auto_vacuum 设置只对当前连接生效，必须与 VACUUM 在同一连接上执行
+		conn, err := s.db.Conn(context.Background())
+		if err != nil {
+			return 0, fmt.Errorf("获取数据库连接失败: %w", err)
+		}
+		defer conn.Close()
+		if _, err := conn.ExecContext(context.Background(), "PRAGMA auto_vacuum = INCREMENTAL"); err != nil {
+			return 0, fmt.Errorf("设置 auto_vacuum 失败: %w", err)
+		}
+		if _, err := conn.ExecContext(context.Background(), "VACUUM"); err != nil {
+			return 0, fmt.Errorf("VACUUM 失败: %w", err)
+		}
+	}
+
+	var newPageCount int64
+	if err := s.db.QueryRow("PRAGMA page_count").Scan(&newPageCount); err != nil {
+		return 0, fmt.Errorf("读取 page_count 失败: %w", err)
+	}
+
+	return (pageCount - newPageCount) * pageSize, nil
 }
 
 // GetLatestMetric 获取最新的指标
 func (s *Storage) GetLatestMetric(metricType MetricType) (*Metric, error) {
 	row := s.db.QueryRow(
-		"SELECT id, timestamp, metric_type, value, extra FROM metrics WHERE metric_type = ? ORDER BY timestamp DESC LIMIT 1",
+		"SELECT id, timestamp, metric_type, value, extra FROM metrics WHERE metric_type = ?"+s.flagFilter+" ORDER BY timestamp DESC LIMIT 1",
 		string(metricType),
 	)
 
@@ -197,3 +780,30 @@ func (s *Storage) GetLatestMetric(metricType MetricType) (*Metric, error) {
 
 	return m, nil
 }
+
+// GetState 读取持久化的状态值，不存在时 ok 为 false
+func (s *Storage) GetState(key string) (value string, updatedAt time.Time, ok bool, err error) {
+	var ts int64
+	err = s.db.QueryRow("SELECT value, updated_at FROM state WHERE key = ?", key).Scan(&value, &ts)
+	if err == sql.ErrNoRows {
+		return "", time.Time{}, false, nil
+	}
+	if err != nil {
+		return "", time.Time{}, false, fmt.Errorf("读取状态失败: %w", err)
+	}
+	return value, time.Unix(ts, 0), true, nil
+}
+
+// SetState 写入持久化的状态值（不受数据保留期清理影响）
+func (s *Storage) SetState(key, value string) error {
+	s.maintMu.RLock()
+	defer s.maintMu.RUnlock()
+	_, err := s.db.Exec(
+		"INSERT INTO state (key, value, updated_at) VALUES (?, ?, ?) ON CONFLICT(key) DO UPDATE SET value = excluded.value, updated_at = excluded.updated_at",
+		key, value, time.Now().Unix(),
+	)
+	if err != nil {
+		return fmt.Errorf("写入状态失败: %w", err)
+	}
+	return nil
+}